@@ -0,0 +1,344 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package checker finds errors in ISLisp source files without evaluating
+// them: references to undefined functions and variables, calls with the wrong
+// number of arguments, malformed special forms, and go and return-from forms
+// outside of the tagbody or block they refer to.
+//
+// The forms are read in the runtime of the builtins, so that only the names
+// defined by the builtins or by the files checked are known. The forms
+// defmodule and in-module are the only ones evaluated, as they change how the
+// symbols that follow them are read.
+package checker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/reader/parser"
+	"github.com/islisp-dev/iris/reader/tokenizer"
+)
+
+// Diagnostic is an error found in a form.
+type Diagnostic struct {
+	Location *core.Location
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%v: %s", d.Location, d.Message)
+}
+
+// signature is the number of arguments a function requires and whether it
+// accepts more.
+type signature struct {
+	required int
+	rest     bool
+}
+
+// Checker collects the definitions of the files added, then checks their
+// forms against them.
+type Checker struct {
+	env         core.Environment
+	toplevel    []core.Instance
+	functions   map[string]signature
+	macros      map[string]bool
+	variables   map[string]bool
+	diagnostics []Diagnostic
+}
+
+// New returns a Checker knowing the builtins only.
+func New() *Checker {
+	return &Checker{
+		env:       lib.NewRuntime(),
+		functions: map[string]signature{},
+		macros:    map[string]bool{},
+		variables: map[string]bool{},
+	}
+}
+
+// AddFile adds the forms of the file at path.
+func (c *Checker) AddFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	c.Add(path, f)
+	return nil
+}
+
+// Add adds the forms read from r, a file named name. The forms are read up to
+// the first read error, which is reported.
+func (c *Checker) Add(name string, r io.Reader) {
+	t := tokenizer.NewBufferedTokenReader(r)
+	t.File = name
+	defer c.env.Runtime.SetCurrentModule(c.env.Runtime.CurrentModule())
+	for {
+		form, err := parser.Parse(c.env, t)
+		if err != nil {
+			if !core.InstanceOf(core.EndOfStreamClass, err) {
+				c.report(nil, err)
+			}
+			return
+		}
+		if operator(form) == "DEFMODULE" || operator(form) == "IN-MODULE" {
+			if _, err := lib.Eval(c.env, form); err != nil {
+				c.report(form, err)
+			}
+			continue
+		}
+		c.define(form)
+		c.toplevel = append(c.toplevel, form)
+	}
+}
+
+// report adds a diagnostic for the condition err signaled by form.
+func (c *Checker) report(form, err core.Instance) {
+	loc := core.LocationOf(form)
+	for _, frame := range core.Stacktrace(err) {
+		if frame.Location != nil {
+			loc = frame.Location
+			break
+		}
+	}
+	c.errorf(loc, "%s", lib.ReportString(c.env, err))
+}
+
+func (c *Checker) errorf(loc *core.Location, format string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{loc, fmt.Sprintf(format, args...)})
+}
+
+// Check checks the forms added and returns the diagnostics, in the order of
+// the files added and of the locations in each file.
+func (c *Checker) Check() []Diagnostic {
+	for _, form := range c.toplevel {
+		c.form(nil, form)
+	}
+	files := map[string]int{}
+	for _, d := range c.diagnostics {
+		if d.Location != nil {
+			if _, ok := files[d.Location.File]; !ok {
+				files[d.Location.File] = len(files)
+			}
+		}
+	}
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i].Location, c.diagnostics[j].Location
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if a.File != b.File {
+			return files[a.File] < files[b.File]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	diagnostics := c.diagnostics
+	c.diagnostics = nil
+	return diagnostics
+}
+
+// define records the definitions made by a top level form.
+func (c *Checker) define(form core.Instance) {
+	args := arguments(form)
+	if len(args) < 2 {
+		return
+	}
+	name, ok := args[0].(core.Symbol)
+	if !ok {
+		return
+	}
+	switch operator(form) {
+	case "DEFUN", "DEFGENERIC":
+		if lib.CheckSyntax(c.env, form) == nil {
+			required, rest := core.LambdaListArity(args[1])
+			c.functions[name.String()] = signature{required, rest}
+		}
+	case "DEFMACRO":
+		c.macros[name.String()] = true
+	case "DEFGLOBAL", "DEFCONSTANT":
+		c.variables[name.String()] = true
+	case "DEFCLASS":
+		if len(args) < 3 {
+			return
+		}
+		specs, _ := elements(args[2])
+		for _, spec := range specs {
+			options := arguments(spec)
+			for i := 0; i+1 < len(options); i += 2 {
+				accessor := options[i+1].String()
+				switch options[i].String() {
+				case ":READER", ":BOUNDP":
+					c.functions[accessor] = signature{1, false}
+				case ":WRITER":
+					c.functions[accessor] = signature{2, false}
+				case ":ACCESSOR":
+					c.functions[accessor] = signature{1, false}
+				}
+			}
+		}
+	}
+}
+
+// operator returns the name of the operator of a compound form, or "".
+func operator(form core.Instance) string {
+	if cons, ok := form.(*core.Cons); ok {
+		if symbol, ok := cons.Car.(core.Symbol); ok {
+			return symbol.String()
+		}
+	}
+	return ""
+}
+
+// elements returns the elements of a proper list.
+func elements(list core.Instance) ([]core.Instance, bool) {
+	elements := []core.Instance{}
+	for list != core.Nil {
+		cons, ok := list.(*core.Cons)
+		if !ok {
+			return nil, false
+		}
+		elements = append(elements, cons.Car)
+		list = cons.Cdr
+	}
+	return elements, true
+}
+
+// arguments returns the elements of a compound form after the operator, or
+// nil if form is not a proper list.
+func arguments(form core.Instance) []core.Instance {
+	if cons, ok := form.(*core.Cons); ok {
+		args, _ := elements(cons.Cdr)
+		return args
+	}
+	return nil
+}
+
+// describeArity describes the number of arguments of a signature.
+func describeArity(s signature) string {
+	plural := "s"
+	if s.required == 1 {
+		plural = ""
+	}
+	if s.rest {
+		return fmt.Sprintf("at least %d argument%s", s.required, plural)
+	}
+	return fmt.Sprintf("%d argument%s", s.required, plural)
+}
+
+// call checks the number of arguments of a call to a function of signature s.
+func (c *Checker) call(form core.Instance, s signature) {
+	n := len(arguments(form))
+	if n < s.required || (!s.rest && n > s.required) {
+		c.errorf(core.LocationOf(form), "%v takes %s but is given %d", form.(*core.Cons).Car, describeArity(s), n)
+	}
+}
+
+// lookupFunction returns the signature of the global function name.
+func (c *Checker) lookupFunction(name core.Instance) (signature, bool) {
+	if s, ok := c.functions[name.String()]; ok {
+		return s, true
+	}
+	f, ok := c.env.Function.Get(name)
+	if !ok {
+		return signature{}, false
+	}
+	if f, ok := f.(interface{ Arity() (int, bool) }); ok {
+		required, rest := f.Arity()
+		return signature{required, rest}, true
+	}
+	return signature{0, true}, true
+}
+
+// globalVariable reports whether name is a global variable or a constant.
+func (c *Checker) globalVariable(name core.Instance) bool {
+	if c.variables[name.String()] {
+		return true
+	}
+	if _, ok := c.env.Variable.Get(name); ok {
+		return true
+	}
+	_, ok := c.env.Constant.Get(name)
+	return ok
+}
+
+// form checks a form evaluated in the scope s.
+func (c *Checker) form(s *scope, form core.Instance) {
+	switch f := form.(type) {
+	case core.Symbol:
+		c.variable(s, f)
+	case *core.Cons:
+		c.compound(s, f)
+	}
+}
+
+// forms checks the forms evaluated in order in the scope s.
+func (c *Checker) forms(s *scope, forms []core.Instance) {
+	for _, form := range forms {
+		c.form(s, form)
+	}
+}
+
+// variable checks a reference to the variable name.
+func (c *Checker) variable(s *scope, name core.Symbol) {
+	if strings.HasPrefix(name.String(), ":") || strings.HasPrefix(name.String(), "&") {
+		return
+	}
+	if _, ok := s.lookup(variableSpace, name); ok || c.globalVariable(name) {
+		return
+	}
+	c.errorf(core.LocationOf(name), "undefined variable %v", name)
+}
+
+// compound checks a compound form.
+func (c *Checker) compound(s *scope, form *core.Cons) {
+	if _, ok := elements(form); !ok {
+		c.errorf(core.LocationOf(form), "%v is not a proper list", form)
+		return
+	}
+	args := arguments(form)
+	switch operator := form.Car.(type) {
+	case core.Symbol:
+		if b, ok := s.lookup(functionSpace, operator); ok {
+			c.call(form, b.signature)
+			c.forms(s, args)
+			return
+		}
+		if _, ok := c.env.Special.Get(operator); ok {
+			c.special(s, form, args)
+			return
+		}
+		if _, ok := c.env.Macro.Get(operator); ok || c.macros[operator.String()] {
+			return
+		}
+		if signature, ok := c.lookupFunction(operator); ok {
+			c.call(form, signature)
+		} else {
+			c.errorf(core.LocationOf(operator), "undefined function %v", operator)
+		}
+		c.forms(s, args)
+	case *core.Cons:
+		if operator.Car.String() != "LAMBDA" {
+			c.errorf(core.LocationOf(operator), "%v is not a function name or a lambda expression", operator)
+			return
+		}
+		c.form(s, operator)
+		if lib.CheckSyntax(c.env, operator) == nil {
+			required, rest := core.LambdaListArity(arguments(operator)[0])
+			c.call(form, signature{required, rest})
+		}
+		c.forms(s, args)
+	default:
+		c.errorf(core.LocationOf(form), "%v is not a function name or a lambda expression", form.Car)
+	}
+}
@@ -0,0 +1,107 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package checker
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func check(files ...string) []string {
+	c := New()
+	for i, src := range files {
+		c.Add(fmt.Sprintf("%d.lsp", i+1), strings.NewReader(src))
+	}
+	got := []string{}
+	for _, d := range c.Check() {
+		got = append(got, d.String())
+	}
+	return got
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`(defun f (x &rest y) (list x y)) (f) (f 1 2 3)`, []string{
+			"1.lsp:1:34: F takes at least 1 argument but is given 0",
+		}},
+		{`(car 1 2) (cons 1)`, []string{
+			"1.lsp:1:1: CAR takes 1 argument but is given 2",
+			"1.lsp:1:11: CONS takes 2 arguments but is given 1",
+		}},
+		{"(defun f ()\n  (g x))", []string{
+			"1.lsp:2:4: undefined function G",
+			"1.lsp:2:6: undefined variable X",
+		}},
+		{`(let ((x 1) y) x)`, []string{
+			"1.lsp:1:13: Y is invalid in the LET form, whose syntax is (let ((var form)*) body-form*)",
+		}},
+		{`(let* ((x 1) (y x)) y) (let ((x 1) (y x)) y)`, []string{
+			"1.lsp:1:39: undefined variable X",
+		}},
+		{`(flet ((f (x) (f x))) (f 1 2))`, []string{
+			"1.lsp:1:16: undefined function F",
+			"1.lsp:1:23: F takes 1 argument but is given 2",
+		}},
+		{`(labels ((f (x) (f x))) (f 1))`, []string{}},
+		{`(lambda (x &rest) x) (defun g (1) 1)`, []string{
+			"1.lsp:1:12: &REST is invalid in the LAMBDA form, whose syntax is (lambda lambda-list form*)",
+			"1.lsp:1:31: 1 is invalid in the DEFUN form, whose syntax is (defun function-name lambda-list form*)",
+		}},
+		{`(defclass <c> () ((a :reader c-a :bogus 1))) (c-a 1 2)`, []string{
+			"1.lsp:1:34: :BOGUS is invalid in the DEFCLASS form, whose syntax is (defclass class-name (sc-name*) (slot-spec*) class-opt*)",
+			"1.lsp:1:46: C-A takes 1 argument but is given 2",
+		}},
+		{`(defclass <c> () ((b :writer)))`, []string{
+			"1.lsp:1:22: :WRITER is invalid in the DEFCLASS form, whose syntax is (defclass class-name (sc-name*) (slot-spec*) class-opt*)",
+		}},
+		{`(defclass <c> () () (:metaclass))`, []string{
+			"1.lsp:1:21: (:METACLASS) is invalid in the DEFCLASS form, whose syntax is (defclass class-name (sc-name*) (slot-spec*) class-opt*)",
+		}},
+		{`(block a (return-from a 1) (return-from b 2))`, []string{
+			"1.lsp:1:28: return-from B outside of a block named B",
+		}},
+		{`(tagbody start (go start) (go end)) (go start)`, []string{
+			"1.lsp:1:27: go to the tag END outside of a tagbody with this tag",
+			"1.lsp:1:37: go to the tag START outside of a tagbody with this tag",
+		}},
+		{`(tagbody (lambda () (go start)) start)`, []string{}},
+		{"`(a ,b ,@(c))", []string{
+			"1.lsp:1:6: undefined variable B",
+			"1.lsp:1:11: undefined function C",
+		}},
+		{`(if 1) (quote)`, []string{
+			"1.lsp:1:1: Malformed IF form, whose syntax is (if test-form then-form [else-form])",
+			"1.lsp:1:8: Malformed QUOTE form, whose syntax is (quote obj)",
+		}},
+		{`(defmacro m (x) x) (m (undefined))`, []string{}},
+		{`(defmodule m (:export f)) (defun f () 1) (defun g () 2) (in-module user) (m:f) (m::g 1) (m:h)`, []string{
+			"1.lsp:1:80: M:G takes 0 arguments but is given 1",
+			"1.lsp:1:90: The symbol H is not exported from the module M",
+		}},
+		{`(list 1 (2 3) . 4)`, []string{
+			"1.lsp:1:1: (LIST 1 (2 3) . 4) is not a proper list",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			if got := check(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	got := check(`(defun f () (g 1))`, `(defun g (x) (f x))`)
+	want := []string{"2.lsp:1:14: F takes 0 arguments but is given 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %q, want %q", got, want)
+	}
+}
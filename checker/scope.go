@@ -0,0 +1,40 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package checker
+
+import "github.com/islisp-dev/iris/core"
+
+type namespace int
+
+const (
+	variableSpace namespace = iota
+	functionSpace
+	blockSpace
+	tagSpace
+)
+
+// scope is a lexical binding, linked to the bindings of the enclosing forms.
+// The nil scope is the top level.
+type scope struct {
+	parent    *scope
+	space     namespace
+	name      string
+	signature signature // of a function
+}
+
+// bind returns the scope s extended with the binding of name in space.
+func (s *scope) bind(space namespace, name core.Instance, sig signature) *scope {
+	return &scope{s, space, name.String(), sig}
+}
+
+// lookup returns the innermost binding of name in space.
+func (s *scope) lookup(space namespace, name core.Instance) (*scope, bool) {
+	for ; s != nil; s = s.parent {
+		if s.space == space && s.name == name.String() {
+			return s, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,265 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package checker
+
+import (
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+)
+
+// parts returns the elements of x, a part of a form whose syntax is valid.
+func parts(x core.Instance) []core.Instance {
+	elements, _ := elements(x)
+	return elements
+}
+
+// restKeyword reports whether x is the keyword of a rest parameter.
+func restKeyword(x core.Instance) bool {
+	return x.String() == "&REST" || x.String() == ":REST"
+}
+
+// special checks a special form. Its syntax is checked first, as the
+// evaluator does, and the parts of a form of a valid syntax are checked then.
+func (c *Checker) special(s *scope, form *core.Cons, args []core.Instance) {
+	if err := lib.CheckSyntax(c.env, form); err != nil {
+		c.report(form, err)
+		return
+	}
+	switch form.Car.String() {
+	case "QUOTE", "CLASS", "DYNAMIC", "IMPORT", "DEFMODULE", "IN-MODULE", "TRACE", "UNTRACE", "IN-SUITE":
+	case "QUASIQUOTE":
+		c.quasiquote(s, args[0], 1)
+	case "FUNCTION":
+		c.function(s, args[0])
+	case "LAMBDA":
+		c.lambda(s, args[0], args[1:])
+	case "DEFUN", "DEFMACRO":
+		c.lambda(s, args[1], args[2:])
+	case "DEFGLOBAL", "DEFCONSTANT", "DEFDYNAMIC", "ASSURE", "THE", "ASSERT-ERROR":
+		c.form(s, args[1])
+	case "CONVERT":
+		c.form(s, args[0])
+	case "LET", "LET*", "DYNAMIC-LET":
+		c.let(s, form, args[0], args[1:])
+	case "FLET", "LABELS":
+		c.flet(s, form, args[0], args[1:])
+	case "SETQ":
+		c.variable(s, args[0].(core.Symbol))
+		c.form(s, args[1])
+	case "SETF":
+		c.place(s, args[0])
+		c.form(s, args[1])
+	case "COND":
+		for _, x := range args {
+			c.forms(s, parts(x))
+		}
+	case "CASE", "CASE-USING":
+		keys := 1
+		if form.Car.String() == "CASE-USING" {
+			keys = 2
+		}
+		c.forms(s, args[:keys])
+		for _, x := range args[keys:] {
+			c.forms(s, arguments(x))
+		}
+	case "FOR":
+		c.iteration(s, args[0], args[1], args[2:])
+	case "BLOCK":
+		c.forms(s.bind(blockSpace, args[0], signature{}), args[1:])
+	case "RETURN-FROM":
+		if _, ok := s.lookup(blockSpace, args[0]); !ok {
+			c.errorf(core.LocationOf(form), "return-from %v outside of a block named %v", args[0], args[0])
+		}
+		c.form(s, args[1])
+	case "TAGBODY":
+		inner := s
+		for _, x := range args {
+			if _, ok := x.(*core.Cons); !ok {
+				inner = inner.bind(tagSpace, x, signature{})
+			}
+		}
+		for _, x := range args {
+			if _, ok := x.(*core.Cons); ok {
+				c.form(inner, x)
+			}
+		}
+	case "GO":
+		if _, ok := s.lookup(tagSpace, args[0]); !ok {
+			c.errorf(core.LocationOf(form), "go to the tag %v outside of a tagbody with this tag", args[0])
+		}
+	case "DEFCLASS":
+		c.defclass(s, args)
+	case "DEFTEST":
+		c.forms(s, args[1:])
+	case "DEFSUITE":
+		for _, x := range args[1:] {
+			c.forms(s, arguments(x))
+		}
+	case "DEFGENERIC":
+		for _, x := range args[2:] {
+			if option := x.(*core.Cons); option.Car.String() == ":METHOD" {
+				c.method(s, arguments(option))
+			}
+		}
+	case "DEFMETHOD":
+		c.method(s, args[1:])
+	case "WITH-OPEN-INPUT-FILE", "WITH-OPEN-OUTPUT-FILE":
+		spec := parts(args[0])
+		c.forms(s, spec[1:])
+		c.forms(s.bind(variableSpace, spec[0], signature{}), args[1:])
+	default:
+		c.forms(s, args)
+	}
+}
+
+// quasiquote checks the forms unquoted in a template quasiquoted depth times.
+func (c *Checker) quasiquote(s *scope, template core.Instance, depth int) {
+	cons, ok := template.(*core.Cons)
+	if !ok {
+		return
+	}
+	switch operator(cons) {
+	case "UNQUOTE", "UNQUOTE-SPLICING":
+		if depth == 1 {
+			c.forms(s, arguments(cons))
+			return
+		}
+		depth--
+	case "QUASIQUOTE":
+		depth++
+	}
+	c.quasiquote(s, cons.Car, depth)
+	c.quasiquote(s, cons.Cdr, depth)
+}
+
+// function checks the function name or lambda expression of (function x).
+func (c *Checker) function(s *scope, x core.Instance) {
+	if name, ok := x.(core.Symbol); ok {
+		if _, ok := s.lookup(functionSpace, name); ok {
+			return
+		}
+		if _, ok := c.lookupFunction(name); !ok {
+			c.errorf(core.LocationOf(name), "undefined function %v", name)
+		}
+		return
+	}
+	c.form(s, x)
+}
+
+// lambda checks the body of a function, whose parameters are bound in the
+// scope s by the lambda list.
+func (c *Checker) lambda(s *scope, lambdaList core.Instance, body []core.Instance) {
+	for _, p := range parts(lambdaList) {
+		if !restKeyword(p) {
+			s = s.bind(variableSpace, p, signature{})
+		}
+	}
+	c.forms(s, body)
+}
+
+// let checks let, let* and dynamic-let.
+func (c *Checker) let(s *scope, form *core.Cons, bindings core.Instance, body []core.Instance) {
+	inner := s
+	for _, b := range parts(bindings) {
+		pair := parts(b)
+		switch form.Car.String() {
+		case "LET":
+			c.form(s, pair[1])
+			inner = inner.bind(variableSpace, pair[0], signature{})
+		case "LET*":
+			c.form(inner, pair[1])
+			inner = inner.bind(variableSpace, pair[0], signature{})
+		default:
+			c.form(s, pair[1])
+		}
+	}
+	c.forms(inner, body)
+}
+
+// flet checks flet and labels.
+func (c *Checker) flet(s *scope, form *core.Cons, definitions core.Instance, body []core.Instance) {
+	inner := s
+	for _, d := range parts(definitions) {
+		def := parts(d)
+		required, rest := core.LambdaListArity(def[1])
+		inner = inner.bind(functionSpace, def[0], signature{required, rest})
+	}
+	for _, d := range parts(definitions) {
+		def := parts(d)
+		if form.Car.String() == "LABELS" {
+			c.lambda(inner, def[1], def[2:])
+		} else {
+			c.lambda(s, def[1], def[2:])
+		}
+	}
+	c.forms(inner, body)
+}
+
+// place checks the place of setf.
+func (c *Checker) place(s *scope, place core.Instance) {
+	switch p := place.(type) {
+	case core.Symbol:
+		c.variable(s, p)
+	case *core.Cons:
+		if operator(p) != "DYNAMIC" {
+			c.forms(s, arguments(p))
+		}
+	}
+}
+
+// iteration checks for.
+func (c *Checker) iteration(s *scope, specs, end core.Instance, body []core.Instance) {
+	inner := s
+	steps := []core.Instance{}
+	for _, spec := range parts(specs) {
+		iteration := parts(spec)
+		c.form(s, iteration[1])
+		inner = inner.bind(variableSpace, iteration[0], signature{})
+		steps = append(steps, iteration[2:]...)
+	}
+	c.forms(inner, steps)
+	c.forms(inner, parts(end))
+	c.forms(inner, body)
+}
+
+// method checks the parameter profile and body of a method, after its
+// qualifiers.
+func (c *Checker) method(s *scope, args []core.Instance) {
+	for {
+		if _, ok := args[0].(core.Symbol); !ok || args[0] == core.Nil {
+			break
+		}
+		args = args[1:]
+	}
+	for _, p := range parts(args[0]) {
+		if specialized, ok := p.(*core.Cons); ok {
+			p = specialized.Car
+		}
+		if !restKeyword(p) {
+			s = s.bind(variableSpace, p, signature{})
+		}
+	}
+	s = s.bind(functionSpace, core.NewSymbol("CALL-NEXT-METHOD"), signature{})
+	s = s.bind(functionSpace, core.NewSymbol("NEXT-METHOD-P"), signature{})
+	c.forms(s, args[1:])
+}
+
+// defclass checks the initforms of the slot specs and the class options of
+// defclass.
+func (c *Checker) defclass(s *scope, args []core.Instance) {
+	for _, spec := range parts(args[2]) {
+		options := arguments(spec)
+		for i := 0; i+1 < len(options); i += 2 {
+			if options[i].String() == ":INITFORM" {
+				c.form(s, options[i+1])
+			}
+		}
+	}
+	for _, option := range args[3:] {
+		if option := option.(*core.Cons); option.Car.String() == ":ABSTRACTP" {
+			c.forms(s, arguments(option))
+		}
+	}
+}
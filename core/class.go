@@ -33,15 +33,19 @@ var FloatingPointOnderflowClass = NewBuiltInClass("<FLOATING-POINT-OVERFLOW>", A
 var FloatingPointUnderflowClass = NewBuiltInClass("<FLOATING-POINT-UNDERFLOW>", ArithmeticErrorClass)
 var ControlErrorClass = NewBuiltInClass("<CONTROL-ERROR>", ErrorClass)
 var ParseErrorClass = NewBuiltInClass("<PARSE-ERROR>", ErrorClass, "STRING", "EXPECTED-CLASS")
-var ProgramErrorClass = NewBuiltInClass("<PROGRAM-ERROR>", ErrorClass)
+var ProgramErrorClass = NewBuiltInClass("<PROGRAM-ERROR>", ErrorClass, "IRIS.OPERATION", "IRIS.PANIC")
 var DomainErrorClass = NewBuiltInClass("<DOMAIN-ERROR>", ProgramErrorClass, "OBJECT", "EXPECTED-CLASS")
+var SyntaxErrorClass = NewBuiltInClass("<SYNTAX-ERROR>", ProgramErrorClass, "FORM", "SYNTAX", "OBJECT")
 var UndefinedEntityClass = NewBuiltInClass("<UNDEFINED-ENTITY>", ProgramErrorClass, "NAME", "NAMESPACE")
 var UnboundVariableClass = NewBuiltInClass("<UNBOUND-VARIABLE>", UndefinedEntityClass)
 var UndefinedFunctionClass = NewBuiltInClass("<UNDEFINED-FUNCTION>", UndefinedEntityClass)
 var SimpleErrorClass = NewBuiltInClass("<SIMPLE-ERROR>", ErrorClass, "FORMAT-STRING", "FORMAT-ARGUMENTS")
+var AssertionFailureClass = NewBuiltInClass("<ASSERTION-FAILURE>", SimpleErrorClass)
 var StreamErrorClass = NewBuiltInClass("<STREAM-ERROR>", ErrorClass)
 var EndOfStreamClass = NewBuiltInClass("<END-OF-STREAM>", StreamErrorClass)
-var StorageExhaustedClass = NewBuiltInClass("<STORAGE-EXHAUSTED>", SeriousConditionClass)
+var StorageExhaustedClass = NewBuiltInClass("<STORAGE-EXHAUSTED>", SeriousConditionClass, "IRIS.STACK-DEPTH")
+var QuotaExceededClass = NewBuiltInClass("<QUOTA-EXCEEDED>", StorageExhaustedClass, "QUOTA", "LIMIT")
+var CancelledClass = NewBuiltInClass("<CANCELLED>", SeriousConditionClass, "REASON")
 var StandardObjectClass = NewBuiltInClass("<STANDARD-OBJECT>", ObjectClass)
 var StreamClass = NewBuiltInClass("<STREAM>", ObjectClass, "STREAM")
 
@@ -51,3 +55,4 @@ var CatchTagClass = NewBuiltInClass("<THROW>", EscapeClass, "IRIS.OBJECT")
 var TagbodyTagClass = NewBuiltInClass("<TAGBODY-TAG>", EscapeClass)
 var BlockTagClass = NewBuiltInClass("<BLOCK-TAG>", EscapeClass, "IRIS.OBJECT")
 var ContinueClass = NewBuiltInClass("<CONTINUE>", EscapeClass, "IRIS.OBJECT")
+var TailCallClass = NewBuiltInClass("<TAIL-CALL>", ObjectClass)
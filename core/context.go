@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+// cancelReserve is the number of function applications and loop iterations
+// left to the handler of a <cancelled>.
+const cancelReserve = 1000
+
+// CheckContext signals a <cancelled> condition if the context of e has been
+// cancelled or its deadline has passed. It is called at every function
+// application and loop iteration, so that any evaluation can be stopped. The
+// handler keeps the context, and is left cancelReserve checks; a handler
+// exceeding them makes the condition be returned without being signaled
+// again.
+func CheckContext(e Environment) (Instance, Instance) {
+	if e.Context == nil || e.Context.Err() == nil {
+		return nil, nil
+	}
+	reason := NewString([]rune(e.Context.Err().Error()))
+	r := e.Runtime
+	if r == nil {
+		return nil, NewCancelled(e, reason)
+	}
+	if r.cancelled {
+		if r.grace > 0 {
+			r.grace--
+			return nil, nil
+		}
+		return nil, NewCancelled(e, reason)
+	}
+	r.cancelled, r.grace = true, cancelReserve
+	defer func() { r.cancelled = false }()
+	return SignalCondition(e, NewCancelled(e, reason), Nil)
+}
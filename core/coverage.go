@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+// Coverage records how many times the forms of the files loaded while it is
+// started are evaluated, and which branches of their conditional forms are
+// taken. The forms are added to it as they are read, so that the forms never
+// evaluated are recorded too.
+type Coverage struct {
+	forms   map[*Cons]*coveredForm
+	order   []*coveredForm // the forms added, in order
+	current *Cons          // the form evaluated last if it is covered
+}
+
+// coveredForm is a form added to a coverage. branches is nil unless the form
+// is conditional, and then counts the times each of its branches is taken.
+type coveredForm struct {
+	location Location
+	count    int64
+	branches []int64
+}
+
+// NewCoverage returns a coverage recording no form yet.
+func NewCoverage() *Coverage {
+	return &Coverage{forms: map[*Cons]*coveredForm{}}
+}
+
+// StartCoverage makes the runtime record the forms in c.
+func (r *Runtime) StartCoverage(c *Coverage) {
+	r.coverage = c
+}
+
+// StopCoverage stops recording the forms.
+func (r *Runtime) StopCoverage() {
+	r.coverage = nil
+}
+
+// Coverage returns the coverage started, or nil.
+func (r *Runtime) Coverage() *Coverage {
+	return r.coverage
+}
+
+// AddForm adds the form, which has a location, to the forms recorded. It does
+// nothing if form is not located.
+func (c *Coverage) AddForm(form Instance) {
+	cons, ok := form.(*Cons)
+	if !ok || cons.Location() == nil {
+		return
+	}
+	if _, ok := c.forms[cons]; ok {
+		return
+	}
+	f := &coveredForm{location: *cons.Location()}
+	c.forms[cons] = f
+	c.order = append(c.order, f)
+}
+
+// AddBranches adds the form like AddForm, as a conditional form of n
+// branches.
+func (c *Coverage) AddBranches(form Instance, n int) {
+	c.AddForm(form)
+	if f, ok := c.forms[form.(*Cons)]; ok && f.branches == nil {
+		f.branches = make([]int64, n)
+	}
+}
+
+// Cover records the evaluation of the form if it is recorded by the coverage
+// started.
+func Cover(e Environment, form Instance) {
+	if e.Runtime == nil || e.Runtime.coverage == nil {
+		return
+	}
+	c := e.Runtime.coverage
+	c.current = nil
+	cons, ok := form.(*Cons)
+	if !ok {
+		return
+	}
+	if f, ok := c.forms[cons]; ok {
+		f.count++
+		c.current = cons
+	}
+}
+
+// CoveredForm returns the form whose evaluation was recorded last by Cover,
+// or nil. A conditional special form calls it before it evaluates anything,
+// to get itself.
+func CoveredForm(e Environment) Instance {
+	if e.Runtime == nil || e.Runtime.coverage == nil || e.Runtime.coverage.current == nil {
+		return nil
+	}
+	return e.Runtime.coverage.current
+}
+
+// CoverBranch records that the conditional form, returned by CoveredForm,
+// took its branch of the index. It does nothing if form is nil.
+func CoverBranch(e Environment, form Instance, branch int) {
+	if form == nil || e.Runtime == nil || e.Runtime.coverage == nil {
+		return
+	}
+	if f, ok := e.Runtime.coverage.forms[form.(*Cons)]; ok && branch < len(f.branches) {
+		f.branches[branch]++
+	}
+}
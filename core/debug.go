@@ -0,0 +1,158 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+// Debugger is called with a condition no handler has been established for,
+// at the point it is signaled. The calls of the function applications in
+// progress are those of e.Runtime.Calls. It returns as a handler does: an
+// instance of <continue> continues the condition, anything else is signaled
+// as usual.
+type Debugger func(e Environment, condition Instance) (Instance, Instance)
+
+// Call is a function application in progress, recorded while the runtime has
+// a debugger.
+type Call struct {
+	Name      Instance
+	Arguments []Instance
+	// Environment is the environment current in the application when it
+	// applied the function of the next call, or when the condition was
+	// signaled in it. It holds the local variables of the application.
+	Environment *Environment
+	// Form is the form the application was evaluating then, or nil if
+	// unknown.
+	Form Instance
+	// Tail is true if the application has ended by calling the function of
+	// the next call in tail position. Of successive tail calls, only the last
+	// caller is kept.
+	Tail bool
+}
+
+// SetDebugger sets the debugger of the runtime, or removes it if debugger is
+// nil. The calls are only recorded while there is a debugger.
+func (r *Runtime) SetDebugger(debugger Debugger) {
+	r.debugger = debugger
+	r.calls = nil
+}
+
+// Calls returns the function applications in progress, the innermost first.
+func (r *Runtime) Calls() []*Call {
+	calls := make([]*Call, len(r.calls))
+	for i, call := range r.calls {
+		calls[len(calls)-1-i] = call
+	}
+	return calls
+}
+
+// pushCall records the application of the function named name, and returns
+// a function removing it. It does nothing without a debugger.
+func pushCall(e Environment, name Instance, arguments []Instance) func() {
+	r := e.Runtime
+	if r == nil || r.debugger == nil {
+		return func() {}
+	}
+	env := e
+	r.calls = append(r.calls, &Call{Name: name, Arguments: arguments, Environment: &env})
+	n := len(r.calls)
+	return func() {
+		if len(r.calls) >= n {
+			r.calls = r.calls[:n-1]
+		}
+	}
+}
+
+// replaceCall records the application of the function named name, which is
+// called in tail position by the innermost application with the form, if
+// known. The caller is kept as a tail call, in place of the caller it was
+// called in tail position by, if any, so that a loop of tail calls keeps two
+// calls at most. The calls are removed together with the application which
+// made the first of them.
+func replaceCall(e Environment, name Instance, arguments []Instance, form Instance) {
+	r := e.Runtime
+	if r == nil || r.debugger == nil || len(r.calls) == 0 {
+		return
+	}
+	env := e
+	call := &Call{Name: name, Arguments: arguments, Environment: &env}
+	n := len(r.calls)
+	caller := r.calls[n-1]
+	caller.Tail = true
+	if form != nil {
+		caller.Form = form
+	}
+	if n > 1 && r.calls[n-2].Tail {
+		r.calls[n-2], r.calls[n-1] = caller, call
+		return
+	}
+	r.calls = append(r.calls, call)
+}
+
+// MarkCall records e as the environment current in the innermost function
+// application, and form as the form it evaluates, for the debugger to show
+// and the profile to record the line of. The evaluator marks the call before
+// each function call it makes.
+func MarkCall(e Environment, form Instance) {
+	if profiling(e) {
+		markProfile(e, form)
+	}
+	r := e.Runtime
+	if r == nil || r.debugger == nil || len(r.calls) == 0 {
+		return
+	}
+	env := e
+	call := r.calls[len(r.calls)-1]
+	call.Environment = &env
+	call.Form = form
+}
+
+// debug calls the debugger of the runtime with the condition, and reports
+// whether there is one. It is called by DefaultHandler, whose own call is
+// left out. The debugger is removed while it runs, so that the conditions
+// signaled by the forms it evaluates are not debugged in turn.
+func debug(e Environment, condition Instance) (Instance, Instance, bool) {
+	r := e.Runtime
+	if r == nil || r.debugger == nil {
+		return nil, nil, false
+	}
+	debugger, calls := r.debugger, r.calls
+	if len(calls) > 0 {
+		r.calls = calls[:len(calls)-1]
+	}
+	MarkCall(e, nil)
+	r.debugger = nil
+	defer func() { r.debugger, r.calls = debugger, calls }()
+	ret, err := debugger(e, condition)
+	return ret, err, true
+}
+
+// Stepper is called with each list the evaluator is about to evaluate as a
+// form while the runtime has one, and the environment it evaluates the form
+// in. It returns a function called once the form is evaluated, or nil. The
+// forms evaluated while the stepper runs are not stepped in turn.
+type Stepper func(e Environment, form Instance) func()
+
+// SetStepper sets the stepper of the runtime, or removes it if stepper is
+// nil.
+func (r *Runtime) SetStepper(stepper Stepper) {
+	r.stepper = stepper
+}
+
+// Step calls the stepper of the runtime with the form, and returns the
+// function to call once the form is evaluated. The evaluator steps each list
+// it evaluates as a form, before its operator is applied.
+func Step(e Environment, form Instance) func() {
+	r := e.Runtime
+	if r == nil || r.stepper == nil || r.stepping {
+		return stepped
+	}
+	r.stepping = true
+	done := r.stepper(e, form)
+	r.stepping = false
+	if done == nil {
+		return stepped
+	}
+	return done
+}
+
+func stepped() {}
@@ -4,6 +4,8 @@
 
 package core
 
+import "context"
+
 // Environment struct is the struct for keeping functions and variables
 type Environment struct {
 	// Lexical
@@ -26,6 +28,11 @@ type Environment struct {
 	StandardOutput  Instance
 	ErrorOutput     Instance
 	Handler         Instance
+	outerHandlers   []Instance      // the handlers Handler was established in, innermost last
+	Context         context.Context // nil if never cancelled
+
+	// Shared
+	Runtime *Runtime
 }
 
 // New creates new eironment
@@ -52,6 +59,9 @@ func NewEnvironment(stdin, stdout, stderr, handler Instance) Environment {
 	e.StandardOutput = stdout
 	e.ErrorOutput = stderr
 	e.Handler = handler
+
+	// Shared
+	e.Runtime = NewRuntime()
 	return *e
 }
 
@@ -70,10 +80,18 @@ func (before *Environment) NewHandler(handler Instance) Environment {
 	e.Property = before.Property
 
 	e.CatchTag = before.CatchTag
+	e.DynamicVariable = before.DynamicVariable
+	e.Context = before.Context
+	e.outerHandlers = append(before.outerHandlers[:len(before.outerHandlers):len(before.outerHandlers)], before.Handler)
 
+	e.Runtime = before.Runtime
 	return e
 }
 
+// MergeLexical puts the lexical bindings of the closure environment before
+// under those of e. The dynamic bindings, such as the catch tags, the dynamic
+// variables, the standard streams and the handler, stay those of e, the
+// environment of the application.
 func (e *Environment) MergeLexical(before Environment) {
 	e.BlockTag = before.BlockTag.Append(e.BlockTag[1:])
 	e.TagbodyTag = before.TagbodyTag.Append(e.TagbodyTag[1:])
@@ -86,50 +104,43 @@ func (e *Environment) MergeLexical(before Environment) {
 	e.Constant = before.Constant.Append(e.Constant[1:])
 	e.Property = before.Property
 
-	e.CatchTag = before.CatchTag.Append(e.CatchTag[1:])
-	e.DynamicVariable = before.DynamicVariable.Append(e.DynamicVariable[1:])
-	e.StandardInput = before.StandardInput
-	e.StandardOutput = before.StandardOutput
-	e.ErrorOutput = before.ErrorOutput
-	e.Handler = before.Handler
+	e.Runtime = before.Runtime
 }
 
 func (before *Environment) NewLexical() Environment {
-	e := NewEnvironment(before.StandardInput, before.StandardOutput, before.ErrorOutput, before.Handler)
+	e := *before
 
-	e.BlockTag = before.BlockTag.Append(e.BlockTag)
-	e.TagbodyTag = before.TagbodyTag.Append(e.TagbodyTag)
-	e.Variable = before.Variable.Append(e.Variable)
-	e.Function = before.Function.Append(e.Function)
+	e.BlockTag = before.BlockTag.Push()
+	e.TagbodyTag = before.TagbodyTag.Push()
+	e.Variable = before.Variable.Push()
+	e.Function = before.Function.Push()
 
-	e.Macro = before.Macro.Append(e.Macro)
-	e.Class = before.Class.Append(e.Class)
-	e.Special = before.Special.Append(e.Special)
-	e.Constant = before.Constant.Append(e.Constant)
-	e.Property = before.Property
+	e.Macro = before.Macro.Push()
+	e.Class = before.Class.Push()
+	e.Special = before.Special.Push()
+	e.Constant = before.Constant.Push()
 
-	e.CatchTag = before.CatchTag.Append(e.CatchTag)
-	e.DynamicVariable = before.DynamicVariable.Append(e.DynamicVariable)
+	e.CatchTag = before.CatchTag.Push()
+	e.DynamicVariable = before.DynamicVariable.Push()
 
 	return e
 }
 
 func (before *Environment) NewDynamic() Environment {
-	e := NewEnvironment(before.StandardInput, before.StandardOutput, before.ErrorOutput, before.Handler)
+	e := *before
 
-	e.BlockTag = stack{before.BlockTag[0]}.Append(e.BlockTag)
-	e.TagbodyTag = stack{before.TagbodyTag[0]}.Append(e.TagbodyTag)
-	e.Variable = stack{before.Variable[0]}.Append(e.Variable)
-	e.Function = stack{before.Function[0]}.Append(e.Function)
+	e.BlockTag = stack{before.BlockTag[0], NewHashMap()}
+	e.TagbodyTag = stack{before.TagbodyTag[0], NewHashMap()}
+	e.Variable = stack{before.Variable[0], NewHashMap()}
+	e.Function = stack{before.Function[0], NewHashMap()}
 
-	e.Macro = stack{before.Macro[0]}.Append(e.Macro)
-	e.Class = stack{before.Class[0]}.Append(e.Class)
-	e.Special = stack{before.Special[0]}.Append(e.Special)
-	e.Constant = stack{before.Constant[0]}.Append(e.Constant)
-	e.Property = before.Property
+	e.Macro = stack{before.Macro[0], NewHashMap()}
+	e.Class = stack{before.Class[0], NewHashMap()}
+	e.Special = stack{before.Special[0], NewHashMap()}
+	e.Constant = stack{before.Constant[0], NewHashMap()}
 
-	e.CatchTag = before.CatchTag.Append(e.CatchTag)
-	e.DynamicVariable = before.DynamicVariable.Append(e.DynamicVariable)
+	e.CatchTag = before.CatchTag.Push()
+	e.DynamicVariable = before.DynamicVariable.Push()
 
 	return e
 }
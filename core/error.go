@@ -4,7 +4,21 @@
 
 package core
 
+import "reflect"
+
+// DefaultHandler is the handler active when no other has been established. It
+// calls the debugger of the runtime if there is one.
 var DefaultHandler = NewFunction(NewSymbol("DEFAULT-HANDLER"), func(e Environment, c Instance) (Instance, Instance) {
+	if ret, err, ok := debug(e, c); ok {
+		return ret, err
+	}
+	return nil, c
+})
+
+// SilentHandler returns the conditions to the forms signaling them, as
+// DefaultHandler does without a debugger. It is established where Go code
+// examines the conditions, such as the end of the stream being read.
+var SilentHandler = NewFunction(NewSymbol("SILENT-HANDLER"), func(e Environment, c Instance) (Instance, Instance) {
 	return nil, c
 })
 
@@ -13,7 +27,12 @@ func SignalCondition(e Environment, condition, continuable Instance) (Instance,
 		return SignalCondition(e, NewDomainError(e, condition, SeriousConditionClass), Nil)
 	}
 	condition.(BasicInstance).SetSlotValue(NewSymbol("IRIS.CONTINUABLE"), continuable, SeriousConditionClass)
-	_, c := e.Handler.(Applicable).Apply(e, condition)
+	// The handler runs with the handler active when it was established.
+	handler := e.Handler
+	if n := len(e.outerHandlers); n > 0 {
+		e.Handler, e.outerHandlers = e.outerHandlers[n-1], e.outerHandlers[:n-1]
+	}
+	_, c := handler.(Applicable).Apply(e, condition)
 	if InstanceOf(ContinueClass, c) {
 		o, _ := c.(BasicInstance).GetSlotValue(NewSymbol("IRIS.OBJECT"), ContinueClass)
 		return o, nil
@@ -49,40 +68,89 @@ func NewDomainError(e Environment, object Instance, expectedClass Class) Instanc
 		NewSymbol("EXPECTED-CLASS"), expectedClass)
 }
 
-func NewUndefinedFunction(e Environment, name Instance) Instance {
-	l, c := -1, -1
-	if s, ok := name.(Symbol); ok {
-		l, c = s.Location()
+// NewSyntaxError returns the condition of a special form whose part object
+// does not have the syntax, a string describing the shape of the form. Its
+// frame locates object, or form if object was not read.
+func NewSyntaxError(e Environment, form, syntax, object Instance) Instance {
+	loc := locationWithin(form, object)
+	if loc == nil {
+		loc = LocationOf(form)
+	}
+	var name Instance = form
+	if c, ok := form.(*Cons); ok {
+		name = c.Car
+	}
+	return PushFrame(Create(e, SyntaxErrorClass,
+		NewSymbol("FORM"), form,
+		NewSymbol("SYNTAX"), syntax,
+		NewSymbol("OBJECT"), object), name, loc)
+}
+
+// locationWithin returns the location of object, a part of form, or the
+// location of the innermost list of form which contains object and has a
+// location, as atoms have no location of their own.
+func locationWithin(form, object Instance) *Location {
+	loc, _ := locate(form, object)
+	return loc
+}
+
+func locate(form, object Instance) (*Location, bool) {
+	if identical(form, object) {
+		return LocationOf(form), true
 	}
-	loc := NewCons(NewInteger(l), NewInteger(c))
-	return Create(e, UndefinedFunctionClass,
+	for list := form; ; {
+		cons, ok := list.(*Cons)
+		if !ok {
+			return nil, false
+		}
+		loc, found := locate(cons.Car, object)
+		if !found && identical(cons.Cdr, object) {
+			found = true
+		}
+		if found {
+			if loc == nil {
+				loc = LocationOf(form)
+			}
+			return loc, true
+		}
+		list = cons.Cdr
+	}
+}
+
+// identical reports whether a and b are the same object.
+func identical(a, b Instance) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t != nil && t.Comparable() && a == b
+}
+
+func NewUndefinedFunction(e Environment, name Instance) Instance {
+	return PushFrame(Create(e, UndefinedFunctionClass,
 		NewSymbol("NAME"), name,
-		NewSymbol("NAMESPACE"), NewSymbol("FUNCTION"),
-		NewSymbol("IRIS.STACKTRACE"), NewCons(loc, Nil))
+		NewSymbol("NAMESPACE"), NewSymbol("FUNCTION")), name, LocationOf(name))
 }
 
 func NewUnboundVariable(e Environment, name Instance) Instance {
-	l, c := -1, -1
-	if s, ok := name.(Symbol); ok {
-		l, c = s.Location()
-	}
-	loc := NewCons(NewInteger(l), NewInteger(c))
-	return Create(e, UnboundVariableClass,
+	return PushFrame(Create(e, UnboundVariableClass,
 		NewSymbol("NAME"), name,
-		NewSymbol("NAMESPACE"), NewSymbol("VARIABLE"),
-		NewSymbol("IRIS.STACKTRACE"), NewCons(loc, Nil))
+		NewSymbol("NAMESPACE"), NewSymbol("VARIABLE")), name, LocationOf(name))
 }
 
 func NewUndefinedClass(e Environment, name Instance) Instance {
-	l, c := -1, -1
-	if s, ok := name.(Symbol); ok {
-		l, c = s.Location()
-	}
-	loc := NewCons(NewInteger(l), NewInteger(c))
+	return PushFrame(Create(e, UndefinedEntityClass,
+		NewSymbol("NAME"), name,
+		NewSymbol("NAMESPACE"), NewSymbol("CLASS")), name, LocationOf(name))
+}
+
+func NewUndefinedModule(e Environment, name Instance) Instance {
+	return Create(e, UndefinedEntityClass,
+		NewSymbol("NAME"), name,
+		NewSymbol("NAMESPACE"), NewSymbol("MODULE"))
+}
+
+func NewUndefinedSuite(e Environment, name Instance) Instance {
 	return Create(e, UndefinedEntityClass,
 		NewSymbol("NAME"), name,
-		NewSymbol("NAMESPACE"), NewSymbol("CLASS"),
-		NewSymbol("IRIS.STACKTRACE"), NewCons(loc, Nil))
+		NewSymbol("NAMESPACE"), NewSymbol("SUITE"))
 }
 
 func NewArityError(e Environment) Instance {
@@ -103,6 +171,14 @@ func NewSimpleError(e Environment, formatString, formatArguments Instance) Insta
 		NewSymbol("FORMAT-ARGUMENTS"), formatArguments)
 }
 
+// NewAssertionFailure returns the condition signaled by an assertion of a
+// test which does not hold. It is reported as a <simple-error>.
+func NewAssertionFailure(e Environment, formatString, formatArguments Instance) Instance {
+	return Create(e, AssertionFailureClass,
+		NewSymbol("FORMAT-STRING"), formatString,
+		NewSymbol("FORMAT-ARGUMENTS"), formatArguments)
+}
+
 func NewControlError(e Environment) Instance {
 	return Create(e, ControlErrorClass)
 }
@@ -110,3 +186,23 @@ func NewControlError(e Environment) Instance {
 func NewStreamError(e Environment, stream Instance) Instance {
 	return Create(e, StreamErrorClass, NewSymbol("STREAM"), stream)
 }
+
+func NewCancelled(e Environment, reason Instance) Instance {
+	return Create(e, CancelledClass, NewSymbol("REASON"), reason)
+}
+
+func NewStorageExhausted(e Environment) Instance {
+	return Create(e, StorageExhaustedClass)
+}
+
+// NewStackExhausted returns the <storage-exhausted> signaled beyond the stack
+// depth, which it keeps in its slot IRIS.STACK-DEPTH.
+func NewStackExhausted(e Environment, depth int) Instance {
+	return Create(e, StorageExhaustedClass, NewSymbol("IRIS.STACK-DEPTH"), NewInteger(depth))
+}
+
+func NewQuotaExceeded(e Environment, quota, limit Instance) Instance {
+	return Create(e, QuotaExceededClass,
+		NewSymbol("QUOTA"), quota,
+		NewSymbol("LIMIT"), limit)
+}
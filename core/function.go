@@ -17,10 +17,27 @@ type Applicable interface {
 type Function struct {
 	name     Instance
 	function interface{}
+	macro    bool // whether it is the expander of a macro
 }
 
 func NewFunction(name Instance, function interface{}) Instance {
-	return Function{name, function}
+	return Function{name, function, false}
+}
+
+// Macro returns the function as the expander of a macro, which is profiled
+// as such.
+func (f Function) Macro() Instance {
+	f.macro = true
+	return f
+}
+
+// label returns the label of the function in the profile: its name, or
+// (MACRO NAME) for the expander of a macro.
+func (f Function) label() Instance {
+	if f.macro {
+		return NewCons(NewSymbol("MACRO"), NewCons(f.name, Nil))
+	}
+	return f.name
 }
 
 func (Function) Class() Class {
@@ -31,12 +48,124 @@ func (f Function) String() string {
 	return fmt.Sprintf("#%v", f.Class())
 }
 
+// Apply calls the function and then keeps calling any function returned as a
+// TailCall, so that calls in tail position do not grow the Go stack.
 func (f Function) Apply(e Environment, arguments ...Instance) (Instance, Instance) {
+	if _, err := CheckContext(e); err != nil {
+		return nil, err
+	}
+	if _, err := EnterFunction(e); err != nil {
+		return nil, err
+	}
+	defer LeaveFunction(e)
+	defer pushCall(e, f.name, arguments)()
+	if profiling(e) {
+		enterProfile(e, f.label(), LocationOf(f.name))
+		defer leaveProfile(e)
+	}
+	ret, err := f.ApplyTail(e, arguments...)
+	return performTailCalls(e, ret, err)
+}
+
+// applyAs is Apply without the trace of the function, profiled as label. The
+// methods of generic functions are traced by their generic functions instead,
+// and profiled by their own labels.
+func (f Function) applyAs(e Environment, label Instance, arguments ...Instance) (Instance, Instance) {
+	if _, err := CheckContext(e); err != nil {
+		return nil, err
+	}
+	if _, err := EnterFunction(e); err != nil {
+		return nil, err
+	}
+	defer LeaveFunction(e)
+	defer pushCall(e, f.name, arguments)()
+	if profiling(e) {
+		enterProfile(e, label, LocationOf(f.name))
+		defer leaveProfile(e)
+	}
+	ret, err := f.applyOnce(e, arguments...)
+	return performTailCalls(e, ret, err)
+}
+
+// performTailCalls keeps calling the function returned as ret while it is a
+// TailCall.
+func performTailCalls(e Environment, ret, err Instance) (Instance, Instance) {
+	for err == nil {
+		t, ok := ret.(TailCall)
+		if !ok {
+			break
+		}
+		if _, err := CheckContext(e); err != nil {
+			return nil, err
+		}
+		g, ok := t.Function.(Function)
+		if !ok {
+			ret, err := t.Function.(Applicable).Apply(e.NewDynamic(), t.Arguments...)
+			if err != nil {
+				return nil, PushCallFrame(err, t.Form)
+			}
+			return ret, nil
+		}
+		d := e.NewDynamic()
+		replaceCall(d, g.name, t.Arguments, t.Form)
+		if profiling(d) {
+			leaveProfile(d)
+			enterProfile(d, g.label(), LocationOf(g.name))
+		}
+		ret, err = g.ApplyTail(d, t.Arguments...)
+		if err != nil {
+			err = PushCallFrame(err, t.Form)
+		}
+	}
+	return ret, err
+}
+
+// ApplyTail calls the function once. A TailCall returned by the function is
+// returned as is for the caller to perform, unless the function is traced.
+// A panic in the function is signaled as a condition.
+func (f Function) ApplyTail(e Environment, arguments ...Instance) (Instance, Instance) {
+	if traced(e, f.name) {
+		return traceApplication(e, f.name, arguments, func() (Instance, Instance) {
+			ret, err := f.applyOnce(e, arguments...)
+			return performTailCalls(e, ret, err)
+		})
+	}
+	return f.applyOnce(e, arguments...)
+}
+
+func (f Function) applyOnce(e Environment, arguments ...Instance) (ret, err Instance) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret, err = SignalCondition(e, NewPanicError(e, f.name, r, arguments), Nil)
+		}
+	}()
 	for _, arg := range arguments {
 		if arg == nil {
 			return SignalCondition(e, NewDomainError(e, arg, ObjectClass), Nil)
 		}
 	}
+	// Avoid reflection for the common signatures, most notably that of
+	// functions defined in Lisp.
+	switch fn := f.function.(type) {
+	case func(Environment, ...Instance) (Instance, Instance):
+		return fn(e, arguments...)
+	case func(Environment, Instance) (Instance, Instance):
+		if len(arguments) == 1 {
+			return fn(e, arguments[0])
+		}
+	case func(Environment, Instance, Instance) (Instance, Instance):
+		if len(arguments) == 2 {
+			return fn(e, arguments[0], arguments[1])
+		}
+	case func(Environment, Instance, ...Instance) (Instance, Instance):
+		if len(arguments) >= 1 {
+			return fn(e, arguments[0], arguments[1:]...)
+		}
+	case func(Environment, Instance, Instance, ...Instance) (Instance, Instance):
+		if len(arguments) >= 2 {
+			return fn(e, arguments[0], arguments[1], arguments[2:]...)
+		}
+	}
 	fv := reflect.ValueOf(f.function)
 	ft := reflect.TypeOf(f.function)
 	argv := []reflect.Value{reflect.ValueOf(e)}
@@ -50,7 +179,36 @@ func (f Function) Apply(e Environment, arguments ...Instance) (Instance, Instanc
 	a, _ := rets[0].Interface().(Instance)
 	b, _ := rets[1].Interface().(Instance)
 	return a, b
+}
+
+// Arity returns the number of arguments the function requires, and whether
+// it accepts more.
+func (f Function) Arity() (int, bool) {
+	t := reflect.TypeOf(f.function)
+	if t.IsVariadic() {
+		return t.NumIn() - 2, true
+	}
+	return t.NumIn() - 1, false
+}
+
+// TailCall is returned instead of a value by a form in tail position. It is
+// never visible to Lisp code, Function.Apply performs the call.
+type TailCall struct {
+	Function  Instance
+	Arguments []Instance
+	Form      Instance // the call, for the stack trace
+}
+
+func NewTailCall(function Instance, arguments ...Instance) Instance {
+	return TailCall{function, arguments, nil}
+}
+
+func (TailCall) Class() Class {
+	return TailCallClass
+}
 
+func (t TailCall) String() string {
+	return fmt.Sprintf("#%v", t.Class())
 }
 
 type method struct {
@@ -100,7 +258,44 @@ func (f *GenericFunction) String() string {
 	return fmt.Sprintf("#%v", f.Class())
 }
 
+// Arity returns the number of arguments the generic function requires, and
+// whether it accepts more.
+func (f *GenericFunction) Arity() (int, bool) {
+	return LambdaListArity(f.lambdaList)
+}
+
+// LambdaListArity returns the number of the required parameters of a lambda
+// list, and whether it has a rest parameter.
+func LambdaListArity(lambdaList Instance) (int, bool) {
+	list, ok := lambdaList.(List)
+	if !ok {
+		return 0, true
+	}
+	for i, parameter := range list.Slice() {
+		if DeepEqual(parameter, NewSymbol(":REST")) || DeepEqual(parameter, NewSymbol("&REST")) {
+			return i, true
+		}
+	}
+	return list.Length(), false
+}
+
+// Apply applies the methods of the generic function applicable to the
+// arguments.
 func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance, Instance) {
+	defer pushCall(e, f.funcSpec, arguments)()
+	if profiling(e) {
+		enterProfile(e, f.funcSpec, LocationOf(f.funcSpec))
+		defer leaveProfile(e)
+	}
+	if traced(e, f.funcSpec) {
+		return traceApplication(e, f.funcSpec, arguments, func() (Instance, Instance) {
+			return f.apply(e, arguments...)
+		})
+	}
+	return f.apply(e, arguments...)
+}
+
+func (f *GenericFunction) apply(e Environment, arguments ...Instance) (Instance, Instance) {
 	parameters := f.lambdaList.(List).Slice()
 	variadic := false
 	{
@@ -139,8 +334,15 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 				return true
 			}
 		}
-		t := map[Instance]int{around: 4, before: 3, nil: 2, after: 1}
-		return t[methods[a].qualifier] > t[methods[b].qualifier]
+		rank := func(qualifier Instance) int {
+			for i, q := range []Instance{after, nil, before, around} {
+				if DeepEqual(qualifier, q) {
+					return i
+				}
+			}
+			return 0
+		}
+		return rank(methods[a].qualifier) > rank(methods[b].qualifier)
 	})
 
 	nextMethodPisNil := NewFunction(NewSymbol("NEXT-METHOD-P"), func(e Environment) (Instance, Instance) {
@@ -161,7 +363,7 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 				e.Function.Define(NewSymbol("CALL-NEXT-METHOD"), NewFunction(NewSymbol("CALL-NEXT-METHOD"), callNextMethod))
 				e.Function.Define(NewSymbol("NEXT-METHOD-P"), nextMethodPisT)
 			}
-			return methods[index].function.Apply(e, arguments...) // Call next method
+			return methods[index].apply(e, f.funcSpec, arguments...) // Call next method
 		}
 		e.DynamicVariable.Define(NewSymbol("IRIS.DEPTH"), NewInteger(0)) // Set current depth
 		// If Generic Function has no next-mehtods,  NEXT-METHOD-P e function returns nil
@@ -170,7 +372,7 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 			e.Function.Define(NewSymbol("NEXT-METHOD-P"), nextMethodPisT)
 			e.Function.Define(NewSymbol("CALL-NEXT-METHOD"), NewFunction(NewSymbol("CALL-NEXT-METHOD"), callNextMethod))
 		}
-		return methods[0].function.Apply(e, arguments...) //Call first of method
+		return methods[0].apply(e, f.funcSpec, arguments...) //Call first of method
 	}
 	// if DeepEqual(f.methodCombination, NewSymbol("STANDARD"))
 	{
@@ -196,14 +398,14 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 								e.Function.Define(NewSymbol("CALL-NEXT-METHOD"), NewFunction(NewSymbol("CALL-NEXT-METHOD"), callNextMethod))
 							}
 						}
-						return methods[int(depth.(Integer))].function.Apply(e, arguments...) // Call next method
+						return methods[int(depth.(Integer))].apply(e, f.funcSpec, arguments...) // Call next method
 					}
 				}
 				// If has no :around method then,
 				// Do All :before mehtods
 				for _, method := range methods {
 					if DeepEqual(method.qualifier, before) {
-						if _, err := method.function.Apply(e, arguments...); err != nil {
+						if _, err := method.apply(e, f.funcSpec, arguments...); err != nil {
 							return nil, err
 						}
 					}
@@ -236,7 +438,7 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 							}
 						}
 					}
-					return methods[index].function.Apply(e, arguments...) // Call next method
+					return methods[index].apply(e, f.funcSpec, arguments...) // Call next method
 				} // callNextMethod ends here
 				index := 0 // index of the first primary method
 				{          // index != 0 is always true because this function has :around methods
@@ -256,14 +458,14 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 					}
 				}
 				// Do primary methods
-				ret, err := methods[index].function.Apply(e, arguments...)
+				ret, err := methods[index].apply(e, f.funcSpec, arguments...)
 				if err != nil {
 					return nil, err
 				}
 				// Do all :after methods
 				for i := len(methods) - 1; i >= 0; i-- {
 					if DeepEqual(methods[i].qualifier, after) {
-						if _, err := methods[i].function.Apply(e, arguments...); err != nil {
+						if _, err := methods[i].apply(e, f.funcSpec, arguments...); err != nil {
 							return nil, err
 						}
 					}
@@ -281,7 +483,7 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 					e.Function.Define(NewSymbol("CALL-NEXT-METHOD"), NewFunction(NewSymbol("CALL-NEXT-METHOD"), callNextMethod))
 				}
 			}
-			return methods[index].function.Apply(e, arguments...)
+			return methods[index].apply(e, f.funcSpec, arguments...)
 		}
 	}
 	{ // Function has no :around methods
@@ -306,12 +508,12 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 					e.Function.Define(NewSymbol("CALL-NEXT-METHOD"), NewFunction(NewSymbol("CALL-NEXT-METHOD"), callNextMethod))
 				}
 			}
-			return methods[int(depth.(Integer))].function.Apply(e, arguments...)
+			return methods[int(depth.(Integer))].apply(e, f.funcSpec, arguments...)
 		} // callNextMethod ends here
 		// Do All :before mehtods
 		for _, method := range methods {
 			if DeepEqual(method.qualifier, before) {
-				if _, err := method.function.Apply(e, arguments...); err != nil {
+				if _, err := method.apply(e, f.funcSpec, arguments...); err != nil {
 					return nil, err
 				}
 			}
@@ -320,7 +522,7 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 		{
 			test := func(i int) bool { return DeepEqual(methods[i].qualifier, nil) }
 			width := len(methods)
-			index := sort.Search(width, test)
+			index = sort.Search(width, test)
 			e.DynamicVariable.Define(NewSymbol("IRIS.DEPTH"), NewInteger(index))
 			if index == len(methods) {
 				return SignalCondition(e, NewUndefinedFunction(e, f.funcSpec), Nil)
@@ -335,11 +537,11 @@ func (f *GenericFunction) Apply(e Environment, arguments ...Instance) (Instance,
 				e.Function.Define(NewSymbol("CALL-NEXT-METHOD"), NewFunction(NewSymbol("CALL-NEXT-METHOD"), callNextMethod))
 			}
 		}
-		ret, err := methods[index].function.Apply(e, arguments...)
+		ret, err := methods[index].apply(e, f.funcSpec, arguments...)
 		// Do all :after methods
 		for i := len(methods) - 1; i >= 0; i-- {
 			if DeepEqual(methods[i].qualifier, after) {
-				if _, err := methods[i].function.Apply(e, arguments...); err != nil {
+				if _, err := methods[i].apply(e, f.funcSpec, arguments...); err != nil {
 					return nil, err
 				}
 			}
@@ -25,14 +25,47 @@ type Instance interface {
 }
 
 func DeepEqual(x, y interface{}) bool {
+	if reflect.TypeOf(x) != reflect.TypeOf(y) {
+		return false
+	}
+	switch p := x.(type) {
+	case Symbol:
+		return p.Equal(y)
+	case Integer, Float, Character:
+		return x == y
+	case *Null:
+		return true
+	case *BigInteger:
+		return p.Int().Cmp(y.(*BigInteger).Int()) == 0
+	case *Cons:
+		return p.Equal(y)
+	case Stream:
+		return p.Column == y.(Stream).Column
+	}
 	return reflect.DeepEqual(x, y) || cmp.Equal(x, y, cmp.AllowUnexported(BuiltInClass{}, StandardClass{}, Function{}))
 	//, cmpopts.IgnoreUnexported(Symbol{}))
 }
 
+// sameClass compares classes by name before falling back to DeepEqual, which
+// is far too slow to be called for every dispatch in the evaluator.
+func sameClass(x, y Class) bool {
+	switch p := x.(type) {
+	case BuiltInClass:
+		q, ok := y.(BuiltInClass)
+		return ok && p.name.String() == q.name.String()
+	case StandardClass:
+		q, ok := y.(StandardClass)
+		if !ok || p.name.String() != q.name.String() {
+			return false
+		}
+	}
+	return DeepEqual(x, y)
+}
+
 func SubclassOf(super, sub Class) bool {
 	var subclassof func(p, c Class) bool
 	subclassof = func(p, c Class) bool {
-		if DeepEqual(p, c) {
+		if sameClass(p, c) {
 			return true
 		}
 		for _, d := range c.Supers() {
@@ -51,7 +84,7 @@ func SubclassOf(super, sub Class) bool {
 }
 
 func InstanceOf(c Class, i Instance) bool {
-	if DeepEqual(c, i.Class()) {
+	if sameClass(c, i.Class()) {
 		return true
 	}
 	return SubclassOf(c, i.Class())
@@ -0,0 +1,163 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// coveredFile is the coverage of a file by lines: the lines where the forms
+// begin, with the most times one of them is evaluated, and the conditional
+// forms in order.
+type coveredFile struct {
+	name     string
+	lines    map[int]int64
+	branches []*coveredForm
+}
+
+// files returns the coverage of the files in the order they were loaded.
+func (c *Coverage) files() []*coveredFile {
+	files := []*coveredFile{}
+	byName := map[string]*coveredFile{}
+	for _, f := range c.order {
+		file, ok := byName[f.location.File]
+		if !ok {
+			file = &coveredFile{name: f.location.File, lines: map[int]int64{}}
+			byName[file.name] = file
+			files = append(files, file)
+		}
+		if count, ok := file.lines[f.location.Line]; !ok || f.count > count {
+			file.lines[f.location.Line] = f.count
+		}
+		if f.branches != nil {
+			file.branches = append(file.branches, f)
+		}
+	}
+	for _, file := range files {
+		sort.SliceStable(file.branches, func(i, j int) bool {
+			a, b := file.branches[i].location, file.branches[j].location
+			return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+		})
+	}
+	return files
+}
+
+// sortedLines returns the lines of the file where the forms begin, in order.
+func (file *coveredFile) sortedLines() []int {
+	lines := make([]int, 0, len(file.lines))
+	for line := range file.lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// summary returns the numbers of lines and branches of the file, and of
+// those evaluated and taken.
+func (file *coveredFile) summary() (lines, linesHit, branches, branchesHit int) {
+	for _, count := range file.lines {
+		lines++
+		if count > 0 {
+			linesHit++
+		}
+	}
+	for _, f := range file.branches {
+		for _, count := range f.branches {
+			branches++
+			if count > 0 {
+				branchesHit++
+			}
+		}
+	}
+	return lines, linesHit, branches, branchesHit
+}
+
+// WriteLcov writes the coverage to w in the tracefile format of lcov, a
+// record for each file. A line is evaluated as many times as the form
+// evaluated the most of those beginning on it. The branches of a conditional
+// form are numbered in order in the block of the form; a branch of a form
+// never evaluated is shown as "-".
+func (c *Coverage) WriteLcov(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	for _, file := range c.files() {
+		fmt.Fprintln(b, "TN:")
+		fmt.Fprintf(b, "SF:%s\n", file.name)
+		for block, f := range file.branches {
+			for branch, count := range f.branches {
+				taken := fmt.Sprint(count)
+				if f.count == 0 {
+					taken = "-"
+				}
+				fmt.Fprintf(b, "BRDA:%d,%d,%d,%s\n", f.location.Line, block, branch, taken)
+			}
+		}
+		lines, linesHit, branches, branchesHit := file.summary()
+		fmt.Fprintf(b, "BRF:%d\n", branches)
+		fmt.Fprintf(b, "BRH:%d\n", branchesHit)
+		for _, line := range file.sortedLines() {
+			fmt.Fprintf(b, "DA:%d,%d\n", line, file.lines[line])
+		}
+		fmt.Fprintf(b, "LF:%d\n", lines)
+		fmt.Fprintf(b, "LH:%d\n", linesHit)
+		fmt.Fprintln(b, "end_of_record")
+	}
+	return b.Flush()
+}
+
+const coverageStyle = `body { font-family: sans-serif; }
+pre { font-family: monospace; }
+.hit { background: #dfd; }
+.missed { background: #fdd; }
+.partial { background: #ffd; }
+.count { color: #888; display: inline-block; text-align: right; width: 6em; }`
+
+// WriteHTML writes the coverage to w as an HTML page showing the sources of
+// the files, which are read again, with the lines evaluated in green, those
+// never evaluated in red, and those with branches never taken in yellow.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "<!DOCTYPE html>")
+	fmt.Fprintf(b, "<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Coverage</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", coverageStyle)
+	for _, file := range c.files() {
+		src, err := ioutil.ReadFile(file.name)
+		if err != nil {
+			return err
+		}
+		partial := map[int]bool{}
+		for _, f := range file.branches {
+			for _, count := range f.branches {
+				if count == 0 {
+					partial[f.location.Line] = true
+				}
+			}
+		}
+		lines, linesHit, branches, branchesHit := file.summary()
+		fmt.Fprintf(b, "<h2>%s</h2>\n", html.EscapeString(file.name))
+		fmt.Fprintf(b, "<p>%d of %d lines, %d of %d branches</p>\n<pre>\n", linesHit, lines, branchesHit, branches)
+		for i, text := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+			count, ok := file.lines[i+1]
+			class, shown := "", ""
+			switch {
+			case !ok:
+			case count == 0:
+				class, shown = "missed", "0"
+			case partial[i+1]:
+				class, shown = "partial", fmt.Sprint(count)
+			default:
+				class, shown = "hit", fmt.Sprint(count)
+			}
+			fmt.Fprintf(b, "<span class=\"%s\"><span class=\"count\">%s</span> %4d  %s</span>\n", class, shown, i+1, html.EscapeString(text))
+		}
+		fmt.Fprintln(b, "</pre>")
+	}
+	fmt.Fprintln(b, "</body>\n</html>")
+	return b.Flush()
+}
@@ -21,10 +21,40 @@ type List interface {
 type Cons struct {
 	Car Instance
 	Cdr Instance
+	loc *Location
 }
 
 func NewCons(car, cdr Instance) Instance {
-	return &Cons{car, cdr}
+	return &Cons{car, cdr, nil}
+}
+
+// Location returns the location the cons was read from, or nil.
+func (i *Cons) Location() *Location {
+	return i.loc
+}
+
+func (i *Cons) SetLocation(loc *Location) {
+	i.loc = loc
+}
+
+// Equal compares the elements of two conses, ignoring their locations.
+func (i *Cons) Equal(y interface{}) bool {
+	j, ok := y.(*Cons)
+	for ok {
+		if i == j {
+			return true
+		}
+		if !DeepEqual(i.Car, j.Car) {
+			return false
+		}
+		k, iok := i.Cdr.(*Cons)
+		l, jok := j.Cdr.(*Cons)
+		if !iok || !jok {
+			return DeepEqual(i.Cdr, j.Cdr)
+		}
+		i, j = k, l
+	}
+	return false
 }
 
 func (*Cons) Class() Class {
@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import "fmt"
+
+// Location is the position of a form in the source it was read from. File is
+// empty if the source is not a named file. Line and Column start at 1.
+type Location struct {
+	File         string
+	Line, Column int
+}
+
+func NewLocation(file string, line, column int) *Location {
+	return &Location{file, line, column}
+}
+
+func (l *Location) String() string {
+	if l == nil {
+		return "?"
+	}
+	if l.File == "" {
+		return fmt.Sprintf("%d:%d", l.Line, l.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// Located is a form which records the location it was read from. The
+// location is nil if the form was not read.
+type Located interface {
+	Location() *Location
+}
+
+// LocationOf returns the location of the form obj, or nil if it has none.
+func LocationOf(obj Instance) *Location {
+	if l, ok := obj.(Located); ok {
+		return l.Location()
+	}
+	return nil
+}
+
+// Frame is an element of the stack trace of a condition, the innermost first:
+// the name of the operator called and the location of the call.
+type Frame struct {
+	Name     Instance
+	Location *Location
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%v (%v)", f.Name, f.Location)
+}
+
+// PushFrame adds an outer frame to the stack trace of the condition, which is
+// kept in the slot IRIS.STACKTRACE as a list of frames of the form (name file
+// line column), the innermost first, and returns the condition. A frame of the
+// same name without a location, as pushed by the function itself, is given
+// the location instead.
+func PushFrame(condition, name Instance, loc *Location) Instance {
+	c, ok := condition.(BasicInstance)
+	if !ok || !InstanceOf(SeriousConditionClass, condition) {
+		return condition
+	}
+	trace, ok := c.GetSlotValue(NewSymbol("IRIS.STACKTRACE"), SeriousConditionClass)
+	if !ok || trace == nil {
+		trace = Nil
+	}
+	var file Instance = Nil
+	line, column := -1, -1
+	if loc != nil {
+		if loc.File != "" {
+			file = NewString([]rune(loc.File))
+		}
+		line, column = loc.Line, loc.Column
+	}
+	frame := NewCons(name, NewCons(file, NewCons(NewInteger(line), NewCons(NewInteger(column), Nil))))
+	last, ok := trace.(*Cons)
+	if !ok {
+		c.SetSlotValue(NewSymbol("IRIS.STACKTRACE"), NewCons(frame, Nil), SeriousConditionClass)
+		return condition
+	}
+	for next, ok := last.Cdr.(*Cons); ok; next, ok = last.Cdr.(*Cons) {
+		last = next
+	}
+	if outer, ok := last.Car.(*Cons); ok && outer.Car.String() == name.String() && outer.Nth(2) == NewInteger(-1) {
+		last.Car = frame
+		return condition
+	}
+	last.Cdr = NewCons(frame, Nil)
+	return condition
+}
+
+// PushCallFrame adds the frame of the call form, a cons whose car names the
+// operator, to the stack trace of the condition and returns the condition.
+func PushCallFrame(condition, form Instance) Instance {
+	if c, ok := form.(*Cons); ok {
+		return PushFrame(condition, c.Car, c.loc)
+	}
+	return condition
+}
+
+// HasStacktrace reports whether the stack trace of the condition has frames,
+// without building them as Stacktrace does.
+func HasStacktrace(condition Instance) bool {
+	c, ok := condition.(BasicInstance)
+	if !ok {
+		return false
+	}
+	trace, ok := c.GetSlotValue(NewSymbol("IRIS.STACKTRACE"), SeriousConditionClass)
+	if !ok {
+		return false
+	}
+	_, ok = trace.(*Cons)
+	return ok
+}
+
+// Stacktrace returns the frames of the stack trace of the condition, the
+// innermost first.
+func Stacktrace(condition Instance) []Frame {
+	c, ok := condition.(BasicInstance)
+	if !ok {
+		return nil
+	}
+	trace, ok := c.GetSlotValue(NewSymbol("IRIS.STACKTRACE"), SeriousConditionClass)
+	if !ok {
+		return nil
+	}
+	frames := []Frame{}
+	for cons, ok := trace.(*Cons); ok; cons, ok = cons.Cdr.(*Cons) {
+		elements, ok := cons.Car.(*Cons)
+		if !ok || elements.Length() != 4 {
+			continue
+		}
+		frame := Frame{Name: elements.Nth(0)}
+		line, _ := elements.Nth(2).(Integer)
+		column, _ := elements.Nth(3).(Integer)
+		if line > 0 {
+			file, _ := elements.Nth(1).(String)
+			frame.Location = NewLocation(string(file), int(line), int(column))
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
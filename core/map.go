@@ -17,6 +17,8 @@ type Map interface {
 	String() string
 }
 
+var errNotFound = errors.New("not found")
+
 type HashMap struct {
 	table map[uint64]interface{}
 	keys  mapset.Set
@@ -27,52 +29,76 @@ func (m *HashMap) Equal(n interface{}) bool {
 	if !ok {
 		return false
 	}
+	if len(c.table) == 0 && len(m.table) == 0 {
+		return true
+	}
 	return cmp.Equal(c.table, m.table)
 }
 
+// NewHashMap returns an empty map. The table and key set are allocated on
+// the first Set, since most maps created by environments stay empty.
 func NewHashMap() *HashMap {
-	return &HashMap{map[uint64]interface{}{}, mapset.NewSet()}
+	return &HashMap{}
+}
+
+// hashKey avoids the reflection in hashstructure for symbols, which are by
+// far the most common keys.
+func hashKey(key interface{}) (uint64, error) {
+	if s, ok := key.(Symbol); ok {
+		return s.Hash()
+	}
+	return hashstructure.Hash(key, hashstructure.FormatV2, nil)
 }
 
 func (m *HashMap) Set(key, value interface{}) error {
-	hash, err := hashstructure.Hash(key, hashstructure.FormatV2, nil)
+	hash, err := hashKey(key)
 	if err != nil {
 		return err
 	}
+	if m.table == nil {
+		m.table = map[uint64]interface{}{}
+		m.keys = mapset.NewSet()
+	}
 	m.table[hash] = value
 	m.keys.Add(key)
 	return nil
 }
 
 func (m *HashMap) Get(key interface{}) (interface{}, error) {
-	hash, err := hashstructure.Hash(key, hashstructure.FormatV2, nil)
+	hash, err := hashKey(key)
 	if err != nil {
 		return nil, err
 	}
 	value, ok := m.table[hash]
 	if !ok {
-		return nil, errors.New("not found")
+		return nil, errNotFound
 	}
 	return value, nil
 }
 
 func (m *HashMap) Delete(key interface{}) error {
-	hash, err := hashstructure.Hash(key, hashstructure.FormatV2, nil)
+	hash, err := hashKey(key)
 	if err != nil {
 		return err
 	}
+	if m.table == nil {
+		return nil
+	}
 	delete(m.table, hash)
 	m.keys.Remove(hash)
 	return nil
 }
 
 func (m *HashMap) Keys() []interface{} {
+	if m.keys == nil {
+		return []interface{}{}
+	}
 	return m.keys.ToSlice()
 }
 
 func (m *HashMap) String() (str string) {
 	str += "{"
-	keys := m.keys.ToSlice()
+	keys := m.Keys()
 	for idx, key := range keys {
 		val, _ := m.Get(key)
 		str += fmt.Sprintf("%v: %v", key, val)
@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import "strings"
+
+// UserModule is the name of the module the reader starts in. Its symbols are
+// not qualified.
+const UserModule = "USER"
+
+// Module is a namespace of top level definitions. The reader qualifies the
+// symbols read in a module other than USER by its name, as GEOMETRY:AREA, so
+// that the definitions of different modules never collide in the global
+// tables. The names of the builtins, keywords and the names imported from
+// other modules are not qualified.
+type Module struct {
+	Name    string
+	exports map[string]bool     // the names exported
+	symbols map[string]bool     // the names qualified by this module
+	imports map[string]Instance // the symbols imported by their local names
+}
+
+func newModule(name string) *Module {
+	return &Module{name, map[string]bool{}, map[string]bool{}, map[string]Instance{}}
+}
+
+// Export exports the symbol of the module named name.
+func (m *Module) Export(name string) {
+	m.exports[name] = true
+	m.symbols[name] = true
+}
+
+// Exports reports whether the module exports its symbol named name.
+func (m *Module) Exports(name string) bool {
+	return m.exports[name]
+}
+
+// ExportedNames returns the names of the symbols exported by the module.
+func (m *Module) ExportedNames() []string {
+	names := []string{}
+	for name := range m.exports {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Shadow makes the reader qualify name in the module even if it names a
+// builtin.
+func (m *Module) Shadow(name string) {
+	m.symbols[name] = true
+}
+
+// Import makes the reader read name in the module as symbol.
+func (m *Module) Import(name string, symbol Instance) {
+	m.imports[name] = symbol
+}
+
+// Symbol returns the symbol of the module named name.
+func (m *Module) Symbol(name string) Instance {
+	return NewSymbol(m.Name + ":" + name)
+}
+
+// DefineModule returns the module named name, which is created if it does
+// not exist yet.
+func (r *Runtime) DefineModule(name string) *Module {
+	if r.modules == nil {
+		r.modules = map[string]*Module{}
+	}
+	m, ok := r.modules[name]
+	if !ok {
+		m = newModule(name)
+		r.modules[name] = m
+	}
+	return m
+}
+
+// Module returns the module named name.
+func (r *Runtime) Module(name string) (*Module, bool) {
+	m, ok := r.modules[name]
+	return m, ok
+}
+
+// CurrentModule returns the module the symbols are read in, or nil for USER.
+func (r *Runtime) CurrentModule() *Module {
+	return r.module
+}
+
+// SetCurrentModule sets the module the symbols are read in, nil for USER.
+func (r *Runtime) SetCurrentModule(m *Module) {
+	r.module = m
+}
+
+// MarkBuiltins records the names defined in the global tables of e as the
+// builtins, which are not qualified by the modules.
+func MarkBuiltins(e Environment) {
+	r := e.Runtime
+	r.builtins = map[string]bool{"T": true, "NIL": true, "UNQUOTE": true, "UNQUOTE-SPLICING": true, "STANDARD": true, "CALL-NEXT-METHOD": true, "NEXT-METHOD-P": true}
+	for _, table := range []stack{e.Function, e.Macro, e.Special, e.Variable, e.Constant, e.Class} {
+		for _, key := range table[:1].Keys() {
+			r.builtins[key.String()] = true
+		}
+	}
+}
+
+// BaseName returns the name of a symbol without its module.
+func BaseName(symbol Instance) string {
+	name := symbol.String()
+	if i := strings.LastIndex(name, ":"); i > 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// Intern returns the symbol read as name, already in upper case, at loc in
+// the current module. module is the name of the module written before name,
+// or empty, and internal is true if they are separated by "::". An unknown
+// module, or a symbol not exported without "::", signals an error.
+func Intern(e Environment, module, name string, internal bool, loc *Location) (Instance, Instance) {
+	r := e.Runtime
+	if module != "" {
+		if module == UserModule {
+			return NewSymbolAt(name, loc), nil
+		}
+		m, ok := r.Module(module)
+		if !ok {
+			return SignalCondition(e, NewUndefinedModule(e, NewSymbolAt(module, loc)), Nil)
+		}
+		if !internal && !m.Exports(name) {
+			return SignalCondition(e, NewSimpleError(e,
+				NewString([]rune("The symbol ~A is not exported from the module ~A")),
+				NewCons(NewString([]rune(name)), NewCons(NewString([]rune(module)), Nil))), Nil)
+		}
+		return NewSymbolAt(module+":"+name, loc), nil
+	}
+	m := r.module
+	if m == nil || name == "" || name[0] == ':' || name[0] == '&' {
+		return NewSymbolAt(name, loc), nil
+	}
+	if symbol, ok := m.imports[name]; ok {
+		return NewSymbolAt(symbol.String(), loc), nil
+	}
+	if !m.symbols[name] && r.builtins[name] {
+		return NewSymbolAt(name, loc), nil
+	}
+	m.symbols[name] = true
+	return NewSymbolAt(m.Name+":"+name, loc), nil
+}
@@ -6,6 +6,7 @@ package core
 
 import (
 	"fmt"
+	"math/big"
 )
 
 // Integer
@@ -23,6 +24,33 @@ func (i Integer) String() string {
 	return fmt.Sprint(int(i))
 }
 
+// BigInteger
+
+// BigInteger is an integer too large for an Integer. NewBigInteger returns an
+// Integer for the values which fit, so the two types never hold the same
+// value and small integers stay as fast as before.
+type BigInteger big.Int
+
+func NewBigInteger(i *big.Int) Instance {
+	if i.IsInt64() && int64(int(i.Int64())) == i.Int64() {
+		return Integer(i.Int64())
+	}
+	return (*BigInteger)(i)
+}
+
+func (*BigInteger) Class() Class {
+	return IntegerClass
+}
+
+func (i *BigInteger) String() string {
+	return (*big.Int)(i).String()
+}
+
+// Int returns the value of i. The result must not be modified.
+func (i *BigInteger) Int() *big.Int {
+	return (*big.Int)(i)
+}
+
 // Float
 
 type Float float64
@@ -0,0 +1,83 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+)
+
+// typeAssertion matches the message of a failed type assertion, giving the
+// type of the value and the type asserted.
+var typeAssertion = regexp.MustCompile(`^interface conversion: .* is (\S+), not ([^:]+)`)
+
+// typeClasses are the classes of the instances of the Go types asserted by
+// the builtins.
+var typeClasses = map[string]Class{}
+
+func init() {
+	for _, t := range []struct {
+		sample interface{}
+		class  Class
+	}{
+		{Integer(0), IntegerClass},
+		{(*BigInteger)(nil), IntegerClass},
+		{Float(0), FloatClass},
+		{Character(0), CharacterClass},
+		{String(nil), StringClass},
+		{Symbol{}, SymbolClass},
+		{(*Cons)(nil), ConsClass},
+		{Null{}, NullClass},
+		{(*List)(nil), ListClass},
+		{GeneralVector(nil), GeneralVectorClass},
+		{(*GeneralArrayStar)(nil), GeneralArrayStarClass},
+		{Stream{}, StreamClass},
+		{Function{}, FunctionClass},
+		{(*Applicable)(nil), FunctionClass},
+		{(*GenericFunction)(nil), GenericFunctionClass},
+		{BasicInstance{}, StandardObjectClass},
+	} {
+		typ := reflect.TypeOf(t.sample)
+		if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+			typ = typ.Elem()
+		}
+		typeClasses[typ.String()] = t.class
+	}
+}
+
+// NewPanicError returns the condition of a panic with the value in the
+// builtin function named name called with the arguments. A failed type
+// assertion on an argument is a <domain-error> of the argument and the class
+// expected, and any other panic is a <program-error> which keeps the name and
+// the value in its slots IRIS.OPERATION and IRIS.PANIC. Its frame is named by
+// the function, and is located by the caller. The builtins check their
+// arguments rather than panic, so such a panic is a bug of the builtin, which
+// the Panic of the runtime is told about first.
+func NewPanicError(e Environment, name Instance, value interface{}, arguments []Instance) Instance {
+	if r := e.Runtime; r != nil && r.Panic != nil {
+		r.Panic(name, value)
+	}
+	var condition Instance
+	if err, ok := value.(*runtime.TypeAssertionError); ok {
+		if m := typeAssertion.FindStringSubmatch(err.Error()); m != nil {
+			if class, ok := typeClasses[m[2]]; ok {
+				for _, argument := range arguments {
+					if fmt.Sprintf("%T", argument) == m[1] {
+						condition = NewDomainError(e, argument, class)
+						break
+					}
+				}
+			}
+		}
+	}
+	if condition == nil {
+		condition = Create(e, ProgramErrorClass,
+			NewSymbol("IRIS.OPERATION"), name,
+			NewSymbol("IRIS.PANIC"), NewString([]rune(fmt.Sprint(value))))
+	}
+	return PushFrame(condition, name, nil)
+}
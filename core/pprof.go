@@ -0,0 +1,150 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// WritePprof writes the profile to w in the format of pprof, a gzipped
+// protocol buffer of profile.proto. The profile has two sample types: calls,
+// the number of applications, and time, the time spent in the functions
+// themselves. The frames are the functions by their labels, with the
+// locations of their definitions and the lines of the calls they made.
+func (p *Profile) WritePprof(w io.Writer) error {
+	strings := map[string]int{"": 0}
+	table := []string{""}
+	str := func(s string) uint64 {
+		i, ok := strings[s]
+		if !ok {
+			i = len(table)
+			strings[s] = i
+			table = append(table, s)
+		}
+		return uint64(i)
+	}
+	functions := make([]profileFunction, len(p.functions))
+	for f, i := range p.functions {
+		functions[i] = f
+	}
+	out := new(protobuf)
+	for _, t := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}} {
+		valueType := new(protobuf)
+		valueType.uint64(1, str(t[0]))
+		valueType.uint64(2, str(t[1]))
+		out.message(1, valueType)
+	}
+	// The locations are the functions at the lines, numbered from 1 in
+	// the order they are met.
+	locations := map[[2]int]uint64{}
+	messages := []*protobuf{}
+	location := func(function, line int) uint64 {
+		if line == 0 {
+			line = functions[function].line
+		}
+		key := [2]int{function, line}
+		id, ok := locations[key]
+		if !ok {
+			id = uint64(len(locations) + 1)
+			locations[key] = id
+			l := new(protobuf)
+			l.uint64(1, id)
+			ln := new(protobuf)
+			ln.uint64(1, uint64(function+1))
+			ln.uint64(2, uint64(line))
+			l.message(4, ln)
+			messages = append(messages, l)
+		}
+		return id
+	}
+	var walk func(node *profileNode)
+	walk = func(node *profileNode) {
+		if node.calls > 0 {
+			ids := []uint64{location(node.function, 0)}
+			for n := node; n.parent.function >= 0; n = n.parent {
+				ids = append(ids, location(n.parent.function, n.line))
+			}
+			sample := new(protobuf)
+			sample.packed(1, ids)
+			sample.packed(2, []uint64{uint64(node.calls), uint64(node.time)})
+			out.message(2, sample)
+		}
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	walk(p.root)
+	for _, l := range messages {
+		out.message(4, l)
+	}
+	for i, f := range functions {
+		function := new(protobuf)
+		function.uint64(1, uint64(i+1))
+		function.uint64(2, str(f.label))
+		function.uint64(4, str(f.file))
+		function.uint64(5, uint64(f.line))
+		out.message(5, function)
+	}
+	timeType := new(protobuf)
+	timeType.uint64(1, str("time"))
+	timeType.uint64(2, str("nanoseconds"))
+	out.uint64(9, uint64(p.start.UnixNano()))
+	out.uint64(10, uint64(p.duration))
+	out.message(11, timeType)
+	out.uint64(14, str("time"))
+	for _, s := range table {
+		out.bytes(6, []byte(s))
+	}
+	z := gzip.NewWriter(w)
+	if _, err := z.Write(out.Bytes()); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+// protobuf is an encoder of the wire format of protocol buffers, enough for
+// the messages of profile.proto.
+type protobuf struct {
+	bytes.Buffer
+}
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+// uint64 encodes a varint field, left out if zero.
+func (b *protobuf) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(field) << 3)
+	b.varint(x)
+}
+
+// bytes encodes a length-delimited field, even if empty.
+func (b *protobuf) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
+
+func (b *protobuf) message(field int, m *protobuf) {
+	b.bytes(field, m.Bytes())
+}
+
+// packed encodes a repeated varint field.
+func (b *protobuf) packed(field int, xs []uint64) {
+	data := new(protobuf)
+	for _, x := range xs {
+		data.varint(x)
+	}
+	b.bytes(field, data.Bytes())
+}
@@ -0,0 +1,139 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import "time"
+
+// Profile records the number of calls of the functions, generic functions,
+// methods and macro expanders applied while it is started, and the time spent
+// in them. The applications are recorded by their stacks, as a tree whose
+// nodes are the stacks, so that each application is recorded in constant time.
+type Profile struct {
+	functions map[profileFunction]int // the indices of the functions
+	root      *profileNode
+	stack     []*profileEntry // the applications in progress, the innermost last
+	start     time.Time
+	duration  time.Duration
+}
+
+// profileFunction is a function as it is shown in the profile: its label, and
+// the location of its definition if known.
+type profileFunction struct {
+	label string
+	file  string
+	line  int
+}
+
+// profileNode is a stack of applications: the application of the function at
+// line of the caller, the stack of the parent.
+type profileNode struct {
+	function int
+	line     int // the line of the call in the caller, 0 for the top level
+	parent   *profileNode
+	children map[[2]int]*profileNode // by function and line of the call
+	calls    int64
+	time     time.Duration // spent in the function itself
+}
+
+type profileEntry struct {
+	node     *profileNode
+	line     int // the line of the form evaluated, 0 if unknown
+	start    time.Time
+	children time.Duration // spent in the applications made
+}
+
+// NewProfile returns a profile recording nothing yet.
+func NewProfile() *Profile {
+	return &Profile{functions: map[profileFunction]int{}, root: &profileNode{function: -1}}
+}
+
+// StartProfile makes the runtime record the applications in p, which may be
+// started already. Profiles may be started in any number.
+func (r *Runtime) StartProfile(p *Profile) {
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	p.stack = []*profileEntry{{node: p.root, start: time.Now()}}
+	r.profiles = append(r.profiles, p)
+}
+
+// StopProfile stops recording the applications in p.
+func (r *Runtime) StopProfile(p *Profile) {
+	for i, q := range r.profiles {
+		if q == p {
+			r.profiles = append(r.profiles[:i:i], r.profiles[i+1:]...)
+			p.duration += time.Since(p.stack[0].start)
+			p.stack = nil
+			return
+		}
+	}
+}
+
+// profiling reports whether the applications are recorded in a profile.
+func profiling(e Environment) bool {
+	return e.Runtime != nil && len(e.Runtime.profiles) > 0
+}
+
+// enterProfile records the start of the application of the function shown as
+// label, defined at location.
+func enterProfile(e Environment, label Instance, location *Location) {
+	f := profileFunction{label: label.String()}
+	if location != nil {
+		f.file, f.line = location.File, location.Line
+	}
+	for _, p := range e.Runtime.profiles {
+		p.enter(f)
+	}
+}
+
+// leaveProfile records the end of the innermost application.
+func leaveProfile(e Environment) {
+	for _, p := range e.Runtime.profiles {
+		p.leave()
+	}
+}
+
+// markProfile records the line of the form evaluated in the innermost
+// application.
+func markProfile(e Environment, form Instance) {
+	location := LocationOf(form)
+	if location == nil {
+		return
+	}
+	for _, p := range e.Runtime.profiles {
+		p.stack[len(p.stack)-1].line = location.Line
+	}
+}
+
+func (p *Profile) enter(f profileFunction) {
+	id, ok := p.functions[f]
+	if !ok {
+		id = len(p.functions)
+		p.functions[f] = id
+	}
+	caller := p.stack[len(p.stack)-1]
+	key := [2]int{id, caller.line}
+	node, ok := caller.node.children[key]
+	if !ok {
+		node = &profileNode{function: id, line: caller.line, parent: caller.node}
+		if caller.node.children == nil {
+			caller.node.children = map[[2]int]*profileNode{}
+		}
+		caller.node.children[key] = node
+	}
+	p.stack = append(p.stack, &profileEntry{node: node, start: time.Now()})
+}
+
+func (p *Profile) leave() {
+	if len(p.stack) < 2 {
+		return
+	}
+	entry := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	elapsed := time.Since(entry.start)
+	entry.node.calls++
+	entry.node.time += elapsed - entry.children
+	p.stack[len(p.stack)-1].children += elapsed
+}
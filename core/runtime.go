@@ -0,0 +1,205 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+// Runtime keeps the mutable state shared by all the environments derived from
+// one NewEnvironment. Environments of different runtimes never share it.
+type Runtime struct {
+	Limits Limits
+	Usage  Usage
+	// Debug enables the checks of the debug mode: the classes declared by
+	// THE are checked like ASSURE.
+	Debug bool
+	// StackDepth is the number of nested function applications beyond which a
+	// <storage-exhausted> is signaled, before the Go stack overflows. Zero
+	// means DefaultStackDepth.
+	StackDepth int
+	// Panic is called, if not nil, with the name of a builtin function and the
+	// value it panics with, before the panic is signaled as a <program-error>.
+	Panic func(name Instance, value interface{})
+
+	unique    int
+	signaling bool                // true while a handler of <quota-exceeded> runs
+	exhausted bool                // true while a handler of <storage-exhausted> runs
+	cancelled bool                // true while a handler of <cancelled> runs
+	grace     int                 // the checks left to the handler of <cancelled>
+	provided  map[string]bool     // the names of the modules provided
+	modules   map[string]*Module  // the modules defined by their names
+	module    *Module             // the current module, nil for USER
+	builtins  map[string]bool     // the names not qualified by the modules
+	traced    map[string]Instance // the names of the functions traced
+	tracing   int                 // traced applications in progress
+	debugger  Debugger            // called with the conditions not handled, if not nil
+	calls     []*Call             // the applications in progress, while debugged
+	stepper   Stepper             // called with the forms evaluated, if not nil
+	stepping  bool                // true while the stepper runs
+	profiles  []*Profile          // the profiles started
+	coverage  *Coverage           // the coverage started, or nil
+	tests     []*Test             // the tests defined, in order
+	suites    map[string]*Suite   // the suites of tests by their names
+	suite     *Suite              // the current suite, nil outside of the suites
+}
+
+// Limits bounds the resources used by the evaluations in a runtime. A zero
+// field means no limit. The limits are counted in steps and elements rather
+// than time and bytes, so that the same program always stops at the same
+// point.
+type Limits struct {
+	Steps int // forms evaluated
+	Depth int // nested function applications
+	Size  int // elements of a string, array or list created at once
+}
+
+// Usage counts the resources used by the evaluations in a runtime. The host
+// may read it after an evaluation, or reset it between evaluations.
+type Usage struct {
+	Steps     int // forms evaluated
+	Depth     int // function applications in progress
+	MaxDepth  int // largest Depth so far
+	Allocated int // elements of the strings, arrays and lists created
+}
+
+// DefaultStackDepth is the stack depth of a runtime unless set otherwise. A
+// function application takes up to about 16KB of the Go stack, which is
+// limited to 1GB by default.
+const DefaultStackDepth = 10000
+
+// stackReserve is the depth left to the handler of the <storage-exhausted>
+// signaled at the stack depth.
+const stackReserve = 1000
+
+func NewRuntime() *Runtime {
+	return &Runtime{}
+}
+
+// UniqueInt returns a number which is never returned again by this runtime.
+func (r *Runtime) UniqueInt() int {
+	i := r.unique
+	r.unique++
+	return i
+}
+
+// Provide records that the module name has been loaded.
+func (r *Runtime) Provide(name string) {
+	if r.provided == nil {
+		r.provided = map[string]bool{}
+	}
+	r.provided[name] = true
+}
+
+// Provided reports whether the module name has been loaded.
+func (r *Runtime) Provided(name string) bool {
+	return r.provided[name]
+}
+
+// quotaReserve is the number of steps, and of nested function applications,
+// left to the handler of a <quota-exceeded> beyond the limit exceeded.
+const quotaReserve = 1000
+
+// reserve returns quotaReserve while the handler of a <quota-exceeded> runs,
+// and 0 otherwise.
+func (r *Runtime) reserve() int {
+	if r.signaling {
+		return quotaReserve
+	}
+	return 0
+}
+
+// exceed signals a <quota-exceeded>. Its handler is left quotaReserve steps
+// and applications beyond the limits; a handler exceeding them makes the
+// condition be returned without being signaled again.
+func (r *Runtime) exceed(e Environment, quota string, limit int) (Instance, Instance) {
+	condition := NewQuotaExceeded(e, NewSymbol(quota), NewInteger(limit))
+	if r.signaling {
+		return nil, condition
+	}
+	r.signaling = true
+	defer func() { r.signaling = false }()
+	return SignalCondition(e, condition, Nil)
+}
+
+// CountStep counts the evaluation of a form.
+func CountStep(e Environment) (Instance, Instance) {
+	r := e.Runtime
+	if r == nil {
+		return nil, nil
+	}
+	r.Usage.Steps++
+	if r.Limits.Steps > 0 && r.Usage.Steps > r.Limits.Steps+r.reserve() {
+		return r.exceed(e, "STEPS", r.Limits.Steps)
+	}
+	return nil, nil
+}
+
+// EnterFunction counts the start of a function application. Unless it
+// signals, it must be paired with LeaveFunction.
+func EnterFunction(e Environment) (Instance, Instance) {
+	r := e.Runtime
+	if r == nil {
+		return nil, nil
+	}
+	r.Usage.Depth++
+	if r.Usage.Depth > r.Usage.MaxDepth {
+		r.Usage.MaxDepth = r.Usage.Depth
+	}
+	if r.Limits.Depth > 0 && r.Usage.Depth > r.Limits.Depth+r.reserve() {
+		if _, err := r.exceed(e, "DEPTH", r.Limits.Depth); err != nil {
+			r.Usage.Depth--
+			return nil, err
+		}
+	}
+	if r.Usage.Depth > r.stackDepth() {
+		r.Usage.Depth--
+		return r.exhaust(e)
+	}
+	return nil, nil
+}
+
+// stackDepth returns the stack depth, which is deeper by stackReserve while
+// the handler of the <storage-exhausted> runs.
+func (r *Runtime) stackDepth() int {
+	depth := r.StackDepth
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	if r.exhausted {
+		depth += stackReserve
+	}
+	return depth
+}
+
+// exhaust signals a <storage-exhausted> as the stack depth is exceeded. A
+// handler exceeding the reserve left to it makes the condition be returned
+// without being signaled again.
+func (r *Runtime) exhaust(e Environment) (Instance, Instance) {
+	condition := NewStackExhausted(e, r.stackDepth())
+	if r.exhausted {
+		return nil, condition
+	}
+	r.exhausted = true
+	defer func() { r.exhausted = false }()
+	return SignalCondition(e, condition, Nil)
+}
+
+// LeaveFunction counts the end of a function application.
+func LeaveFunction(e Environment) {
+	if e.Runtime != nil {
+		e.Runtime.Usage.Depth--
+	}
+}
+
+// CountAllocation counts the creation of a string, array or list of n
+// elements, and signals before it is created if n is over the size limit.
+func CountAllocation(e Environment, n int) (Instance, Instance) {
+	r := e.Runtime
+	if r == nil || n < 0 {
+		return nil, nil
+	}
+	if r.Limits.Size > 0 && n > r.Limits.Size {
+		return r.exceed(e, "SIZE", r.Limits.Size)
+	}
+	r.Usage.Allocated += n
+	return nil, nil
+}
@@ -42,9 +42,35 @@ func (s stack) Delete(key Instance) {
 	s[len(s) - 1].Delete(key)
 }
 
+// Keys returns the keys defined in any map of the stack.
+func (s stack) Keys() []Instance {
+	keys := []Instance{}
+	for _, m := range s {
+		for _, key := range m.Keys() {
+			keys = append(keys, key.(Instance))
+		}
+	}
+	return keys
+}
+
 func (s stack) Append(t stack) stack {
-	u := stack{}
+	u := make(stack, 0, len(s)+len(t))
 	u = append(u, s...)
 	u = append(u, t...)
 	return u
 }
+
+// Push returns a copy of the stack with a new empty map on the top. The
+// empty maps above the bottom one are left out of the copy, as they hold no
+// bindings, so that the stacks of nested applications do not grow with their
+// depth.
+func (s stack) Push() stack {
+	u := make(stack, 1, len(s)+1)
+	u[0] = s[0]
+	for _, m := range s[1:] {
+		if h, ok := m.(*HashMap); !ok || len(h.table) > 0 {
+			u = append(u, m)
+		}
+	}
+	return append(u, NewHashMap())
+}
@@ -4,21 +4,23 @@
 
 package core
 
-import hashstructure "github.com/mitchellh/hashstructure/v2"
+import "hash/fnv"
 
 // Symbol
 
 type Symbol struct {
-	str          string
-	line, column int
+	str string
+	loc *Location
 }
 
 func (x Symbol) Hash() (uint64, error) {
-	return hashstructure.Hash(x.str, hashstructure.FormatV2, nil)
+	h := fnv.New64a()
+	h.Write([]byte(x.str))
+	return h.Sum64(), nil
 }
 
-func (x Symbol) Location() (line, column int) {
-	return x.line, x.column
+func (x Symbol) Location() *Location {
+	return x.loc
 }
 
 func (x Symbol) Equal(y interface{}) bool {
@@ -31,9 +33,14 @@ func (x Symbol) Equal(y interface{}) bool {
 
 func NewSymbol(s string, pos ...int) Instance {
 	if len(pos) != 2 {
-		return Symbol{s, -1, -1}
+		return Symbol{s, nil}
 	}
-	return Symbol{s, pos[0], pos[1]}
+	return Symbol{s, NewLocation("", pos[0], pos[1])}
+}
+
+// NewSymbolAt returns a symbol read at the location loc.
+func NewSymbolAt(s string, loc *Location) Instance {
+	return Symbol{s, loc}
 }
 
 func (Symbol) Class() Class {
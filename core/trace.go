@@ -0,0 +1,115 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Trace makes the applications of the functions named name be traced. The
+// functions are found by their names when applied, so that the functions
+// defined later, or locally by FLET and LABELS, are traced too.
+func (r *Runtime) Trace(name Instance) {
+	if r.traced == nil {
+		r.traced = map[string]Instance{}
+	}
+	r.traced[name.String()] = name
+}
+
+// Untrace stops tracing the functions named name, and reports whether they
+// were traced.
+func (r *Runtime) Untrace(name Instance) bool {
+	_, ok := r.traced[name.String()]
+	delete(r.traced, name.String())
+	return ok
+}
+
+// Traced returns the names of the functions traced, sorted.
+func (r *Runtime) Traced() []Instance {
+	names := []Instance{}
+	for _, name := range r.traced {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+	return names
+}
+
+// traced reports whether the applications of the functions named name are
+// traced.
+func traced(e Environment, name Instance) bool {
+	if e.Runtime == nil || len(e.Runtime.traced) == 0 || name == nil {
+		return false
+	}
+	_, ok := e.Runtime.traced[name.String()]
+	return ok
+}
+
+// traceApplication applies a traced function with apply. It prints a line
+// with the label and the arguments to the error output before, and a line
+// with the value returned or the condition signaled after. The lines are
+// indented by the number of the traced applications in progress.
+func traceApplication(e Environment, label Instance, arguments []Instance, apply func() (Instance, Instance)) (Instance, Instance) {
+	r := e.Runtime
+	depth := r.tracing
+	r.tracing++
+	defer func() { r.tracing-- }()
+	call := Instance(Nil)
+	for i := len(arguments) - 1; i >= 0; i-- {
+		call = NewCons(arguments[i], call)
+	}
+	printTrace(e, depth, "%v", NewCons(label, call))
+	ret, err := apply()
+	switch {
+	case err == nil:
+		printTrace(e, depth, "%v returned %v", label, ret)
+	case InstanceOf(EscapeClass, err):
+		printTrace(e, depth, "%v exited", label)
+	default:
+		printTrace(e, depth, "%v signaled %v", label, err.Class())
+	}
+	return ret, err
+}
+
+func printTrace(e Environment, depth int, format string, a ...interface{}) {
+	stream, ok := e.ErrorOutput.(Stream)
+	if !ok || stream.BufferedWriter == nil || stream.BufferedWriter.Raw == nil {
+		return
+	}
+	fmt.Fprintf(stream, "%s%d: %s\n", strings.Repeat("  ", depth), depth, fmt.Sprintf(format, a...))
+	stream.Flush()
+}
+
+// label returns the label of the method in the trace and the profile of the
+// generic function named name, such as (METHOD FOO :AROUND (<INTEGER>)).
+func (m method) label(name Instance) Instance {
+	classes := Instance(Nil)
+	for i := len(m.classList) - 1; i >= 0; i-- {
+		classes = NewCons(m.classList[i], classes)
+	}
+	label := NewCons(classes, Nil)
+	if m.qualifier != nil {
+		label = NewCons(m.qualifier, label)
+	}
+	return NewCons(NewSymbol("METHOD"), NewCons(name, label))
+}
+
+// apply applies the method. The parameters of each method are bound in a
+// map of their own, as the methods of an application share e. The method is
+// traced when the generic function named name is, and profiled by its label.
+func (m method) apply(e Environment, name Instance, arguments ...Instance) (Instance, Instance) {
+	e.Variable = e.Variable.Push()
+	if !traced(e, name) && !profiling(e) {
+		return m.function.Apply(e, arguments...)
+	}
+	label := m.label(name)
+	if !traced(e, name) {
+		return m.function.applyAs(e, label, arguments...)
+	}
+	return traceApplication(e, label, arguments, func() (Instance, Instance) {
+		return m.function.applyAs(e, label, arguments...)
+	})
+}
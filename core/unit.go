@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package core
+
+// Suite is a suite of tests defined by DEFSUITE. Its fixtures Setup and
+// Teardown are functions of no arguments applied before and after each of its
+// tests, or nil.
+type Suite struct {
+	Name     Instance
+	Setup    Instance
+	Teardown Instance
+}
+
+// Test is a test defined by DEFTEST: a function of no arguments, which fails
+// by signaling a condition.
+type Test struct {
+	Name     Instance
+	Suite    *Suite // nil outside of the suites
+	Location *Location
+	Function Instance
+}
+
+// FullName returns the name of the test qualified by its suite, such as
+// MATH/ADDITION.
+func (t *Test) FullName() string {
+	if t.Suite == nil {
+		return t.Name.String()
+	}
+	return t.Suite.Name.String() + "/" + t.Name.String()
+}
+
+// DefineSuite defines the suite, replacing the one of the same name, and
+// makes it the current suite.
+func (r *Runtime) DefineSuite(s *Suite) {
+	if r.suites == nil {
+		r.suites = map[string]*Suite{}
+	}
+	if old, ok := r.suites[s.Name.String()]; ok {
+		*old = *s
+		s = old
+	}
+	r.suites[s.Name.String()] = s
+	r.suite = s
+}
+
+// Suite returns the suite named name.
+func (r *Runtime) Suite(name string) (*Suite, bool) {
+	s, ok := r.suites[name]
+	return s, ok
+}
+
+// CurrentSuite returns the suite of the tests defined, or nil outside of the
+// suites.
+func (r *Runtime) CurrentSuite() *Suite {
+	return r.suite
+}
+
+// SetCurrentSuite sets the suite of the tests defined, nil outside of the
+// suites.
+func (r *Runtime) SetCurrentSuite(s *Suite) {
+	r.suite = s
+}
+
+// DefineTest defines the test, replacing the one of the same name in the same
+// suite.
+func (r *Runtime) DefineTest(t *Test) {
+	for i, u := range r.tests {
+		if u.FullName() == t.FullName() {
+			r.tests[i] = t
+			return
+		}
+	}
+	r.tests = append(r.tests, t)
+}
+
+// Tests returns the tests defined, in the order of their definitions.
+func (r *Runtime) Tests() []*Test {
+	return append([]*Test(nil), r.tests...)
+}
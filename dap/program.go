@@ -0,0 +1,201 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package dap
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/repl"
+)
+
+// visit is a form of the program in evaluation.
+type visit struct {
+	location *core.Location
+	env      core.Environment
+	calls    int // the function applications in progress
+}
+
+// stop is the state of the program stopped.
+type stop struct {
+	frames []frame // the innermost first
+	depth  int     // the forms in evaluation
+	outer  int     // the forms in evaluation out of the innermost application
+}
+
+// frame is a function application in progress, or the top level.
+type frame struct {
+	name     string
+	location *core.Location // the form it evaluates, or nil if unknown
+	env      core.Environment
+}
+
+// output is a stream of the program, written as output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o output) Write(p []byte) (int, error) {
+	o.s.event("output", OutputEventBody{o.category, string(p)})
+	return len(p), nil
+}
+
+// start runs the program on a goroutine of its own.
+func (s *Server) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.env.Context = ctx
+	s.env.StandardInput = core.NewStream(strings.NewReader(""), nil, core.CharacterClass)
+	s.env.StandardOutput = core.NewStream(nil, output{s, "stdout"}, core.CharacterClass)
+	s.env.ErrorOutput = core.NewStream(nil, output{s, "stderr"}, core.CharacterClass)
+	for _, name := range s.env.Variable[:1].Keys() {
+		s.builtins[name.String()] = true
+	}
+	if !s.noDebug {
+		s.env.Runtime.SetStepper(s.step)
+		s.env.Runtime.SetDebugger(s.debug)
+	}
+	go s.run()
+}
+
+// run loads the program, and reports its exit once it ends.
+func (s *Server) run() {
+	defer close(s.done)
+	_, err := lib.LoadFile(s.env, s.program)
+	s.env.StandardOutput.(core.Stream).Flush()
+	s.env.ErrorOutput.(core.Stream).Flush()
+	s.mu.Lock()
+	terminating := s.terminating
+	s.mu.Unlock()
+	code := 0
+	if err != nil {
+		code = 1
+		if !terminating {
+			b := new(strings.Builder)
+			repl.PrintError(b, s.env, err)
+			s.event("output", OutputEventBody{"stderr", b.String()})
+		}
+	}
+	s.event("exited", ExitedEventBody{code})
+	s.event("terminated", nil)
+}
+
+// step is the stepper of the program. It stops the program at the forms
+// located in its files as the client asks. A form at the location of the
+// form it is in, such as the expansion of a macro, is not a step of its own.
+func (s *Server) step(e core.Environment, form core.Instance) func() {
+	location := core.LocationOf(form)
+	n := len(s.visits)
+	if s.evaluating || location == nil || n > 0 && *s.visits[n-1].location == *location {
+		return nil
+	}
+	var parent *core.Location
+	if n > 0 {
+		parent = s.visits[n-1].location
+	}
+	s.visits = append(s.visits, visit{location, e, len(e.Runtime.Calls())})
+	if reason := s.check(n, location, parent); reason != "" {
+		s.halt(reason, "")
+	}
+	return func() { s.visits = s.visits[:n] }
+}
+
+// check returns the reason to stop at the form at location, entered with n
+// forms in evaluation the innermost of which is at parent, or "" not to
+// stop. The program stops at the target, the forms entered with at most
+// target forms in evaluation, and at the first form entered on the line of a
+// breakpoint.
+func (s *Server) check(n int, location, parent *core.Location) string {
+	path, ok := s.paths[location.File]
+	if !ok {
+		path, _ = filepath.Abs(location.File)
+		s.paths[location.File] = path
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.terminating:
+		return ""
+	case s.pausing:
+		return "pause"
+	case n <= s.target:
+		return s.reason
+	case s.breakpoints[path][location.Line] && (parent == nil || parent.File != location.File || parent.Line != location.Line):
+		return "breakpoint"
+	}
+	return ""
+}
+
+// debug is the debugger of the program. It stops the program at the
+// condition, which is signaled as usual once the program continues.
+func (s *Server) debug(e core.Environment, condition core.Instance) (core.Instance, core.Instance) {
+	s.halt("exception", fmt.Sprintf("%v: %s", condition.Class(), lib.ReportString(e, condition)))
+	return nil, condition
+}
+
+// halt stops the program and waits for the client to resume it, unless it is
+// terminating.
+func (s *Server) halt(reason, description string) {
+	st := &stop{frames: s.frames(), depth: len(s.visits)}
+	if n := len(s.visits); n > 0 {
+		for st.outer < n && s.visits[st.outer].calls < s.visits[n-1].calls {
+			st.outer++
+		}
+	}
+	s.mu.Lock()
+	if s.terminating {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped, s.target, s.pausing = st, -1, false
+	s.mu.Unlock()
+	s.event("stopped", StoppedEventBody{Reason: reason, Description: description, ThreadID: thread, AllThreadsStopped: true})
+	<-s.resume
+}
+
+// frames returns the frames of the function applications in progress and of
+// the top level, the innermost first. A frame is at the innermost form in
+// evaluation in its application, if any.
+func (s *Server) frames() []frame {
+	calls := s.env.Runtime.Calls()
+	frames := []frame{}
+	for level, i := len(calls), len(s.visits)-1; level >= 0; level-- {
+		f := frame{name: "top level", env: s.env}
+		if level > 0 {
+			call := calls[len(calls)-level]
+			f.name = describe(call)
+			f.location = core.LocationOf(call.Form)
+			if call.Environment != nil {
+				f.env = *call.Environment
+			}
+		}
+		for ; i >= 0 && s.visits[i].calls > level; i-- {
+		}
+		if i >= 0 && s.visits[i].calls == level {
+			f.location, f.env = s.visits[i].location, s.visits[i].env
+		}
+		frames = append(frames, f)
+	}
+	return frames
+}
+
+// describe returns the application of a call as a form, marked if it has
+// ended by a tail call.
+func describe(call *core.Call) string {
+	text := fmt.Sprint(call.Name)
+	for _, argument := range call.Arguments {
+		text += " " + fmt.Sprint(argument)
+	}
+	if call.Tail {
+		return "(" + text + ") [tail call]"
+	}
+	return "(" + text + ")"
+}
@@ -0,0 +1,447 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package dap is a server of the Debug Adapter Protocol for ISLisp. It
+// launches a program, the forms of a file, and stops it at the breakpoints
+// set on the lines of its files, at the conditions it does not handle, and a
+// form at a time while it is stepped. While the program is stopped, the
+// client can see the function applications in progress as the stack frames,
+// look at their variables and evaluate forms in them.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/protocol"
+	"github.com/islisp-dev/iris/reader/parser"
+	"github.com/islisp-dev/iris/reader/tokenizer"
+)
+
+// thread is the ID of the only thread, the one running the program.
+const thread = 1
+
+var errRunning = errors.New("the program is not stopped")
+
+// Server serves a client over a pair of streams, one request at a time. The
+// program runs on a goroutine of its own, which waits for the client while
+// the program is stopped.
+type Server struct {
+	in         *bufio.Reader
+	out        io.Writer
+	env        core.Environment // the runtime of the program
+	lineBase   int              // 0 if the client counts the lines from 0
+	columnBase int              // 0 if the client counts the columns from 0
+	program    string
+	noDebug    bool
+	launched   bool
+	configured bool
+	then       func()             // called once the response is sent
+	cancel     context.CancelFunc // cancels the program
+	resume     chan struct{}      // resumes the program stopped
+	done       chan struct{}      // closed once the program ends, nil before it starts
+	evaluating bool               // true while the client evaluates a form
+	builtins   map[string]bool    // the names of the global variables of the builtins
+
+	// The fields below are shared with the program.
+	mu          sync.Mutex
+	seq         int
+	breakpoints map[string]map[int]bool // the lines by the absolute paths of the files
+	stopped     *stop                   // the program stopped, or nil
+	target      int                     // see check
+	reason      string                  // of the stop at the target
+	pausing     bool
+	terminating bool
+
+	// The fields below belong to the program.
+	visits []visit
+	paths  map[string]string // the absolute paths of the files by their names
+}
+
+// NewServer returns a server reading the messages of the client from r and
+// writing its own to w.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		in:          bufio.NewReader(r),
+		out:         w,
+		env:         lib.NewRuntime(),
+		lineBase:    1,
+		columnBase:  1,
+		resume:      make(chan struct{}),
+		breakpoints: map[string]map[int]bool{},
+		target:      -1,
+		builtins:    map[string]bool{},
+		paths:       map[string]string{},
+	}
+}
+
+// Run serves the client until it disconnects, or until r ends. The program
+// is terminated then if it is still running. It returns an error if the
+// messages cannot be read or written.
+func (s *Server) Run() error {
+	defer s.terminate()
+	for {
+		content, err := protocol.ReadMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return err
+		}
+		body, herr := s.handle(req.Command, req.Arguments)
+		res := response{Type: "response", RequestSeq: req.Seq, Success: herr == nil, Command: req.Command, Body: body}
+		if herr != nil {
+			res.Message = herr.Error()
+		}
+		if err := s.send(&res.Seq, &res); err != nil {
+			return err
+		}
+		if then := s.then; then != nil {
+			s.then = nil
+			then()
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// send writes a message after giving it the next sequence number.
+func (s *Server) send(seq *int, message interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	*seq = s.seq
+	return protocol.WriteMessage(s.out, message)
+}
+
+func (s *Server) event(name string, body interface{}) {
+	ev := event{Type: "event", Event: name, Body: body}
+	s.send(&ev.Seq, &ev)
+}
+
+// handle handles a request, and returns the body of its response.
+func (s *Server) handle(command string, arguments json.RawMessage) (interface{}, error) {
+	decode := func(v interface{}) error {
+		if len(arguments) == 0 {
+			return nil
+		}
+		return json.Unmarshal(arguments, v)
+	}
+	switch command {
+	case "initialize":
+		var a InitializeArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		if a.LinesStartAt1 != nil && !*a.LinesStartAt1 {
+			s.lineBase = 0
+		}
+		if a.ColumnsStartAt1 != nil && !*a.ColumnsStartAt1 {
+			s.columnBase = 0
+		}
+		s.then = func() { s.event("initialized", nil) }
+		return Capabilities{SupportsConfigurationDoneRequest: true, SupportsEvaluateForHovers: true}, nil
+	case "launch":
+		var a LaunchArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		if s.launched {
+			return nil, errors.New("the program is launched already")
+		}
+		if info, err := os.Stat(a.Program); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("cannot find the program %q", a.Program)
+		}
+		s.program, s.noDebug, s.launched = a.Program, a.NoDebug, true
+		if a.StopOnEntry {
+			s.target, s.reason = math.MaxInt32, "entry"
+		}
+		if s.configured {
+			s.then = s.start
+		}
+		return nil, nil
+	case "configurationDone":
+		s.configured = true
+		if s.launched && s.done == nil {
+			s.then = s.start
+		}
+		return nil, nil
+	case "setBreakpoints":
+		var a SetBreakpointsArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(a), nil
+	case "setExceptionBreakpoints":
+		return nil, nil
+	case "threads":
+		return ThreadsResponseBody{[]Thread{{thread, "main"}}}, nil
+	case "stackTrace":
+		var a StackTraceArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		return s.stackTrace(a)
+	case "scopes":
+		var a ScopesArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		return s.scopes(a.FrameID)
+	case "variables":
+		var a VariablesArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		return s.variables(a.VariablesReference)
+	case "evaluate":
+		var a EvaluateArguments
+		if err := decode(&a); err != nil {
+			return nil, err
+		}
+		return s.evaluate(a)
+	case "continue":
+		return ContinueResponseBody{true}, s.proceed(func(*stop) int { return -1 })
+	case "next":
+		return nil, s.proceed(func(st *stop) int { return st.depth - 1 })
+	case "stepIn":
+		return nil, s.proceed(func(*stop) int { return math.MaxInt32 })
+	case "stepOut":
+		return nil, s.proceed(func(st *stop) int { return st.outer })
+	case "pause":
+		s.mu.Lock()
+		s.pausing = s.stopped == nil
+		s.mu.Unlock()
+		return nil, nil
+	case "disconnect", "terminate":
+		s.terminate()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown command %s", command)
+}
+
+// proceed resumes the program stopped, to stop again at the target returned by
+// target.
+func (s *Server) proceed(target func(*stop) int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped == nil {
+		return errRunning
+	}
+	s.target, s.reason = target(s.stopped), "step"
+	s.stopped = nil
+	s.then = func() { s.resume <- struct{}{} }
+	return nil
+}
+
+// terminate cancels the program and waits for it to end, if it has started.
+func (s *Server) terminate() {
+	if s.done == nil {
+		return
+	}
+	s.mu.Lock()
+	s.terminating = true
+	stopped := s.stopped
+	s.stopped = nil
+	s.mu.Unlock()
+	s.cancel()
+	if stopped != nil {
+		s.resume <- struct{}{}
+	}
+	<-s.done
+}
+
+// setBreakpoints replaces the breakpoints of a file. A breakpoint is only
+// verified on a line where a form begins.
+func (s *Server) setBreakpoints(a SetBreakpointsArguments) SetBreakpointsResponseBody {
+	path, _ := filepath.Abs(a.Source.Path)
+	lines, err := s.formLines(a.Source.Path)
+	set := map[int]bool{}
+	breakpoints := []Breakpoint{}
+	for _, b := range a.Breakpoints {
+		line := b.Line - s.lineBase + 1
+		breakpoint := Breakpoint{Verified: lines[line], Source: &a.Source, Line: b.Line}
+		switch {
+		case err != nil:
+			breakpoint.Message = err.Error()
+		case !lines[line]:
+			breakpoint.Message = "no form begins on this line"
+		default:
+			set[line] = true
+		}
+		breakpoints = append(breakpoints, breakpoint)
+	}
+	s.mu.Lock()
+	s.breakpoints[path] = set
+	s.mu.Unlock()
+	return SetBreakpointsResponseBody{breakpoints}
+}
+
+// formLines returns the lines, from 1, where the lists of the file at path
+// begin.
+func (s *Server) formLines(path string) (map[int]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	lines := map[int]bool{}
+	var walk func(x core.Instance)
+	walk = func(x core.Instance) {
+		for {
+			cons, ok := x.(*core.Cons)
+			if !ok {
+				return
+			}
+			if l := cons.Location(); l != nil {
+				lines[l.Line] = true
+			}
+			walk(cons.Car)
+			x = cons.Cdr
+		}
+	}
+	t := tokenizer.NewBufferedTokenReader(file)
+	e := s.env.NewHandler(core.SilentHandler)
+	for {
+		form, err := parser.Parse(e, t)
+		if err != nil {
+			return lines, nil
+		}
+		walk(form)
+	}
+}
+
+// stoppedProgram returns the state of the program stopped.
+func (s *Server) stoppedProgram() (*stop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped == nil {
+		return nil, errRunning
+	}
+	return s.stopped, nil
+}
+
+func (s *Server) stackTrace(a StackTraceArguments) (interface{}, error) {
+	st, err := s.stoppedProgram()
+	if err != nil {
+		return nil, err
+	}
+	frames := []StackFrame{}
+	for id, f := range st.frames {
+		if id < a.StartFrame || a.Levels > 0 && id >= a.StartFrame+a.Levels {
+			continue
+		}
+		frame := StackFrame{ID: id, Name: f.name}
+		if l := f.location; l != nil {
+			path, _ := filepath.Abs(l.File)
+			frame.Source = &Source{Name: filepath.Base(l.File), Path: path}
+			frame.Line, frame.Column = l.Line-1+s.lineBase, l.Column-1+s.columnBase
+		}
+		frames = append(frames, frame)
+	}
+	return StackTraceResponseBody{frames, len(st.frames)}, nil
+}
+
+// The scopes of a frame are referred to by the number of the frame times the
+// number of the scopes, plus the index of the scope plus one.
+var scopeNames = []string{"Locals", "Dynamic", "Globals"}
+
+func (s *Server) scopes(id int) (interface{}, error) {
+	st, err := s.stoppedProgram()
+	if err != nil {
+		return nil, err
+	}
+	if id < 0 || id >= len(st.frames) {
+		return nil, fmt.Errorf("no frame %d", id)
+	}
+	body := ScopesResponseBody{[]Scope{}}
+	for i, name := range scopeNames {
+		body.Scopes = append(body.Scopes, Scope{Name: name, VariablesReference: id*len(scopeNames) + i + 1})
+	}
+	return body, nil
+}
+
+// variables returns the variables of a scope, sorted by their names. The
+// local variables shadow the others of the same names, and the global ones
+// of the builtins are left out.
+func (s *Server) variables(reference int) (interface{}, error) {
+	st, err := s.stoppedProgram()
+	if err != nil {
+		return nil, err
+	}
+	id := (reference - 1) / len(scopeNames)
+	if reference < 1 || id >= len(st.frames) {
+		return nil, fmt.Errorf("no variables %d", reference)
+	}
+	e := st.frames[id].env
+	var names []core.Instance
+	get, hidden := e.Variable.Get, map[string]bool{}
+	switch (reference - 1) % len(scopeNames) {
+	case 0:
+		names = e.Variable[1:].Keys()
+	case 1:
+		names, get = e.DynamicVariable.Keys(), e.DynamicVariable.Get
+	case 2:
+		names, hidden = e.Variable[:1].Keys(), s.builtins
+	}
+	seen := map[string]bool{}
+	body := VariablesResponseBody{[]Variable{}}
+	for _, name := range names {
+		if seen[name.String()] || hidden[name.String()] || strings.HasPrefix(name.String(), "IRIS.") {
+			continue
+		}
+		seen[name.String()] = true
+		value, _ := get(name)
+		body.Variables = append(body.Variables, Variable{Name: name.String(), Value: value.String(), Type: value.Class().String()})
+	}
+	sort.Slice(body.Variables, func(i, j int) bool { return body.Variables[i].Name < body.Variables[j].Name })
+	return body, nil
+}
+
+// evaluate evaluates the form of the expression in a frame of the program
+// stopped. The conditions it signals are reported as the error of the
+// request.
+func (s *Server) evaluate(a EvaluateArguments) (interface{}, error) {
+	st, err := s.stoppedProgram()
+	if err != nil {
+		return nil, err
+	}
+	id := 0
+	if a.FrameID != nil {
+		id = *a.FrameID
+	}
+	if id < 0 || id >= len(st.frames) {
+		return nil, fmt.Errorf("no frame %d", id)
+	}
+	e := st.frames[id].env.NewHandler(core.SilentHandler)
+	s.evaluating = true
+	defer func() { s.evaluating = false }()
+	stream := core.NewStream(strings.NewReader(a.Expression), nil, core.CharacterClass)
+	form, cond := lib.Read(e, stream)
+	var ret core.Instance
+	if cond == nil {
+		ret, cond = lib.Eval(e, form)
+	}
+	if cond != nil {
+		return nil, errors.New(lib.ReportString(e, cond))
+	}
+	return EvaluateResponseBody{Result: ret.String(), Type: ret.Class().String()}, nil
+}
@@ -0,0 +1,293 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/islisp-dev/iris/protocol"
+)
+
+// client is a client scripted by a test, talking to a server over pipes.
+type client struct {
+	*protocol.Client
+	t      *testing.T
+	seq    int
+	events []map[string]json.RawMessage // received but not waited for yet
+}
+
+func newClient(t *testing.T) *client {
+	serve := func(r io.Reader, w io.Writer) error { return NewServer(r, w).Run() }
+	return &client{Client: protocol.NewClient(serve), t: t}
+}
+
+// next returns the next message of the server.
+func (c *client) next() map[string]json.RawMessage {
+	c.t.Helper()
+	select {
+	case m, ok := <-c.Messages:
+		if !ok {
+			c.t.Fatalf("the server closed: %v", c.Err)
+		}
+		return m
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("the server does not respond")
+	}
+	return nil
+}
+
+// call sends a request and decodes the body of its response into body,
+// keeping the events sent meanwhile. It returns the message of the response
+// if it is not successful.
+func (c *client) call(command string, arguments, body interface{}) string {
+	c.t.Helper()
+	c.seq++
+	if err := c.Send(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		m := c.next()
+		if string(m["type"]) == `"event"` {
+			c.events = append(c.events, m)
+			continue
+		}
+		if string(m["request_seq"]) != fmt.Sprint(c.seq) {
+			c.t.Fatalf("response %s to request %d", m["request_seq"], c.seq)
+		}
+		if string(m["success"]) != "true" {
+			var message string
+			json.Unmarshal(m["message"], &message)
+			return message
+		}
+		if body != nil {
+			if err := json.Unmarshal(m["body"], body); err != nil {
+				c.t.Fatalf("%s returned %s: %v", command, m["body"], err)
+			}
+		}
+		return ""
+	}
+}
+
+// wait waits for the event name and decodes its body into body. It returns
+// the output of the output events sent before it.
+func (c *client) wait(name string, body interface{}) string {
+	c.t.Helper()
+	output := ""
+	for {
+		var m map[string]json.RawMessage
+		if len(c.events) > 0 {
+			m, c.events = c.events[0], c.events[1:]
+		} else {
+			m = c.next()
+		}
+		if string(m["type"]) != `"event"` {
+			c.t.Fatalf("unexpected response %s", m["command"])
+		}
+		var event string
+		json.Unmarshal(m["event"], &event)
+		if event == name {
+			if body != nil {
+				json.Unmarshal(m["body"], body)
+			}
+			return output
+		}
+		if event == "output" {
+			var o OutputEventBody
+			json.Unmarshal(m["body"], &o)
+			output += o.Output
+		}
+	}
+}
+
+// stopped waits for the program to stop, and returns the reason and the
+// frames as "name line:column".
+func (c *client) stopped() (string, []string) {
+	c.t.Helper()
+	var stopped StoppedEventBody
+	c.wait("stopped", &stopped)
+	var trace StackTraceResponseBody
+	if message := c.call("stackTrace", StackTraceArguments{ThreadID: thread}, &trace); message != "" {
+		c.t.Fatal(message)
+	}
+	frames := []string{}
+	for _, f := range trace.StackFrames {
+		frames = append(frames, fmt.Sprintf("%s %d:%d", f.Name, f.Line, f.Column))
+	}
+	return stopped.Reason, frames
+}
+
+// variables returns the variables of the scope of a frame as "name=value".
+func (c *client) variables(frame int, scope string) []string {
+	c.t.Helper()
+	var scopes ScopesResponseBody
+	c.call("scopes", ScopesArguments{frame}, &scopes)
+	for _, s := range scopes.Scopes {
+		if s.Name != scope {
+			continue
+		}
+		var variables VariablesResponseBody
+		c.call("variables", VariablesArguments{s.VariablesReference}, &variables)
+		got := []string{}
+		for _, v := range variables.Variables {
+			got = append(got, v.Name+"="+v.Value)
+		}
+		return got
+	}
+	c.t.Fatalf("no scope %s in %v", scope, scopes)
+	return nil
+}
+
+var program = `(defun fact (n)
+  (if (= n 0)
+      1
+      (* n (fact (- n 1)))))
+(defglobal *result* (fact 3))
+(format (standard-output) "~A~%" *result*)
+(car *result*)
+`
+
+func writeProgram(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "fact.lsp")
+	if err := ioutil.WriteFile(path, []byte(program), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func (c *client) launch(arguments LaunchArguments, lines ...int) []Breakpoint {
+	c.t.Helper()
+	var capabilities Capabilities
+	if message := c.call("initialize", InitializeArguments{ClientID: "test"}, &capabilities); message != "" {
+		c.t.Fatal(message)
+	}
+	if !capabilities.SupportsConfigurationDoneRequest {
+		c.t.Errorf("initialize = %+v, want supportsConfigurationDoneRequest", capabilities)
+	}
+	c.wait("initialized", nil)
+	if message := c.call("launch", arguments, nil); message != "" {
+		c.t.Fatal(message)
+	}
+	breakpoints := c.setBreakpoints(arguments.Program, lines...)
+	c.call("configurationDone", nil, nil)
+	return breakpoints
+}
+
+func (c *client) setBreakpoints(path string, lines ...int) []Breakpoint {
+	c.t.Helper()
+	a := SetBreakpointsArguments{Source: Source{Path: path}, Breakpoints: []SourceBreakpoint{}}
+	for _, line := range lines {
+		a.Breakpoints = append(a.Breakpoints, SourceBreakpoint{line})
+	}
+	var body SetBreakpointsResponseBody
+	c.call("setBreakpoints", a, &body)
+	return body.Breakpoints
+}
+
+func TestServer(t *testing.T) {
+	path, remove := writeProgram(t)
+	defer remove()
+	c := newClient(t)
+	breakpoints := c.launch(LaunchArguments{Program: path}, 2, 3)
+	if len(breakpoints) != 2 || !breakpoints[0].Verified || breakpoints[1].Verified {
+		t.Errorf("breakpoints on 2 and 3 = %+v, want the one on 2 verified", breakpoints)
+	}
+
+	step := func(command, want string, frames ...string) {
+		t.Helper()
+		if command != "" {
+			if message := c.call(command, map[string]int{"threadId": thread}, nil); message != "" {
+				t.Fatalf("%s: %s", command, message)
+			}
+		}
+		reason, got := c.stopped()
+		if reason != want || !reflect.DeepEqual(got, frames) {
+			t.Errorf("%s stopped for %s at %q, want %s at %q", command, reason, got, want, frames)
+		}
+	}
+	step("", "breakpoint", "(FACT 3) 2:3", "(DEFGLOBAL *RESULT* (FACT 3)) 5:21", "top level 5:1")
+
+	t.Run("variables", func(t *testing.T) {
+		if got := c.variables(0, "Locals"); !reflect.DeepEqual(got, []string{"N=3"}) {
+			t.Errorf("locals of (fact 3) = %q, want N=3", got)
+		}
+		if got := c.variables(2, "Locals"); len(got) != 0 {
+			t.Errorf("locals of the top level = %q, want none", got)
+		}
+	})
+
+	t.Run("evaluate", func(t *testing.T) {
+		var result EvaluateResponseBody
+		if message := c.call("evaluate", EvaluateArguments{Expression: "(* n 10)"}, &result); message != "" || result.Result != "30" {
+			t.Errorf("evaluate (* n 10) = %q, %q, want 30", result.Result, message)
+		}
+		top := 2
+		if message := c.call("evaluate", EvaluateArguments{Expression: "n", FrameID: &top}, nil); !strings.Contains(message, "N") {
+			t.Errorf("evaluate n at the top level = %q, want an error", message)
+		}
+	})
+
+	step("stepIn", "step", "(FACT 3) 2:7", "(DEFGLOBAL *RESULT* (FACT 3)) 5:21", "top level 5:1")
+	step("next", "step", "(FACT 3) 4:7", "(DEFGLOBAL *RESULT* (FACT 3)) 5:21", "top level 5:1")
+	step("stepIn", "step", "(FACT 3) 4:12", "(DEFGLOBAL *RESULT* (FACT 3)) 5:21", "top level 5:1")
+	step("stepIn", "step", "(FACT 3) 4:18", "(DEFGLOBAL *RESULT* (FACT 3)) 5:21", "top level 5:1")
+	step("stepIn", "step", "(FACT 2) 2:3", "(FACT 3) 4:12", "(DEFGLOBAL *RESULT* (FACT 3)) 5:21", "top level 5:1")
+	c.setBreakpoints(path)
+	step("stepOut", "step", "top level 6:1")
+	if got := c.variables(0, "Globals"); !reflect.DeepEqual(got, []string{"*RESULT*=6"}) {
+		t.Errorf("globals = %q, want *RESULT*=6", got)
+	}
+
+	c.call("continue", map[string]int{"threadId": thread}, nil)
+	var stopped StoppedEventBody
+	if output := c.wait("stopped", &stopped); output != "6\n" || stopped.Reason != "exception" || !strings.Contains(stopped.Description, "<DOMAIN-ERROR>") {
+		t.Errorf("continue output %q and stopped for %+v, want 6 and a domain error", output, stopped)
+	}
+	if message := c.call("next", map[string]int{"threadId": thread}, nil); message != "" {
+		t.Fatal(message)
+	}
+	var exited ExitedEventBody
+	if output := c.wait("exited", &exited); !strings.Contains(output, "fact.lsp:7:1") || exited.ExitCode != 1 {
+		t.Errorf("exited with %d after %q, want 1 after the error at 7:1", exited.ExitCode, output)
+	}
+	c.wait("terminated", nil)
+	if message := c.call("stackTrace", StackTraceArguments{ThreadID: thread}, nil); message != errRunning.Error() {
+		t.Errorf("stackTrace after the exit = %q, want an error", message)
+	}
+	c.call("disconnect", nil, nil)
+	if err := <-c.Done; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+}
+
+func TestStopOnEntry(t *testing.T) {
+	path, remove := writeProgram(t)
+	defer remove()
+	c := newClient(t)
+	c.launch(LaunchArguments{Program: path, StopOnEntry: true})
+	if reason, frames := c.stopped(); reason != "entry" || !reflect.DeepEqual(frames, []string{"top level 1:1"}) {
+		t.Errorf("stopped for %s at %q, want entry at 1:1", reason, frames)
+	}
+	var threads ThreadsResponseBody
+	if c.call("threads", nil, &threads); len(threads.Threads) != 1 {
+		t.Errorf("threads = %+v, want one", threads)
+	}
+	c.call("disconnect", nil, nil)
+	if err := <-c.Done; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+}
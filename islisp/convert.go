@@ -0,0 +1,497 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package islisp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+var (
+	instanceType    = reflect.TypeOf((*core.Instance)(nil)).Elem()
+	environmentType = reflect.TypeOf(core.Environment{})
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType      = reflect.TypeOf((*big.Int)(nil))
+)
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// ToLisp converts a Go value to an object of the runtime e. Integers including
+// *big.Int, floats, strings and booleans become <integer>, <float>, <string>
+// and t or nil; slices become lists, arrays become general vectors, maps
+// become association lists sorted by key, structs become instances of a standard class named
+// after the Go type, and functions become functions callable from Lisp.
+// Values which already are objects are returned as is.
+func ToLisp(e core.Environment, v interface{}) (core.Instance, error) {
+	if v == nil {
+		return core.Nil, nil
+	}
+	return toLisp(e, reflect.ValueOf(v))
+}
+
+func toLisp(e core.Environment, v reflect.Value) (core.Instance, error) {
+	if v.Type().Implements(instanceType) {
+		if v.Kind() == reflect.Interface && v.IsNil() {
+			return core.Nil, nil
+		}
+		return v.Interface().(core.Instance), nil
+	}
+	if v.Type() == bigIntType && !v.IsNil() {
+		return core.NewBigInteger(new(big.Int).Set(v.Interface().(*big.Int))), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return core.T, nil
+		}
+		return core.Nil, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < int64(minInt) || v.Int() > int64(maxInt) {
+			return core.NewBigInteger(big.NewInt(v.Int())), nil
+		}
+		return core.NewInteger(int(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > uint64(maxInt) {
+			return core.NewBigInteger(new(big.Int).SetUint64(v.Uint())), nil
+		}
+		return core.NewInteger(int(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return core.NewFloat(v.Float()), nil
+	case reflect.String:
+		return core.NewString([]rune(v.String())), nil
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return core.Nil, nil
+		}
+		return toLisp(e, v.Elem())
+	case reflect.Slice:
+		var list core.Instance = core.Nil
+		for i := v.Len() - 1; i >= 0; i-- {
+			elt, err := toLisp(e, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list = core.NewCons(elt, list)
+		}
+		return list, nil
+	case reflect.Array:
+		vector := make([]core.Instance, v.Len())
+		for i := range vector {
+			elt, err := toLisp(e, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			vector[i] = elt
+		}
+		return core.NewGeneralVector(vector), nil
+	case reflect.Map:
+		pairs := []core.Instance{}
+		for _, key := range v.MapKeys() {
+			car, err := toLisp(e, key)
+			if err != nil {
+				return nil, err
+			}
+			cdr, err := toLisp(e, v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, core.NewCons(car, cdr))
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i].(*core.Cons).Car.String() < pairs[j].(*core.Cons).Car.String()
+		})
+		var alist core.Instance = core.Nil
+		for i := len(pairs) - 1; i >= 0; i-- {
+			alist = core.NewCons(pairs[i], alist)
+		}
+		return alist, nil
+	case reflect.Struct:
+		class, err := structClass(e, v.Type())
+		if err != nil {
+			return nil, err
+		}
+		instance := core.Create(e, class)
+		for i, slot := range structSlots(v.Type()) {
+			if slot == nil {
+				continue
+			}
+			value, err := toLisp(e, v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			setSlotValue(instance, slot, value)
+		}
+		return instance, nil
+	case reflect.Func:
+		return wrap(core.NewSymbol("ANONYMOUS-FUNCTION"), v)
+	}
+	return nil, fmt.Errorf("islisp: cannot convert %v to an object", v.Type())
+}
+
+// FromLisp stores the Go representation of the object i in the value pointed
+// to by v, converting in the opposite direction of ToLisp. If v points to an
+// empty interface, integers, floats, strings and characters are stored as
+// int, float64, string and rune, lists and vectors as []interface{}, nil as
+// nil, and any other object as is.
+func FromLisp(i core.Instance, v interface{}) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return fmt.Errorf("islisp: FromLisp needs a non-nil pointer, not %T", v)
+	}
+	return fromLisp(i, p.Elem())
+}
+
+func fromLisp(i core.Instance, v reflect.Value) error {
+	t := v.Type()
+	if t == instanceType {
+		v.Set(reflect.ValueOf(&i).Elem())
+		return nil
+	}
+	if reflect.TypeOf(i).AssignableTo(t) && (t.Kind() != reflect.Interface || t.NumMethod() != 0) {
+		v.Set(reflect.ValueOf(i))
+		return nil
+	}
+	if t == bigIntType {
+		switch x := i.(type) {
+		case core.Integer:
+			v.Set(reflect.ValueOf(big.NewInt(int64(x))))
+			return nil
+		case *core.BigInteger:
+			v.Set(reflect.ValueOf(new(big.Int).Set(x.Int())))
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		v.SetBool(i != core.Nil)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := int64Value(i); ok && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := uint64Value(i); ok && !v.OverflowUint(n) {
+			v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := float64Value(i); ok && !v.OverflowFloat(f) {
+			v.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		switch x := i.(type) {
+		case core.String:
+			v.SetString(string(x))
+			return nil
+		case core.Symbol:
+			v.SetString(x.String())
+			return nil
+		}
+	case reflect.Ptr:
+		if i == core.Nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		p := reflect.New(t.Elem())
+		if err := fromLisp(i, p.Elem()); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	case reflect.Slice:
+		elts, ok := sequence(i)
+		if !ok {
+			break
+		}
+		s := reflect.MakeSlice(t, len(elts), len(elts))
+		for j, elt := range elts {
+			if err := fromLisp(elt, s.Index(j)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		elts, ok := sequence(i)
+		if !ok || len(elts) != t.Len() {
+			break
+		}
+		for j, elt := range elts {
+			if err := fromLisp(elt, v.Index(j)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if !core.InstanceOf(core.ListClass, i) {
+			break
+		}
+		m := reflect.MakeMap(t)
+		for _, pair := range i.(core.List).Slice() {
+			cons, ok := pair.(*core.Cons)
+			if !ok {
+				return fmt.Errorf("islisp: cannot convert %v to %v", i, t)
+			}
+			key := reflect.New(t.Key()).Elem()
+			if err := fromLisp(cons.Car, key); err != nil {
+				return err
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := fromLisp(cons.Cdr, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Struct:
+		instance, ok := i.(core.BasicInstance)
+		if !ok {
+			break
+		}
+		for j, slot := range structSlots(t) {
+			if slot == nil {
+				continue
+			}
+			value, ok := slotValue(instance, slot)
+			if !ok {
+				continue
+			}
+			if err := fromLisp(value, v.Field(j)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			break
+		}
+		if x := goValue(i); x != nil {
+			v.Set(reflect.ValueOf(x))
+		} else {
+			v.Set(reflect.Zero(t))
+		}
+		return nil
+	}
+	return fmt.Errorf("islisp: cannot convert %v to %v", i, t)
+}
+
+// int64Value returns the value of the integer or character i, and whether it
+// is one which an int64 can hold.
+func int64Value(i core.Instance) (int64, bool) {
+	switch x := i.(type) {
+	case core.Integer:
+		return int64(x), true
+	case core.Character:
+		return int64(x), true
+	case *core.BigInteger:
+		return x.Int().Int64(), x.Int().IsInt64()
+	}
+	return 0, false
+}
+
+// uint64Value returns the value of the integer i, and whether it is one which
+// a uint64 can hold.
+func uint64Value(i core.Instance) (uint64, bool) {
+	switch x := i.(type) {
+	case core.Integer:
+		return uint64(x), x >= 0
+	case *core.BigInteger:
+		return x.Int().Uint64(), x.Int().IsUint64()
+	}
+	return 0, false
+}
+
+// float64Value returns the nearest float64 to the number i, and whether it is
+// finite.
+func float64Value(i core.Instance) (float64, bool) {
+	switch x := i.(type) {
+	case core.Float:
+		return float64(x), true
+	case core.Integer:
+		return float64(x), true
+	case *core.BigInteger:
+		f, _ := new(big.Float).SetInt(x.Int()).Float64()
+		return f, !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+func goValue(i core.Instance) interface{} {
+	switch x := i.(type) {
+	case core.Integer:
+		return int(x)
+	case *core.BigInteger:
+		return new(big.Int).Set(x.Int())
+	case core.Float:
+		return float64(x)
+	case core.String:
+		return string(x)
+	case core.Character:
+		return rune(x)
+	case *core.Null:
+		return nil
+	case *core.Cons, core.GeneralVector:
+		elts, _ := sequence(i)
+		s := make([]interface{}, len(elts))
+		for j, elt := range elts {
+			s[j] = goValue(elt)
+		}
+		return s
+	}
+	return i
+}
+
+func sequence(i core.Instance) ([]core.Instance, bool) {
+	switch x := i.(type) {
+	case core.GeneralVector:
+		return []core.Instance(x), true
+	case *core.Null:
+		return []core.Instance{}, true
+	case *core.Cons:
+		return x.Slice(), true
+	}
+	return nil, false
+}
+
+// classOf returns the class of objects converted to the Go type t, for the
+// domain errors signaled by host functions.
+func classOf(t reflect.Type) core.Class {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return core.IntegerClass
+	case reflect.Float32, reflect.Float64:
+		return core.NumberClass
+	case reflect.String:
+		return core.StringClass
+	case reflect.Slice, reflect.Map:
+		return core.ListClass
+	case reflect.Array:
+		return core.GeneralVectorClass
+	case reflect.Struct:
+		return core.StandardObjectClass
+	case reflect.Func:
+		return core.FunctionClass
+	}
+	return core.ObjectClass
+}
+
+var wordBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// lispName converts a Go identifier such as FirstName to FIRST-NAME.
+func lispName(name string) string {
+	return strings.ToUpper(wordBoundary.ReplaceAllString(name, "$1-$2"))
+}
+
+// structSlots returns the slot name of each field of the struct type t, or
+// nil for unexported fields and fields tagged `lisp:"-"`. A tag `lisp:"name"`
+// overrides the name derived from the field.
+func structSlots(t reflect.Type) []core.Instance {
+	slots := make([]core.Instance, t.NumField())
+	for i := range slots {
+		f := t.Field(i)
+		tag := f.Tag.Get("lisp")
+		switch {
+		case f.PkgPath != "" || tag == "-":
+			continue
+		case tag != "":
+			slots[i] = core.NewSymbol(strings.ToUpper(tag))
+		default:
+			slots[i] = core.NewSymbol(lispName(f.Name))
+		}
+	}
+	return slots
+}
+
+// goType is the property of the name of a class defined by structClass which
+// holds the package path and the name of its struct type.
+var goType = core.NewSymbol("GO-TYPE")
+
+// structClass returns the class named <TYPE-NAME> for the struct type t. If
+// the runtime has no such class, a standard class is defined whose slots are
+// the fields of t, each with itself as its initarg. If <TYPE-NAME> names a
+// class of another struct type or a class defined in Lisp, the class is named
+// <PACKAGE/PATH/TYPE-NAME> instead, and an error is returned if that name is
+// taken as well.
+func structClass(e core.Environment, t reflect.Type) (core.Class, error) {
+	if t.Name() == "" {
+		return nil, fmt.Errorf("islisp: cannot convert unnamed %v to an object", t)
+	}
+	path := core.NewString([]rune(t.PkgPath() + "." + t.Name()))
+	name := core.NewSymbol("<" + lispName(t.Name()) + ">")
+	if taken(e, name, path) {
+		name = core.NewSymbol("<" + strings.ToUpper(t.PkgPath()) + "/" + lispName(t.Name()) + ">")
+	}
+	if taken(e, name, path) {
+		return nil, fmt.Errorf("islisp: cannot convert %v to an object: the class %v is not defined for it", t, name)
+	}
+	if class, ok := e.Class[:1].Get(name); ok {
+		return class.(core.Class), nil
+	}
+	slots := []core.Instance{}
+	initargs := core.NewHashMap()
+	for _, slot := range structSlots(t) {
+		if slot != nil {
+			slots = append(slots, slot)
+			initargs.Set(slot, slot)
+		}
+	}
+	class := core.NewStandardClass(name, []core.Class{core.StandardObjectClass}, slots, core.NewHashMap(), initargs, core.StandardClassClass, core.Nil)
+	e.Class[:1].Define(name, class)
+	e.Property.Set(name, goType, path)
+	return class, nil
+}
+
+// taken reports whether the class name is defined for something other than the
+// struct type named path.
+func taken(e core.Environment, name, path core.Instance) bool {
+	if other, ok := e.Property.Get(name, goType); ok {
+		return !core.DeepEqual(other, path)
+	}
+	_, ok := e.Class[:1].Get(name)
+	return ok
+}
+
+// owner returns the class among c and its superclasses which defines slot.
+func owner(c core.Class, slot core.Instance) (core.Class, bool) {
+	for _, s := range c.Slots() {
+		if core.DeepEqual(s, slot) {
+			return c, true
+		}
+	}
+	for _, super := range c.Supers() {
+		if o, ok := owner(super, slot); ok {
+			return o, true
+		}
+	}
+	return nil, false
+}
+
+func slotValue(i core.BasicInstance, slot core.Instance) (core.Instance, bool) {
+	class, ok := owner(i.Class(), slot)
+	if !ok {
+		return nil, false
+	}
+	return i.GetSlotValue(slot, class)
+}
+
+func setSlotValue(i core.Instance, slot, value core.Instance) {
+	if class, ok := owner(i.Class(), slot); ok {
+		i.(core.BasicInstance).SetSlotValue(slot, value, class)
+	}
+}
@@ -0,0 +1,224 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package islisp
+
+import (
+	"image"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+type labeled struct {
+	X, Y   int
+	Label  string `lisp:"name"`
+	hidden int
+}
+
+type Point struct {
+	X, Y int
+}
+
+func TestToLisp(t *testing.T) {
+	in := New()
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, `nil`},
+		{true, `t`},
+		{false, `nil`},
+		{42, `42`},
+		{uint8(7), `7`},
+		{1.5, `1.5`},
+		{"abc", `"abc"`},
+		{[]int{1, 2, 3}, `'(1 2 3)`},
+		{[]string{}, `nil`},
+		{[2]int{1, 2}, `#(1 2)`},
+		{map[string]int{"b": 2, "a": 1}, `'(("a" . 1) ("b" . 2))`},
+		{[][]int{{1}, {2, 3}}, `'((1) (2 3))`},
+		{core.NewSymbol("FOO"), `'foo`},
+		{&[]int{1}, `'(1)`},
+		{uint64(math.MaxUint64), `18446744073709551615`},
+		{new(big.Int).Lsh(big.NewInt(1), 70), `1180591620717411303424`},
+	}
+	for _, tt := range tests {
+		got, err := ToLisp(in.Environment(), tt.value)
+		if err != nil {
+			t.Errorf("ToLisp(%#v) err = %v", tt.value, err)
+			continue
+		}
+		want, err := in.EvalString(tt.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := in.Call("equal", got, want); ok != core.T {
+			t.Errorf("ToLisp(%#v) got = %v, want %v", tt.value, got, want)
+		}
+	}
+	if _, err := ToLisp(in.Environment(), make(chan int)); err == nil {
+		t.Errorf("ToLisp of a channel succeeded")
+	}
+}
+
+func TestStruct(t *testing.T) {
+	in := New()
+	obj, err := ToLisp(in.Environment(), Point{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := in.DefineValue("p", obj); err != nil {
+		t.Fatal(err)
+	}
+	got, err := in.EvalString(`(instancep p (class <point>))`)
+	if err != nil || got != core.T {
+		t.Errorf("(instancep p (class <point>)) got = %v, %v", got, err)
+	}
+	var p Point
+	if err := FromLisp(obj, &p); err != nil || p != (Point{1, 2}) {
+		t.Errorf("FromLisp got = %v, %v, want {1 2}", p, err)
+	}
+	created, err := in.EvalString(`(create (class <point>) 'x 3 'y 4)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := FromLisp(created, &p); err != nil || p != (Point{3, 4}) {
+		t.Errorf("FromLisp got = %v, %v, want {3 4}", p, err)
+	}
+	obj, err = ToLisp(in.Environment(), labeled{X: 5, Label: "q", hidden: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q labeled
+	if err := FromLisp(obj, &q); err != nil || q != (labeled{X: 5, Label: "q"}) {
+		t.Errorf("FromLisp got = %v, %v, want {5 0 q 0}", q, err)
+	}
+	other, err := ToLisp(in.Environment(), image.Point{X: 5, Y: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := in.DefineValue("q", other); err != nil {
+		t.Fatal(err)
+	}
+	got, err = in.EvalString(`(list (instancep q (class <point>)) (instancep q (class <image/point>)) (eq (class-of p) (class-of q)))`)
+	if err != nil || got.String() != "(NIL T NIL)" {
+		t.Errorf("classes of image.Point got = %v, %v, want (NIL T NIL)", got, err)
+	}
+	obj, err = ToLisp(in.Environment(), Point{7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := in.DefineValue("p", obj); err != nil {
+		t.Fatal(err)
+	}
+	got, err = in.EvalString(`(instancep p (class <point>))`)
+	if err != nil || got != core.T {
+		t.Errorf("(instancep p (class <point>)) got = %v, %v", got, err)
+	}
+}
+
+func TestStructClassClash(t *testing.T) {
+	in := New()
+	if _, err := in.EvalString(`(defclass <point> () ((z :initarg z)))`); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := ToLisp(in.Environment(), Point{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := in.DefineValue("p", obj); err != nil {
+		t.Fatal(err)
+	}
+	got, err := in.EvalString(`(instancep p (class <point>))`)
+	if err != nil || got != core.Nil {
+		t.Errorf("(instancep p (class <point>)) got = %v, %v, want nil", got, err)
+	}
+	var p Point
+	if err := FromLisp(obj, &p); err != nil || p != (Point{1, 2}) {
+		t.Errorf("FromLisp got = %v, %v, want {1 2}", p, err)
+	}
+	if _, err := in.EvalString(`(defclass |<GITHUB.COM/ISLISP-DEV/IRIS/ISLISP/LABELED>| () ())`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.EvalString(`(defclass <labeled> () ())`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToLisp(in.Environment(), labeled{}); err == nil {
+		t.Errorf("ToLisp of labeled succeeded with both of its class names taken")
+	}
+}
+
+func TestFromLisp(t *testing.T) {
+	in := New()
+	tests := []struct {
+		src  string
+		ptr  interface{}
+		want interface{}
+	}{
+		{`42`, new(int), 42},
+		{`42`, new(float64), 42.0},
+		{`#\a`, new(rune), 'a'},
+		{`"abc"`, new(string), "abc"},
+		{`'abc`, new(string), "ABC"},
+		{`nil`, new(bool), false},
+		{`'(1 2)`, new([]int), []int{1, 2}},
+		{`#(1 2)`, new([]int), []int{1, 2}},
+		{`#(1 2)`, new([2]int), [2]int{1, 2}},
+		{`'(("a" . 1))`, new(map[string]int), map[string]int{"a": 1}},
+		{`'(1 "a" (2.5))`, new(interface{}), []interface{}{1, "a", []interface{}{2.5}}},
+		{`nil`, new(*int), (*int)(nil)},
+		{`(expt 2 70)`, new(*big.Int), new(big.Int).Lsh(big.NewInt(1), 70)},
+		{`(expt 2 70)`, new(interface{}), new(big.Int).Lsh(big.NewInt(1), 70)},
+		{`(expt 2 70)`, new(float64), math.Pow(2, 70)},
+		{`18446744073709551615`, new(uint64), uint64(math.MaxUint64)},
+		{`-128`, new(int8), int8(-128)},
+	}
+	for _, tt := range tests {
+		obj, err := in.EvalString(tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := FromLisp(obj, tt.ptr); err != nil {
+			t.Errorf("FromLisp(%v) err = %v", tt.src, err)
+			continue
+		}
+		if got := reflect.ValueOf(tt.ptr).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FromLisp(%v) got = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+	var i int
+	if err := FromLisp(core.NewString([]rune("1")), &i); err == nil {
+		t.Errorf("FromLisp of a string to int succeeded")
+	}
+	if err := FromLisp(core.NewBigInteger(new(big.Int).Lsh(big.NewInt(1), 70)), &i); err == nil {
+		t.Errorf("FromLisp of a bignum to int succeeded")
+	}
+	var b int8
+	if err := FromLisp(core.NewInteger(1000), &b); err == nil {
+		t.Errorf("FromLisp of 1000 to int8 succeeded, got %v", b)
+	}
+	var u uint8
+	if err := FromLisp(core.NewInteger(-1), &u); err == nil {
+		t.Errorf("FromLisp of -1 to uint8 succeeded, got %v", u)
+	}
+	var f float32
+	if err := FromLisp(core.NewFloat(1e300), &f); err == nil {
+		t.Errorf("FromLisp of 1e300 to float32 succeeded, got %v", f)
+	}
+	obj, err := ToLisp(in.Environment(), uint64(math.MaxUint64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var max uint64
+	if err := FromLisp(obj, &max); err != nil || max != math.MaxUint64 {
+		t.Errorf("FromLisp of ToLisp(MaxUint64) got = %v, %v", max, err)
+	}
+	if err := FromLisp(core.NewInteger(1), i); err == nil {
+		t.Errorf("FromLisp to a non-pointer succeeded")
+	}
+}
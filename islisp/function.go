@@ -0,0 +1,98 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package islisp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+// wrap makes a function object from an ordinary Go function. The arguments
+// are converted with FromLisp to the parameter types; a parameter of type
+// core.Environment in the first position receives the calling environment.
+// The function may return nothing, a value, an error, or a value and an
+// error. The value is converted with ToLisp and a non-nil error is signaled
+// as a <simple-error>.
+func wrap(name core.Instance, fv reflect.Value) (core.Instance, error) {
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return nil, fmt.Errorf("islisp: %v is not a function", ft)
+	}
+	withEnv := ft.NumIn() > 0 && ft.In(0) == environmentType
+	withError := ft.NumOut() > 0 && ft.Out(ft.NumOut()-1) == errorType
+	withValue := ft.NumOut() > 0 && !(ft.NumOut() == 1 && withError)
+	if ft.NumOut() > 2 || (ft.NumOut() == 2 && !withError) {
+		return nil, fmt.Errorf("islisp: %v returns too many values", ft)
+	}
+	first := 0
+	if withEnv {
+		first = 1
+	}
+	fixed := ft.NumIn() - first
+	if ft.IsVariadic() {
+		fixed--
+	}
+	return core.NewFunction(name, func(e core.Environment, arguments ...core.Instance) (core.Instance, core.Instance) {
+		if len(arguments) < fixed || (!ft.IsVariadic() && len(arguments) > fixed) {
+			return core.SignalCondition(e, core.NewArityError(e), core.Nil)
+		}
+		in := []reflect.Value{}
+		if withEnv {
+			in = append(in, reflect.ValueOf(e))
+		}
+		for i, argument := range arguments {
+			var t reflect.Type
+			if i < fixed {
+				t = ft.In(first + i)
+			} else {
+				t = ft.In(ft.NumIn() - 1).Elem()
+			}
+			v := reflect.New(t).Elem()
+			if err := fromLisp(argument, v); err != nil {
+				return core.SignalCondition(e, core.NewDomainError(e, argument, classOf(t)), core.Nil)
+			}
+			in = append(in, v)
+		}
+		var out []reflect.Value
+		if ft.IsVariadic() {
+			out = fv.CallSlice(variadic(in, ft))
+		} else {
+			out = fv.Call(in)
+		}
+		if withError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return core.SignalCondition(e, newError(e, err), core.Nil)
+			}
+		}
+		if !withValue {
+			return core.Nil, nil
+		}
+		ret, err := toLisp(e, out[0])
+		if err != nil {
+			return core.SignalCondition(e, newError(e, err), core.Nil)
+		}
+		return ret, nil
+	}), nil
+}
+
+// variadic packs the trailing arguments of in into the slice expected by
+// CallSlice.
+func variadic(in []reflect.Value, ft reflect.Type) []reflect.Value {
+	n := ft.NumIn() - 1
+	rest := reflect.MakeSlice(ft.In(n), 0, len(in)-n)
+	for _, v := range in[n:] {
+		rest = reflect.Append(rest, v)
+	}
+	return append(in[:n:n], rest)
+}
+
+// newError makes a <simple-error> whose message is the text of a Go error.
+func newError(e core.Environment, err error) core.Instance {
+	message := strings.Replace(err.Error(), "~", "~~", -1)
+	return core.NewSimpleError(e, core.NewString([]rune(message)), core.Nil)
+}
@@ -0,0 +1,207 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package islisp embeds the interpreter in Go programs. An Interpreter owns
+// an isolated runtime; values cross the boundary with ToLisp and FromLisp,
+// and ordinary Go functions can be defined as Lisp functions.
+package islisp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+)
+
+// Error is a condition signaled and not handled while evaluating Lisp code.
+// Message is its description written by report-condition, which tells, for
+// example, the limit exceeded by a <storage-exhausted>.
+type Error struct {
+	Condition core.Instance
+	Message   string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%v: %s", err.Condition.Class(), err.Message)
+}
+
+// error returns the Error of the condition signaled in the interpreter.
+func (in *Interpreter) error(condition core.Instance) *Error {
+	return &Error{condition, lib.ReportString(in.env, condition)}
+}
+
+// Interpreter evaluates Lisp code in its own runtime. An Interpreter must not
+// be used by several goroutines at the same time.
+type Interpreter struct {
+	env core.Environment
+}
+
+// New returns an interpreter with a fresh runtime reading from os.Stdin and
+// writing to os.Stdout and os.Stderr.
+func New() *Interpreter {
+	return &Interpreter{lib.NewRuntime()}
+}
+
+// Environment returns the top level environment of the interpreter.
+func (in *Interpreter) Environment() core.Environment {
+	return in.env
+}
+
+// WithContext returns an interpreter sharing the runtime of in whose
+// evaluations signal a <cancelled> condition at the next function application
+// or loop iteration once ctx is cancelled or its deadline passes. The cleanup
+// forms of unwind-protect still run after the cancellation.
+func (in *Interpreter) WithContext(ctx context.Context) *Interpreter {
+	env := in.env
+	env.Context = ctx
+	return &Interpreter{env}
+}
+
+// SetLimits sets the quotas of the runtime. When an evaluation exceeds one, a
+// <quota-exceeded> condition, a subclass of <storage-exhausted>, is signaled.
+func (in *Interpreter) SetLimits(limits core.Limits) {
+	in.env.Runtime.Limits = limits
+}
+
+// SetStackDepth sets the number of nested function applications beyond which
+// a <storage-exhausted> condition is signaled. Zero means
+// core.DefaultStackDepth.
+func (in *Interpreter) SetStackDepth(depth int) {
+	in.env.Runtime.StackDepth = depth
+}
+
+// SetDebug turns the debug mode of the runtime on or off. In debug mode, the
+// declarations (the class-name form) signal a <domain-error> when the value
+// of form is not an instance of the class.
+func (in *Interpreter) SetDebug(debug bool) {
+	in.env.Runtime.Debug = debug
+}
+
+// Usage returns the resources used by the evaluations so far.
+func (in *Interpreter) Usage() core.Usage {
+	return in.env.Runtime.Usage
+}
+
+// ResetUsage sets the usage counters to zero.
+func (in *Interpreter) ResetUsage() {
+	in.env.Runtime.Usage = core.Usage{}
+}
+
+// SetInput sets the standard input of the interpreter.
+func (in *Interpreter) SetInput(r io.Reader) {
+	in.env.StandardInput = core.NewStream(r, nil, core.CharacterClass)
+}
+
+// SetOutput sets the standard output of the interpreter.
+func (in *Interpreter) SetOutput(w io.Writer) {
+	in.env.StandardOutput = core.NewStream(nil, w, core.CharacterClass)
+}
+
+// SetErrorOutput sets the error output of the interpreter.
+func (in *Interpreter) SetErrorOutput(w io.Writer) {
+	in.env.ErrorOutput = core.NewStream(nil, w, core.CharacterClass)
+}
+
+// Eval evaluates a form.
+func (in *Interpreter) Eval(form core.Instance) (core.Instance, error) {
+	ret, err := lib.Eval(in.env, form)
+	if err != nil {
+		return nil, in.error(err)
+	}
+	return ret, nil
+}
+
+// EvalString reads and evaluates every form of src in order and returns the
+// value of the last one, or nil if there is none.
+func (in *Interpreter) EvalString(src string) (core.Instance, error) {
+	return in.load(strings.NewReader(src))
+}
+
+// LoadFile reads and evaluates every form of the file at path in order and
+// returns the value of the last one. The files it loads with load are
+// searched relative to path.
+func (in *Interpreter) LoadFile(path string) (core.Instance, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	ret, err := lib.LoadFile(in.env, path)
+	if err != nil {
+		return nil, in.error(err)
+	}
+	return ret, nil
+}
+
+func (in *Interpreter) load(r io.Reader) (core.Instance, error) {
+	stream := core.NewStream(r, nil, core.CharacterClass)
+	var ret core.Instance = core.Nil
+	for {
+		form, err := lib.Read(in.env, stream)
+		if err != nil {
+			if core.InstanceOf(core.EndOfStreamClass, err) {
+				return ret, nil
+			}
+			return nil, in.error(err)
+		}
+		if ret, err = lib.Eval(in.env, form); err != nil {
+			return nil, in.error(err)
+		}
+	}
+}
+
+// Call applies the function named name to the arguments, each converted with
+// ToLisp.
+func (in *Interpreter) Call(name string, arguments ...interface{}) (core.Instance, error) {
+	symbol := core.NewSymbol(strings.ToUpper(name))
+	function, ok := in.env.Function.Get(symbol)
+	if !ok {
+		_, err := core.SignalCondition(in.env, core.NewUndefinedFunction(in.env, symbol), core.Nil)
+		return nil, in.error(err)
+	}
+	args := make([]core.Instance, len(arguments))
+	for i, argument := range arguments {
+		arg, err := ToLisp(in.env, argument)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+	ret, err := function.(core.Applicable).Apply(in.env.NewDynamic(), args...)
+	if err != nil {
+		return nil, in.error(err)
+	}
+	return ret, nil
+}
+
+// Define defines a global function named name calling the Go function fn.
+// Arguments are converted with FromLisp to the parameter types of fn, which
+// may take a core.Environment as the first parameter and may be variadic.
+// fn may return nothing, a value, an error, or a value and an error; the
+// value is converted with ToLisp and a non-nil error is signaled as a
+// <simple-error>. Arguments which cannot be converted signal a
+// <domain-error>.
+func (in *Interpreter) Define(name string, fn interface{}) error {
+	symbol := core.NewSymbol(strings.ToUpper(name))
+	function, err := wrap(symbol, reflect.ValueOf(fn))
+	if err != nil {
+		return err
+	}
+	in.env.Function[:1].Define(symbol, function)
+	return nil
+}
+
+// DefineValue defines a global variable named name holding v converted with
+// ToLisp.
+func (in *Interpreter) DefineValue(name string, v interface{}) error {
+	value, err := ToLisp(in.env, v)
+	if err != nil {
+		return err
+	}
+	in.env.Variable[:1].Define(core.NewSymbol(strings.ToUpper(name)), value)
+	return nil
+}
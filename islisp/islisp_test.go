@@ -0,0 +1,237 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package islisp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+func TestEvalString(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    core.Instance
+		wantErr bool
+	}{
+		{`(+ 1 2)`, core.NewInteger(3), false},
+		{`(defglobal x 10) (* x x)`, core.NewInteger(100), false},
+		{``, core.Nil, false},
+		{`(car 1)`, nil, true},
+		{`(+ 1`, nil, true},
+		{`(+ 1 2)) 3`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := New().EvalString(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("EvalString(%q) err = %v, wantErr %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !core.DeepEqual(got, tt.want) {
+			t.Errorf("EvalString(%q) got = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	in := New()
+	var out bytes.Buffer
+	in.SetOutput(&out)
+	if _, err := in.EvalString(`(format (standard-output) "hello ~A" 42)`); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "hello 42" {
+		t.Errorf("output = %q, want %q", got, "hello 42")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "islisp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "square.lsp")
+	if err := ioutil.WriteFile(path, []byte("(defun square (x) (* x x))\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	in := New()
+	if _, err := in.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := in.Call("square", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !core.DeepEqual(got, core.NewInteger(49)) {
+		t.Errorf("(square 7) got = %v, want 49", got)
+	}
+	if _, err := in.LoadFile(filepath.Join(dir, "missing.lsp")); err == nil {
+		t.Errorf("LoadFile of a missing file succeeded")
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New()
+	got, err := in.Call("list", 1, "two", 3.5, []int{4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s []interface{}
+	if err := FromLisp(got, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 4 || s[0] != 1 || s[1] != "two" || s[2] != 3.5 {
+		t.Errorf("(list 1 \"two\" 3.5 '(4)) got = %#v", s)
+	}
+	_, err = in.Call("no-such-function")
+	var lispErr *Error
+	if !errors.As(err, &lispErr) || !core.InstanceOf(core.UndefinedFunctionClass, lispErr.Condition) {
+		t.Errorf("calling an undefined function err = %v", err)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	in := New()
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(in.Define("go-add", func(x, y int) int { return x + y }))
+	must(in.Define("go-join", func(sep string, words ...string) string { return strings.Join(words, sep) }))
+	must(in.Define("go-sum", func(xs []float64) float64 {
+		sum := 0.0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum
+	}))
+	must(in.Define("go-fail", func() error { return errors.New("failed ~A") }))
+	must(in.Define("go-div", func(x, y int) (int, error) {
+		if y == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return x / y, nil
+	}))
+	must(in.Define("go-nothing", func() {}))
+	must(in.Define("go-env", func(e core.Environment, x core.Instance) core.Instance { return x }))
+	must(in.Define("go-half", func(x int8) int8 { return x / 2 }))
+	if err := in.Define("go-bad", 1); err == nil {
+		t.Errorf("Define of a non-function succeeded")
+	}
+	tests := []struct {
+		src       string
+		want      core.Instance
+		wantClass core.Class
+	}{
+		{`(go-add 1 2)`, core.NewInteger(3), nil},
+		{`(go-join "-" "a" "b" "c")`, core.NewString([]rune("a-b-c")), nil},
+		{`(go-join "-")`, core.NewString([]rune("")), nil},
+		{`(go-sum '(1 2.5))`, core.NewFloat(3.5), nil},
+		{`(go-div 7 2)`, core.NewInteger(3), nil},
+		{`(go-nothing)`, core.Nil, nil},
+		{`(go-env 'a)`, core.NewSymbol("A"), nil},
+		{`(go-half 100)`, core.NewInteger(50), nil},
+		{`(go-add 1 "2")`, nil, core.DomainErrorClass},
+		{`(go-half 1000)`, nil, core.DomainErrorClass},
+		{`(go-add 1)`, nil, core.ProgramErrorClass},
+		{`(go-div 1 0)`, nil, core.SimpleErrorClass},
+		{`(go-fail)`, nil, core.SimpleErrorClass},
+	}
+	for _, tt := range tests {
+		got, err := in.EvalString(tt.src)
+		if tt.wantClass != nil {
+			var lispErr *Error
+			if !errors.As(err, &lispErr) || !core.InstanceOf(tt.wantClass, lispErr.Condition) {
+				t.Errorf("%v err = %v, want %v", tt.src, err, tt.wantClass)
+			}
+			continue
+		}
+		if err != nil || !core.DeepEqual(got, tt.want) {
+			t.Errorf("%v got = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	in := New()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := in.WithContext(ctx).EvalString(`(defglobal done nil) (unwind-protect (while t) (setq done t))`)
+	var lispErr *Error
+	if !errors.As(err, &lispErr) || !core.InstanceOf(core.CancelledClass, lispErr.Condition) {
+		t.Fatalf("err = %v, want <cancelled>", err)
+	}
+	if got, err := in.EvalString(`done`); err != nil || got != core.T {
+		t.Errorf("done = %v, %v, want T", got, err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	in := New()
+	in.SetLimits(core.Limits{Steps: 10000})
+	_, err := in.EvalString(`(while t)`)
+	var lispErr *Error
+	if !errors.As(err, &lispErr) || !core.InstanceOf(core.StorageExhaustedClass, lispErr.Condition) {
+		t.Fatalf("err = %v, want <storage-exhausted>", err)
+	}
+	if want := "<QUOTA-EXCEEDED>: The step limit 10000 was exceeded"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+	if got := in.Usage().Steps; got != 10001 {
+		t.Errorf("steps = %v, want 10001", got)
+	}
+	in.ResetUsage()
+	if _, err := in.EvalString(`(+ 1 2)`); err != nil {
+		t.Fatal(err)
+	}
+	if got := in.Usage().Steps; got == 0 || got > 10 {
+		t.Errorf("steps = %v after reset", got)
+	}
+}
+
+func TestStackDepth(t *testing.T) {
+	in := New()
+	in.SetStackDepth(100)
+	if _, err := in.EvalString(`(defun f (n) (if (= n 0) 0 (+ 1 (f (- n 1)))))`); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := in.EvalString(`(f 90)`); err != nil || got.String() != "90" {
+		t.Errorf("(f 90) = %v, %v, want 90", got, err)
+	}
+	_, err := in.EvalString(`(f 200)`)
+	var lispErr *Error
+	if !errors.As(err, &lispErr) || !core.InstanceOf(core.StorageExhaustedClass, lispErr.Condition) {
+		t.Fatalf("err = %v, want <storage-exhausted>", err)
+	}
+	if want := "<STORAGE-EXHAUSTED>: The stack depth limit 100 was exceeded"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+	if got := in.Usage().Depth; got != 0 {
+		t.Errorf("depth = %v, want 0", got)
+	}
+}
+
+func TestDebug(t *testing.T) {
+	in := New()
+	if got, err := in.EvalString(`(the <integer> "a")`); err != nil || got.String() != `"a"` {
+		t.Errorf("the = %v, %v, want \"a\"", got, err)
+	}
+	in.SetDebug(true)
+	_, err := in.EvalString(`(the <integer> "a")`)
+	var lispErr *Error
+	if !errors.As(err, &lispErr) || !core.InstanceOf(core.DomainErrorClass, lispErr.Condition) {
+		t.Fatalf("err = %v, want <domain-error>", err)
+	}
+}
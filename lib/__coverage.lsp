@@ -0,0 +1,12 @@
+(defun coverage-sign (x)
+  (cond ((> x 0) 'positive)
+        ((< x 0) 'negative)
+        (t 'zero)))
+(defun coverage-parity (x)
+  (if (= (mod x 2) 0) 'even))
+(defun coverage-unused (x)
+  (case x
+    ((1) 'one)))
+(coverage-sign 1)
+(coverage-sign -1)
+(coverage-parity 3)
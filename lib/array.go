@@ -4,9 +4,7 @@
 
 package lib
 
-import (
-	"github.com/islisp-dev/iris/core"
-)
+import "github.com/islisp-dev/iris/core"
 
 // BasicArrayP returns t if obj is a basic-array (instance of class
 // basic-array); otherwise, returns nil. obj may be any ISLISP object.
@@ -49,14 +47,25 @@ func CreateArray(e core.Environment, dimensions core.Instance, initialElement ..
 	if err != nil {
 		return nil, err
 	}
+	size := int64(1)
 	for i := 0; i < int(length.(core.Integer)); i++ {
 		elt, err := Elt(e, dimensions, core.NewInteger(i))
 		if err != nil {
 			return nil, err
 		}
-		if err := ensure(e, core.IntegerClass, elt); err != nil {
+		n, err := convSize(e, elt)
+		if err != nil {
 			return nil, err
 		}
+		if size <= maxSize {
+			size *= int64(n)
+		}
+	}
+	if size > maxSize {
+		return SignalCondition(e, core.NewStorageExhausted(e), Nil)
+	}
+	if _, err := core.CountAllocation(e, int(size)); err != nil {
+		return nil, err
 	}
 	// set the initial element
 	elt := Nil
@@ -135,8 +144,11 @@ func Aref(e core.Environment, basicArray core.Instance, dimensions ...core.Insta
 		if len(dimensions) != 1 {
 			return SignalCondition(e, core.NewArityError(e), Nil)
 		}
-		index := int(dimensions[0].(core.Integer))
-		if len(basicArray.(core.String)) <= index {
+		index, err := convIndex(e, dimensions[0])
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || len(basicArray.(core.String)) <= index {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return core.NewCharacter(basicArray.(core.String)[index]), nil
@@ -144,8 +156,11 @@ func Aref(e core.Environment, basicArray core.Instance, dimensions ...core.Insta
 		if len(dimensions) != 1 {
 			return SignalCondition(e, core.NewArityError(e), Nil)
 		}
-		index := int(dimensions[0].(core.Integer))
-		if len(basicArray.(core.GeneralVector)) <= index {
+		index, err := convIndex(e, dimensions[0])
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || len(basicArray.(core.GeneralVector)) <= index {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return basicArray.(core.GeneralVector)[index], nil
@@ -164,6 +179,9 @@ func Garef(e core.Environment, generalArray core.Instance, dimensions ...core.In
 	if err := ensure(e, core.IntegerClass, dimensions...); err != nil {
 		return nil, err
 	}
+	if len(dimensions) != rank(generalArray.(*core.GeneralArrayStar)) {
+		return SignalCondition(e, core.NewArityError(e), Nil)
+	}
 	if len(dimensions) == 0 {
 		if core.DeepEqual(generalArray.(*core.GeneralArrayStar).Scalar, nil) {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
@@ -171,8 +189,11 @@ func Garef(e core.Environment, generalArray core.Instance, dimensions ...core.In
 		return generalArray.(*core.GeneralArrayStar).Scalar, nil
 	}
 	array := generalArray.(*core.GeneralArrayStar)
-	index := int(dimensions[0].(core.Integer))
-	if core.DeepEqual(array.Vector, nil) || len(array.Vector) <= index {
+	index, err := convIndex(e, dimensions[0])
+	if err != nil {
+		return nil, err
+	}
+	if core.DeepEqual(array.Vector, nil) || index < 0 || len(array.Vector) <= index {
 		return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 	}
 	return Garef(e, array.Vector[index], dimensions[1:]...)
@@ -196,8 +217,11 @@ func SetAref(e core.Environment, obj, basicArray core.Instance, dimensions ...co
 		if len(dimensions) != 1 {
 			return SignalCondition(e, core.NewArityError(e), Nil)
 		}
-		index := int(dimensions[0].(core.Integer))
-		if len(basicArray.(core.String)) <= index {
+		index, err := convIndex(e, dimensions[0])
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || len(basicArray.(core.String)) <= index {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		basicArray.(core.String)[index] = rune(obj.(core.Character))
@@ -206,8 +230,11 @@ func SetAref(e core.Environment, obj, basicArray core.Instance, dimensions ...co
 		if len(dimensions) != 1 {
 			return SignalCondition(e, core.NewArityError(e), Nil)
 		}
-		index := int(dimensions[0].(core.Integer))
-		if len(basicArray.(core.GeneralVector)) <= index {
+		index, err := convIndex(e, dimensions[0])
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || len(basicArray.(core.GeneralVector)) <= index {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		basicArray.(core.GeneralVector)[index] = obj
@@ -227,6 +254,9 @@ func SetGaref(e core.Environment, obj, generalArray core.Instance, dimensions ..
 	if err := ensure(e, core.IntegerClass, dimensions...); err != nil {
 		return nil, err
 	}
+	if len(dimensions) != rank(generalArray.(*core.GeneralArrayStar)) {
+		return SignalCondition(e, core.NewArityError(e), Nil)
+	}
 	if len(dimensions) == 0 {
 		if core.DeepEqual(generalArray.(*core.GeneralArrayStar).Scalar, nil) {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
@@ -235,13 +265,26 @@ func SetGaref(e core.Environment, obj, generalArray core.Instance, dimensions ..
 		return obj, nil
 	}
 	array := generalArray.(*core.GeneralArrayStar)
-	index := int(dimensions[0].(core.Integer))
-	if core.DeepEqual(array.Vector, nil) || len(array.Vector) <= index {
+	index, err := convIndex(e, dimensions[0])
+	if err != nil {
+		return nil, err
+	}
+	if core.DeepEqual(array.Vector, nil) || index < 0 || len(array.Vector) <= index {
 		return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 	}
 	return SetGaref(e, obj, array.Vector[index], dimensions[1:]...)
 }
 
+// rank returns the number of dimensions of the array.
+func rank(array *core.GeneralArrayStar) int {
+	n := 0
+	for array.Vector != nil {
+		n++
+		array = array.Vector[0]
+	}
+	return n
+}
+
 // ArrayDimensions returns a list of the dimensions of a given basic-array. An
 // error shall be signaled if basic-array is not a basic-array (error-id.
 // domain-error). The consequences are undefined if the returned list is
@@ -34,6 +34,16 @@ func TestCreateArray(t *testing.T) {
 			want:    `#(0.0 0.0)`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-array '(2 100000000000000000000000))))`,
+			want:    `(class <storage-exhausted>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-array '(2 1.5))))`,
+			want:    `(class <domain-error>)`,
+			wantErr: false,
+		},
 	})
 }
 
@@ -78,6 +88,36 @@ func TestAref(t *testing.T) {
 			want:    `19`,
 			wantErr: false,
 		},
+		{
+			exp:     `(aref array1 0 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(aref array1 0 1 2 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(aref array1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(aref array1 3 0 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(aref "abc" 0 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(garef array1 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
 
@@ -93,6 +133,46 @@ func TestSetAref(t *testing.T) {
 			want:    `51.3`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (aref (create-array '(2 2) 0) 1 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (set-aref #\x "ab" 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (aref (create-array '(2 2) 0) 1 -1)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(set-aref 1 (create-array '(2 2)) 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(set-aref 1 (create-array '(2 2)) 0 0 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(set-aref 1 (create-array '(2 2)))`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(set-garef 1 (create-array '(2 2)) 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(set-aref #\a "abc" 3)`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
 
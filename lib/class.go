@@ -14,20 +14,38 @@ func ClassOf(e core.Environment, obj core.Instance) (core.Instance, core.Instanc
 	return obj.Class(), nil
 }
 
-func Instancep(e core.Environment, obj core.Instance, class core.Class) (core.Instance, core.Instance) {
-	if core.InstanceOf(class, obj) {
+func Instancep(e core.Environment, obj, class core.Instance) (core.Instance, core.Instance) {
+	if err := ensureClass(e, class); err != nil {
+		return nil, err
+	}
+	if core.InstanceOf(class.(core.Class), obj) {
 		return T, nil
 	}
 	return Nil, nil
 }
 
-func Subclassp(e core.Environment, class1, class2 core.Class) (core.Instance, core.Instance) {
-	if core.SubclassOf(class1, class2) {
+func Subclassp(e core.Environment, class1, class2 core.Instance) (core.Instance, core.Instance) {
+	if err := ensureClass(e, class1, class2); err != nil {
+		return nil, err
+	}
+	if core.SubclassOf(class1.(core.Class), class2.(core.Class)) {
 		return T, nil
 	}
 	return Nil, nil
 }
 
+// ensureClass signals a <domain-error> unless every instance is a class, be
+// it built-in or standard.
+func ensureClass(e core.Environment, i ...core.Instance) core.Instance {
+	for _, o := range i {
+		if _, ok := o.(core.Class); !ok {
+			_, err := SignalCondition(e, core.NewDomainError(e, o, core.ObjectClass), Nil)
+			return err
+		}
+	}
+	return nil
+}
+
 func Class(e core.Environment, className core.Instance) (core.Class, core.Instance) {
 	if v, ok := e.Class[:1].Get(className); ok {
 		return v.(core.Class), nil
@@ -36,6 +54,37 @@ func Class(e core.Environment, className core.Instance) (core.Class, core.Instan
 	return nil, err
 }
 
+// Assure evaluates form and returns its value, after checking it is an
+// instance of the class named className. A <domain-error> is signaled
+// otherwise.
+func Assure(e core.Environment, className, form core.Instance) (core.Instance, core.Instance) {
+	class, err := Class(e, className)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := Eval(e, form)
+	if err != nil {
+		return nil, err
+	}
+	if !core.InstanceOf(class, obj) {
+		return SignalCondition(e, core.NewDomainError(e, obj, class), Nil)
+	}
+	return obj, nil
+}
+
+// The evaluates form and returns its value, which is declared to be an
+// instance of the class named className. The declaration is checked like
+// Assure in the debug mode of the runtime only.
+func The(e core.Environment, className, form core.Instance) (core.Instance, core.Instance) {
+	if e.Runtime != nil && e.Runtime.Debug {
+		return Assure(e, className, form)
+	}
+	if _, err := Class(e, className); err != nil {
+		return nil, err
+	}
+	return Eval(e, form)
+}
+
 func checkSuperClass(a, b core.Class) bool {
 	if core.DeepEqual(a, core.StandardObjectClass) || core.DeepEqual(b, core.StandardObjectClass) {
 		return false
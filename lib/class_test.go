@@ -71,6 +71,70 @@ func TestDefclass(t *testing.T) {
 			want:    `nil`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (subclassp 1 (class <object>))))`,
+			want:    `(class <domain-error>)`,
+			wantErr: false,
+		},
 	}
 	execTests(t, Defclass, tests)
 }
+
+func TestAssure(t *testing.T) {
+	execTests(t, Assure, []test{
+		{
+			exp:     `(assure <integer> (+ 1 2))`,
+			want:    `3`,
+			wantErr: false,
+		},
+		{
+			exp:     `(assure <number> 1.5)`,
+			want:    `1.5`,
+			wantErr: false,
+		},
+		{
+			exp:     `(assure <integer> "a")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(assure <nothing> 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+}
+
+func TestThe(t *testing.T) {
+	execTests(t, The, []test{
+		{
+			exp:     `(the <integer> (+ 1 2))`,
+			want:    `3`,
+			wantErr: false,
+		},
+		{
+			exp:     `(the <integer> "a")`,
+			want:    `"a"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(the <nothing> 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+	TopLevel.Runtime.Debug = true
+	defer func() { TopLevel.Runtime.Debug = false }()
+	execTests(t, The, []test{
+		{
+			exp:     `(the <integer> (+ 1 2))`,
+			want:    `3`,
+			wantErr: false,
+		},
+		{
+			exp:     `(the <integer> "a")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+}
@@ -4,7 +4,12 @@
 
 package lib
 
-import "github.com/islisp-dev/iris/core"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+)
 
 func SignalCondition(e core.Environment, condition, continuable core.Instance) (core.Instance, core.Instance) {
 	return core.SignalCondition(e, condition, continuable)
@@ -39,19 +44,187 @@ func Error(e core.Environment, errorString core.Instance, objs ...core.Instance)
 	return SignalCondition(e, condition, Nil)
 }
 
+// ignoreErrors is the handler established by IGNORE-ERRORS. The errors are
+// returned to it, and the other conditions left to the outer handler, which is
+// active while ignoreErrors runs.
+var ignoreErrors = core.NewFunction(core.NewSymbol("IGNORE-ERRORS"), func(e core.Environment, condition core.Instance) (core.Instance, core.Instance) {
+	if core.InstanceOf(core.ErrorClass, condition) {
+		return nil, condition
+	}
+	ret, err := SignalCondition(e, condition, slot(condition, core.SeriousConditionClass, "IRIS.CONTINUABLE"))
+	if err != nil {
+		return nil, err
+	}
+	return ContinueCondition(e, condition, ret)
+})
+
 func IgnoreErrors(e core.Environment, forms ...core.Instance) (core.Instance, core.Instance) {
-	ret, err := Progn(e, forms...)
+	ret, err := Progn(e.NewHandler(ignoreErrors), forms...)
 	if err != nil && core.InstanceOf(core.ErrorClass, err) {
 		return Nil, nil
 	}
 	return ret, err
 }
 
+// ReportCondition is the method of the generic function report-condition for
+// <serious-condition>, which writes the class of the condition to stream.
+// The other methods describe the slots of the built-in condition classes.
 func ReportCondition(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
-	return Format(e, e.StandardOutput, core.NewString([]rune("~A")), condition)
+	return report(e, stream, "~A was signaled", condition.Class())
+}
+
+func ReportArithmeticError(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	operation := slot(condition, core.ArithmeticErrorClass, "OPERATION")
+	operands := slot(condition, core.ArithmeticErrorClass, "OPERANDS")
+	return report(e, stream, "Arithmetic error in ~A with operands ~S", operation, operands)
+}
+
+func ReportDivisionByZero(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	operation := slot(condition, core.ArithmeticErrorClass, "OPERATION")
+	operands := slot(condition, core.ArithmeticErrorClass, "OPERANDS")
+	return report(e, stream, "Division by zero in ~A with operands ~S", operation, operands)
+}
+
+func ReportParseError(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	str := slot(condition, core.ParseErrorClass, "STRING")
+	expectedClass := slot(condition, core.ParseErrorClass, "EXPECTED-CLASS")
+	return report(e, stream, "Cannot parse ~S as ~A", str, expectedClass)
+}
+
+// ReportProgramError describes the panic of a builtin, whose name and value
+// are kept by NewPanicError, and the other program errors by their class.
+func ReportProgramError(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	c, ok := condition.(core.BasicInstance)
+	if !ok {
+		return ReportCondition(e, condition, stream)
+	}
+	value, ok := c.GetSlotValue(core.NewSymbol("IRIS.PANIC"), core.ProgramErrorClass)
+	if !ok {
+		return ReportCondition(e, condition, stream)
+	}
+	operation := slot(condition, core.ProgramErrorClass, "IRIS.OPERATION")
+	return report(e, stream, "Internal error in ~A: ~A", operation, value)
+}
+
+func ReportDomainError(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	object := slot(condition, core.DomainErrorClass, "OBJECT")
+	expectedClass := slot(condition, core.DomainErrorClass, "EXPECTED-CLASS")
+	return report(e, stream, "~S is not an instance of ~A", object, expectedClass)
+}
+
+func ReportSyntaxError(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	form := slot(condition, core.SyntaxErrorClass, "FORM")
+	syntax := slot(condition, core.SyntaxErrorClass, "SYNTAX")
+	object := slot(condition, core.SyntaxErrorClass, "OBJECT")
+	operator := form
+	if c, ok := form.(*core.Cons); ok {
+		operator = c.Car
+	}
+	if object == form {
+		return report(e, stream, "Malformed ~A form, whose syntax is ~A", operator, syntax)
+	}
+	return report(e, stream, "~S is invalid in the ~A form, whose syntax is ~A", object, operator, syntax)
+}
+
+func ReportUndefinedEntity(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	name := slot(condition, core.UndefinedEntityClass, "NAME")
+	namespace := strings.ToLower(fmt.Sprint(slot(condition, core.UndefinedEntityClass, "NAMESPACE")))
+	if namespace == "variable" {
+		return report(e, stream, "The variable ~A is unbound", name)
+	}
+	return report(e, stream, "The ~A ~A is undefined", core.NewString([]rune(namespace)), name)
+}
+
+func ReportSimpleError(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	formatString := slot(condition, core.SimpleErrorClass, "FORMAT-STRING")
+	formatArguments, ok := slot(condition, core.SimpleErrorClass, "FORMAT-ARGUMENTS").(core.List)
+	if !ok {
+		return ReportCondition(e, condition, stream)
+	}
+	if _, err := Format(e, stream, formatString, formatArguments.Slice()...); err != nil {
+		return nil, err
+	}
+	return Nil, nil
+}
+
+func ReportEndOfStream(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	return report(e, stream, "Unexpected end of stream")
+}
+
+// quotaNames are the names of the limits reported for the quotas.
+var quotaNames = map[string]string{
+	"STEPS": "step",
+	"DEPTH": "depth",
+	"SIZE":  "allocation size",
+}
+
+// ReportStorageExhausted describes the stack depth exceeded, if the condition
+// was signaled for it.
+func ReportStorageExhausted(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	depth := slot(condition, core.StorageExhaustedClass, "IRIS.STACK-DEPTH")
+	if depth == Nil {
+		return ReportCondition(e, condition, stream)
+	}
+	return report(e, stream, "The stack depth limit ~A was exceeded", depth)
+}
+
+func ReportQuotaExceeded(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	quota := slot(condition, core.QuotaExceededClass, "QUOTA")
+	limit := slot(condition, core.QuotaExceededClass, "LIMIT")
+	name, ok := quotaNames[quota.String()]
+	if !ok {
+		name = strings.ToLower(quota.String())
+	}
+	return report(e, stream, "The ~A limit ~A was exceeded", core.NewString([]rune(name)), limit)
+}
+
+func ReportCancelled(e core.Environment, condition, stream core.Instance) (core.Instance, core.Instance) {
+	reason := slot(condition, core.CancelledClass, "REASON")
+	return report(e, stream, "The evaluation was cancelled: ~A", reason)
+}
+
+// ReportString returns the description of the condition written by the
+// generic function report-condition, or its printed representation if the
+// report fails.
+func ReportString(e core.Environment, condition core.Instance) string {
+	fun, ok := e.Function.Get(core.NewSymbol("REPORT-CONDITION"))
+	if !ok {
+		return condition.String()
+	}
+	stream, _ := CreateStringOutputStream(e)
+	if _, err := fun.(core.Applicable).Apply(e.NewDynamic(), condition, stream); err != nil {
+		return condition.String()
+	}
+	str, err := GetOutputStreamString(e, stream)
+	if err != nil {
+		return condition.String()
+	}
+	return string(str.(core.String))
+}
+
+// report writes a description formatted by format to stream.
+func report(e core.Environment, stream core.Instance, format string, objs ...core.Instance) (core.Instance, core.Instance) {
+	if _, err := Format(e, stream, core.NewString([]rune(format)), objs...); err != nil {
+		return nil, err
+	}
+	return Nil, nil
+}
+
+// slot returns the value of the slot key of the condition which is declared
+// in class, or nil if it is unbound.
+func slot(condition core.Instance, class core.Class, key string) core.Instance {
+	if c, ok := condition.(core.BasicInstance); ok {
+		if v, ok := c.GetSlotValue(core.NewSymbol(key), class); ok {
+			return v
+		}
+	}
+	return Nil
 }
 
 func ConditionContinuable(e core.Environment, condition core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.SeriousConditionClass, condition); err != nil {
+		return nil, err
+	}
 	if continuable, ok := condition.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.CONTINUABLE"), core.SeriousConditionClass); ok {
 		return continuable, nil
 	}
@@ -59,6 +232,9 @@ func ConditionContinuable(e core.Environment, condition core.Instance) (core.Ins
 }
 
 func ContinueCondition(e core.Environment, condition core.Instance, value ...core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.SeriousConditionClass, condition); err != nil {
+		return nil, err
+	}
 	if b, ok := condition.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.CONTINUABLE"), core.SeriousConditionClass); !ok || b == Nil {
 		return nil, core.Create(e, core.ProgramErrorClass)
 	}
@@ -87,10 +263,7 @@ func WithHandler(e core.Environment, handler core.Instance, forms ...core.Instan
 func CreateReader(class core.Class, key string) func(e core.Environment, c core.Instance) (core.Instance, core.Instance) {
 	return func(e core.Environment, c core.Instance) (core.Instance, core.Instance) {
 		if core.InstanceOf(class, c) {
-			if v, ok := c.(core.BasicInstance).GetSlotValue(core.NewSymbol(key), core.ArithmeticErrorClass); ok {
-				return v, nil
-			}
-			return Nil, nil
+			return slot(c, class, key), nil
 		}
 		return SignalCondition(e, core.NewDomainError(e, c, class), Nil)
 	}
@@ -35,3 +35,176 @@ func TestSignalCondition(t *testing.T) {
 	}
 	execTests(t, SignalCondition, tests)
 }
+
+func TestWithHandler(t *testing.T) {
+	tests := []test{
+		{
+			exp:     `(defdynamic *handled* 1)`,
+			want:    `'*handled*`,
+			wantErr: false,
+		},
+		{
+			exp:     `(dynamic-let ((*handled* 2)) (with-handler (lambda (c) nil) (dynamic *handled*)))`,
+			want:    `2`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c 'outer)) (with-handler (lambda (c) (car 1)) (car 2))))`,
+			want:    `'outer`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defclass <handled-condition> (<serious-condition>) ())`,
+			want:    `'<handled-condition>`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c 'outer)) (ignore-errors (signal-condition (create (class <handled-condition>)) nil))))`,
+			want:    `'outer`,
+			wantErr: false,
+		},
+		{
+			exp:     `(with-handler (lambda (c) (continue-condition c 5)) (ignore-errors (+ 1 (signal-condition (create (class <handled-condition>)) t))))`,
+			want:    `6`,
+			wantErr: false,
+		},
+		{
+			exp:     `(with-handler (lambda (c) (throw 'c 'outer)) (ignore-errors (car 1)))`,
+			want:    `nil`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defun handled-car (x) (car x))`,
+			want:    `'handled-car`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c 'handled)) (handled-car 1)))`,
+			want:    `'handled`,
+			wantErr: false,
+		},
+		{
+			exp:     `(let ((f (lambda (x) (car x)))) (catch 'c (with-handler (lambda (c) (throw 'c 'handled)) (funcall f 1))))`,
+			want:    `'handled`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c 'outer)) (funcall (with-handler (lambda (c) (throw 'c 'inner)) (lambda () (car 1))))))`,
+			want:    `'outer`,
+			wantErr: false,
+		},
+	}
+	execTests(t, WithHandler, tests)
+}
+
+func TestReportCondition(t *testing.T) {
+	tests := []test{
+		{
+			exp: `
+				(defmacro report (form)
+					` + "`" + `(let ((s (create-string-output-stream)))
+						(report-condition (block b (with-handler (lambda (c) (return-from b c)) ,form)) s)
+						(get-output-stream-string s)))
+				`,
+			want:    `'report`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (car 1))`,
+			want:    `"1 is not an instance of <CONS>"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (report-condition-undefined))`,
+			want:    `"The function REPORT-CONDITION-UNDEFINED is undefined"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report report-condition-unbound)`,
+			want:    `"The variable REPORT-CONDITION-UNBOUND is unbound"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (error "~A and ~S" 1 "a"))`,
+			want:    `"1 and \"a\""`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (div 1 0))`,
+			want:    `"Division by zero in DIV with operands (1 0)"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (parse-number "1a"))`,
+			want:    `"Cannot parse \"1a\" as <NUMBER>"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defclass <report-error> (<error>) ((x :initarg x :reader report-error-x)))`,
+			want:    `'<report-error>`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (signal-condition (create (class <report-error>) 'x 1) nil))`,
+			want:    `"<REPORT-ERROR> was signaled"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defmethod report-condition ((c <report-error>) s) (format s "report error ~A" (report-error-x c)))`,
+			want:    `'report-condition`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (signal-condition (create (class <report-error>) 'x 1) nil))`,
+			want:    `"report error 1"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (let ((x 1) y) x))`,
+			want:    `"Y is invalid in the LET form, whose syntax is (let ((var form)*) body-form*)"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(report (if 1))`,
+			want:    `"Malformed IF form, whose syntax is (if test-form then-form [else-form])"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(syntax-error-object (block b (with-handler (lambda (c) (return-from b c)) (setq 1 2))))`,
+			want:    `1`,
+			wantErr: false,
+		},
+	}
+	execTests(t, ReportCondition, tests)
+}
+
+func TestConditionReaders(t *testing.T) {
+	tests := []test{
+		{
+			exp:     `(defmacro condition-of (form) ` + "`" + `(block b (with-handler (lambda (c) (return-from b c)) ,form)))`,
+			want:    `'condition-of`,
+			wantErr: false,
+		},
+		{
+			exp:     `(domain-error-object (condition-of (car 1)))`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(domain-error-expected-class (condition-of (car 1)))`,
+			want:    `(class <cons>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(undefined-entity-name (condition-of (condition-readers-undefined)))`,
+			want:    `'condition-readers-undefined`,
+			wantErr: false,
+		},
+		{
+			exp:     `(undefined-entity-namespace (condition-of condition-readers-unbound))`,
+			want:    `'variable`,
+			wantErr: false,
+		},
+	}
+	execTests(t, CreateReader, tests)
+}
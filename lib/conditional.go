@@ -11,20 +11,23 @@ import "github.com/islisp-dev/iris/core"
 // otherwise (if the test-form returned nil), the else-form is evaluated and its
 // value is returned. If no else-form is provided, it defaults to nil.
 func If(e core.Environment, testForm, thenForm core.Instance, elseForm ...core.Instance) (core.Instance, core.Instance) {
+	form := core.CoveredForm(e)
 	tf, err := Eval(e, testForm)
 	if err != nil {
 		return nil, err
 	}
 	if tf != Nil {
-		return Eval(e, thenForm)
+		core.CoverBranch(e, form, 0)
+		return evalTail(e, thenForm)
 	}
 	if len(elseForm) > 1 {
 		return SignalCondition(e, core.NewArityError(e), Nil)
 	}
+	core.CoverBranch(e, form, 1)
 	if len(elseForm) == 0 {
 		return Nil, nil
 	}
-	return Eval(e, elseForm[0])
+	return evalTail(e, elseForm[0])
 }
 
 // Cond the clauses (test form*) are scanned sequentially and in each case the
@@ -34,7 +37,8 @@ func If(e core.Environment, testForm, thenForm core.Instance, elseForm ...core.I
 // nil is returned. If no form exists for the successful test then the value of
 // this test is returned.
 func Cond(e core.Environment, testFrom ...core.Instance) (core.Instance, core.Instance) {
-	for _, tf := range testFrom {
+	form := core.CoveredForm(e)
+	for idx, tf := range testFrom {
 		if err := ensure(e, core.ListClass, tf); err != nil {
 			return nil, err
 		}
@@ -47,9 +51,11 @@ func Cond(e core.Environment, testFrom ...core.Instance) (core.Instance, core.In
 			return nil, err
 		}
 		if core.DeepEqual(ret, T) {
-			return Progn(e, s[1:]...)
+			core.CoverBranch(e, form, idx)
+			return prognTail(e, s[1:]...)
 		}
 	}
+	core.CoverBranch(e, form, len(testFrom))
 	return Nil, nil
 }
 
@@ -68,6 +74,7 @@ func Cond(e core.Environment, testFrom ...core.Instance) (core.Instance, core.In
 // forms, if any, are evaluated sequentially, and the value of the last one is
 // the result of the case form.
 func Case(e core.Environment, key core.Instance, pattern ...core.Instance) (core.Instance, core.Instance) {
+	covered := core.CoveredForm(e)
 	key, err := Eval(e, key)
 	if err != nil {
 		return nil, err
@@ -81,17 +88,20 @@ func Case(e core.Environment, key core.Instance, pattern ...core.Instance) (core
 			return SignalCondition(e, core.NewArityError(e), Nil)
 		}
 		if idx == len(pattern)-1 && core.DeepEqual(form[0], T) {
-			return Progn(e, form[1:]...)
+			core.CoverBranch(e, covered, idx)
+			return prognTail(e, form[1:]...)
 		}
 		if err := ensure(e, core.ListClass, form[0]); err != nil {
 			return nil, err
 		}
 		for _, k := range form[0].(core.List).Slice() {
 			if core.DeepEqual(k, key) {
-				return Progn(e, form[1:]...)
+				core.CoverBranch(e, covered, idx)
+				return prognTail(e, form[1:]...)
 			}
 		}
 	}
+	core.CoverBranch(e, covered, len(pattern))
 	return Nil, nil
 }
 
@@ -111,6 +121,7 @@ func Case(e core.Environment, key core.Instance, pattern ...core.Instance) (core
 // and there is a default clause, its forms, if any, are evaluated sequentially,
 // and the value of the last one is the result of the case form.
 func CaseUsing(e core.Environment, pred, key core.Instance, pattern ...core.Instance) (core.Instance, core.Instance) {
+	covered := core.CoveredForm(e)
 	key, err := Eval(e, key)
 	if err != nil {
 		return nil, err
@@ -131,7 +142,8 @@ func CaseUsing(e core.Environment, pred, key core.Instance, pattern ...core.Inst
 			return SignalCondition(e, core.NewArityError(e), Nil)
 		}
 		if idx == len(pattern)-1 && core.DeepEqual(form[0], T) {
-			return Progn(e, form[1:]...)
+			core.CoverBranch(e, covered, idx)
+			return prognTail(e, form[1:]...)
 		}
 		if err := ensure(e, core.ListClass, form[0]); err != nil {
 			return nil, err
@@ -142,9 +154,11 @@ func CaseUsing(e core.Environment, pred, key core.Instance, pattern ...core.Inst
 				return nil, err
 			}
 			if ret != Nil {
-				return Progn(e, form[1:]...)
+				core.CoverBranch(e, covered, idx)
+				return prognTail(e, form[1:]...)
 			}
 		}
 	}
+	core.CoverBranch(e, covered, len(pattern))
 	return Nil, nil
 }
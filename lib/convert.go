@@ -4,7 +4,11 @@
 
 package lib
 
-import "github.com/islisp-dev/iris/core"
+import (
+	"unicode"
+
+	"github.com/islisp-dev/iris/core"
+)
 
 func Convert(e core.Environment, object, class1 core.Instance) (core.Instance, core.Instance) {
 	object, err := Eval(e, object)
@@ -32,11 +36,13 @@ func Convert(e core.Environment, object, class1 core.Instance) (core.Instance, c
 	case core.IntegerClass.String():
 		switch class1.String() {
 		case core.CharacterClass.String():
-			return core.NewCharacter(rune(int(object.(core.Integer)))), nil
+			if i, ok := object.(core.Integer); ok && 0 <= i && i <= unicode.MaxRune {
+				return core.NewCharacter(rune(i)), nil
+			}
 		case core.IntegerClass.String():
 			return object, nil
 		case core.FloatClass.String():
-			return core.NewFloat(float64(int(object.(core.Integer)))), nil
+			return Float(e, object)
 		case core.SymbolClass.String():
 		case core.StringClass.String():
 			return core.NewString([]rune(object.String())), nil
@@ -47,7 +53,7 @@ func Convert(e core.Environment, object, class1 core.Instance) (core.Instance, c
 		switch class1.String() {
 		case core.CharacterClass.String():
 		case core.IntegerClass.String():
-			return core.NewInteger(int(float64(object.(core.Float)))), nil
+			return Truncate(e, object)
 		case core.FloatClass.String():
 			return object, nil
 		case core.SymbolClass.String():
@@ -79,12 +85,18 @@ func Convert(e core.Environment, object, class1 core.Instance) (core.Instance, c
 		case core.StringClass.String():
 			return object, nil
 		case core.GeneralVectorClass.String():
+			if _, err := core.CountAllocation(e, len(object.(core.String))); err != nil {
+				return nil, err
+			}
 			v := make([]core.Instance, len(object.(core.String)))
 			for i, c := range object.(core.String) {
 				v[i] = core.NewCharacter(c)
 			}
 			return core.NewGeneralVector(v), nil
 		case core.ListClass.String():
+			if _, err := core.CountAllocation(e, len(object.(core.String))); err != nil {
+				return nil, err
+			}
 			l := Nil
 			s := object.(core.String)
 			for i := len(s) - 1; i >= 0; i-- {
@@ -102,6 +114,9 @@ func Convert(e core.Environment, object, class1 core.Instance) (core.Instance, c
 		case core.GeneralVectorClass.String():
 			return object, nil
 		case core.ListClass.String():
+			if _, err := core.CountAllocation(e, len(object.(core.GeneralVector))); err != nil {
+				return nil, err
+			}
 			return List(e, object.(core.GeneralVector)...)
 		}
 	case core.ListClass.String():
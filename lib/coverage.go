@@ -0,0 +1,150 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import "github.com/islisp-dev/iris/core"
+
+// elementsOf returns the elements of x if it is a proper list.
+func elementsOf(x core.Instance) ([]core.Instance, bool) {
+	if !core.InstanceOf(core.ListClass, x) || !isProperList(x) {
+		return nil, false
+	}
+	return x.(core.List).Slice(), true
+}
+
+// addCoverage adds the form read to the coverage c, with the forms it
+// evaluates when it is evaluated, found by the syntax of the special forms.
+// The conditional forms are added with their branches. The arguments of a
+// macro form are not added, as they may not be forms.
+func addCoverage(e core.Environment, c *core.Coverage, form core.Instance) {
+	s, ok := elementsOf(form)
+	if !ok || len(s) == 0 {
+		return
+	}
+	c.AddForm(form)
+	car, args := s[0], s[1:]
+	if lambda, ok := elementsOf(car); ok && len(lambda) > 1 && core.DeepEqual(lambda[0], core.NewSymbol("LAMBDA")) {
+		addCoverages(e, c, lambda[2:])
+		addCoverages(e, c, args)
+		return
+	}
+	if _, ok := e.Special.Get(car); ok {
+		if CheckSyntax(e, form) == nil {
+			addSpecialCoverage(e, c, form, car.String(), args)
+		}
+		return
+	}
+	if _, ok := e.Macro.Get(car); ok {
+		return
+	}
+	addCoverages(e, c, args)
+}
+
+func addCoverages(e core.Environment, c *core.Coverage, forms []core.Instance) {
+	for _, form := range forms {
+		addCoverage(e, c, form)
+	}
+}
+
+// addSpecialCoverage adds the forms evaluated by the special form of the
+// operator, whose syntax is valid, to the coverage c.
+func addSpecialCoverage(e core.Environment, c *core.Coverage, form core.Instance, operator string, args []core.Instance) {
+	parts := func(x core.Instance) []core.Instance {
+		s, _ := elementsOf(x)
+		return s
+	}
+	switch operator {
+	case "QUOTE", "CLASS", "DYNAMIC", "IMPORT", "DEFMODULE", "IN-MODULE", "TRACE", "UNTRACE", "IN-SUITE", "QUASIQUOTE", "GO", "DEFCLASS":
+	case "FUNCTION":
+		addCoverage(e, c, args[0])
+	case "LAMBDA":
+		addCoverages(e, c, args[1:])
+	case "DEFUN", "DEFMACRO":
+		addCoverages(e, c, args[2:])
+	case "DEFGLOBAL", "DEFCONSTANT", "DEFDYNAMIC", "ASSURE", "THE", "ASSERT-ERROR", "SETQ", "SETF", "RETURN-FROM":
+		addCoverage(e, c, args[1])
+	case "CONVERT":
+		addCoverage(e, c, args[0])
+	case "LET", "LET*", "DYNAMIC-LET":
+		for _, binding := range parts(args[0]) {
+			addCoverages(e, c, parts(binding)[1:])
+		}
+		addCoverages(e, c, args[1:])
+	case "FLET", "LABELS":
+		for _, definition := range parts(args[0]) {
+			addCoverages(e, c, parts(definition)[2:])
+		}
+		addCoverages(e, c, args[1:])
+	case "IF":
+		c.AddBranches(form, 2)
+		addCoverages(e, c, args)
+	case "COND":
+		c.AddBranches(form, branches(args))
+		for _, clause := range args {
+			addCoverages(e, c, parts(clause))
+		}
+	case "CASE", "CASE-USING":
+		keys := 1
+		if operator == "CASE-USING" {
+			keys = 2
+		}
+		c.AddBranches(form, branches(args[keys:]))
+		addCoverages(e, c, args[:keys])
+		for _, clause := range args[keys:] {
+			addCoverages(e, c, parts(clause)[1:])
+		}
+	case "FOR":
+		for _, spec := range parts(args[0]) {
+			addCoverages(e, c, parts(spec)[1:])
+		}
+		addCoverages(e, c, parts(args[1]))
+		addCoverages(e, c, args[2:])
+	case "BLOCK", "DEFTEST":
+		addCoverages(e, c, args[1:])
+	case "DEFSUITE":
+		for _, option := range args[1:] {
+			addCoverages(e, c, parts(option)[1:])
+		}
+	case "DEFGENERIC":
+		for _, option := range args[2:] {
+			if s := parts(option); s[0].String() == ":METHOD" {
+				addMethodCoverage(e, c, s[1:])
+			}
+		}
+	case "DEFMETHOD":
+		addMethodCoverage(e, c, args[1:])
+	case "WITH-OPEN-INPUT-FILE", "WITH-OPEN-OUTPUT-FILE":
+		addCoverages(e, c, parts(args[0])[1:])
+		addCoverages(e, c, args[1:])
+	default:
+		addCoverages(e, c, args)
+	}
+}
+
+// addMethodCoverage adds the body of a method, following its qualifiers and
+// parameter profile, to the coverage c.
+func addMethodCoverage(e core.Environment, c *core.Coverage, args []core.Instance) {
+	for len(args) > 0 {
+		if _, ok := args[0].(core.Symbol); !ok || args[0] == Nil {
+			break
+		}
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		addCoverages(e, c, args[1:])
+	}
+}
+
+// branches returns the number of branches of a cond or case form with the
+// clauses: one for each clause, and one more for none of them unless the last
+// is taken by t.
+func branches(clauses []core.Instance) int {
+	if n := len(clauses); n > 0 {
+		if s, ok := elementsOf(clauses[n-1]); ok && len(s) > 0 && core.DeepEqual(s[0], T) {
+			return n
+		}
+	}
+	return len(clauses) + 1
+}
@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+func TestCoverage(t *testing.T) {
+	src := `(defun coverage-sign (x)
+  (cond ((> x 0) 'positive)
+        ((< x 0) 'negative)
+        (t 'zero)))
+(defun coverage-parity (x)
+  (if (= (mod x 2) 0) 'even))
+(defun coverage-unused (x)
+  (case x
+    ((1) 'one)))
+(coverage-sign 1)
+(coverage-sign -1)
+(coverage-parity 3)
+`
+	if err := ioutil.WriteFile("__coverage.lsp", []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	coverage := core.NewCoverage()
+	TopLevel.Runtime.StartCoverage(coverage)
+	_, err := LoadFile(TopLevel, "__coverage.lsp")
+	TopLevel.Runtime.StopCoverage()
+	if err != nil {
+		t.Fatalf("LoadFile() = %v", err)
+	}
+	out := new(bytes.Buffer)
+	if err := coverage.WriteLcov(out); err != nil {
+		t.Fatal(err)
+	}
+	want := `TN:
+SF:__coverage.lsp
+BRDA:2,0,0,1
+BRDA:2,0,1,1
+BRDA:2,0,2,0
+BRDA:6,1,0,0
+BRDA:6,1,1,1
+BRDA:8,2,0,-
+BRDA:8,2,1,-
+BRF:7
+BRH:3
+DA:1,1
+DA:2,2
+DA:3,1
+DA:4,0
+DA:5,1
+DA:6,1
+DA:7,1
+DA:8,0
+DA:9,0
+DA:10,1
+DA:11,1
+DA:12,1
+LF:12
+LH:9
+end_of_record
+`
+	if out.String() != want {
+		t.Errorf("WriteLcov() = %q, want %q", out, want)
+	}
+	out.Reset()
+	if err := coverage.WriteHTML(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<p>9 of 12 lines, 3 of 7 branches</p>",
+		`<span class="partial"><span class="count">2</span>    2    (cond ((&gt; x 0) &#39;positive)</span>`,
+		`<span class="missed"><span class="count">0</span>    4          (t &#39;zero)))</span>`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteHTML() = %q, want %q in it", out, want)
+		}
+	}
+}
@@ -29,5 +29,30 @@ func TestDynamic(t *testing.T) {
 			want:    `3`,
 			wantErr: false,
 		},
+		{
+			exp:     `(defdynamic *seen* 'global)`,
+			want:    `'*seen*`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defun seen () (dynamic *seen*))`,
+			want:    `'seen`,
+			wantErr: false,
+		},
+		{
+			exp:     `(dynamic-let ((*seen* 'local)) (seen))`,
+			want:    `'local`,
+			wantErr: false,
+		},
+		{
+			exp:     `(let ((f (lambda () (dynamic *seen*)))) (dynamic-let ((*seen* 'local)) (funcall f)))`,
+			want:    `'local`,
+			wantErr: false,
+		},
+		{
+			exp:     `(funcall (dynamic-let ((*seen* 'local)) (lambda () (dynamic *seen*))))`,
+			want:    `'global`,
+			wantErr: false,
+		},
 	})
 }
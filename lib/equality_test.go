@@ -334,6 +334,16 @@ func TestEqual(t *testing.T) {
 			want:    `nil`,
 			wantErr: false,
 		},
+		{
+			exp:     `(equal (create-string-input-stream "a") (create-string-output-stream))`,
+			want:    `nil`,
+			wantErr: false,
+		},
+		{
+			exp:     `(let ((s (create-string-output-stream))) (equal s s))`,
+			want:    `t`,
+			wantErr: false,
+		},
 	}
 	execTests(t, Equal, tests)
 }
@@ -28,44 +28,23 @@ func evalArguments(e core.Environment, arguments core.Instance) (core.Instance,
 
 }
 
-func evalLambda(e core.Environment, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
+func evalLambda(e core.Environment, form, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
 	// eval if lambda form
 	if core.InstanceOf(core.ConsClass, car) {
 		caar := car.(*core.Cons).Car // Checked at the top of// This sentence
 		if core.DeepEqual(caar, core.NewSymbol("LAMBDA")) {
 			fun, err := Eval(e, car)
 			if err != nil {
-				st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-				if !ok {
-					st = Nil
-				}
-				l, c := caar.(core.Symbol).Location()
-				loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-				err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
 				return nil, err, true
 			}
-
 			arguments, err := evalArguments(e, cdr)
 			if err != nil {
-				st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-				if !ok {
-					st = Nil
-				}
-				l, c := caar.(core.Symbol).Location()
-				loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-				err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
 				return nil, err, true
 			}
+			core.MarkCall(e, form)
 			ret, err := fun.(core.Applicable).Apply(e.NewDynamic(), arguments.(core.List).Slice()...)
 			if err != nil {
-				st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-				if !ok {
-					st = Nil
-				}
-				l, c := caar.(core.Symbol).Location()
-				loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-				err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
-				return nil, err, true
+				return nil, core.PushFrame(err, caar, core.LocationOf(form)), true
 			}
 			return ret, nil, true
 		}
@@ -73,64 +52,47 @@ func evalLambda(e core.Environment, car, cdr core.Instance) (core.Instance, core
 	return nil, nil, false
 }
 
-func evalSpecial(e core.Environment, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
+func evalSpecial(e core.Environment, form, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
 	// get special instance has value of Function interface
 	var spl core.Instance
 	if s, ok := e.Special.Get(car); ok {
 		spl = s
 	}
 	if spl != nil {
+		if err := checkSyntax(e, form); err != nil {
+			return nil, err, true
+		}
 		ret, err := spl.(core.Applicable).Apply(e.NewLexical(), cdr.(core.List).Slice()...)
 		if err != nil {
-			st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-			if !ok {
-				st = Nil
-			}
-			l, c := car.(core.Symbol).Location()
-			loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-			err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
-			return nil, err, true
+			return nil, pushInnermostFrame(err, form), true
 		}
 		return ret, nil, true
 	}
 	return nil, nil, false
 }
 
-func evalMacro(e core.Environment, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
+func evalMacro(e core.Environment, form, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
 	// get special instance has value of Function interface
 	var mac core.Instance
 	if m, ok := e.Macro.Get(car); ok {
 		mac = m
 	}
 	if mac != nil {
+		core.MarkCall(e, form)
 		ret, err := mac.(core.Applicable).Apply(e.NewDynamic(), cdr.(core.List).Slice()...)
 		if err != nil {
-			st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-			if !ok {
-				st = Nil
-			}
-			l, c := car.(core.Symbol).Location()
-			loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-			err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
-			return nil, err, true
+			return nil, core.PushCallFrame(err, form), true
 		}
-		ret, err = Eval(e, ret)
+		ret, err = evalExpansion(e, Eval, locate(ret, form))
 		if err != nil {
-			st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-			if !ok {
-				st = Nil
-			}
-			l, c := car.(core.Symbol).Location()
-			loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-			err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
-			return nil, err, true
+			return nil, pushInnermostFrame(err, form), true
 		}
 		return ret, nil, true
 	}
 	return nil, nil, false
 }
 
-func evalFunction(e core.Environment, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
+func evalFunction(e core.Environment, form, car, cdr core.Instance) (core.Instance, core.Instance, bool) {
 	// get special instance has value of Function interface
 	var fun core.Instance
 	if f, ok := e.Function.Get(car); ok {
@@ -139,25 +101,12 @@ func evalFunction(e core.Environment, car, cdr core.Instance) (core.Instance, co
 	if fun != nil {
 		arguments, err := evalArguments(e, cdr)
 		if err != nil {
-			st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-			if !ok {
-				st = Nil
-			}
-			l, c := car.(core.Symbol).Location()
-			loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-			err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
 			return nil, err, true
 		}
+		core.MarkCall(e, form)
 		ret, err := fun.(core.Applicable).Apply(e.NewDynamic(), arguments.(core.List).Slice()...)
 		if err != nil {
-			st, ok := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), core.SeriousConditionClass)
-			if !ok {
-				st = Nil
-			}
-			l, c := car.(core.Symbol).Location()
-			loc := core.NewCons(core.NewCons(core.NewInteger(l), core.NewInteger(c)), st)
-			err.(core.BasicInstance).SetSlotValue(core.NewSymbol("IRIS.STACKTRACE"), loc, core.SeriousConditionClass)
-			return nil, err, true
+			return nil, core.PushCallFrame(err, form), true
 		}
 		return ret, nil, true
 	}
@@ -169,31 +118,131 @@ func evalCons(e core.Environment, obj core.Instance) (core.Instance, core.Instan
 		return nil, err
 	}
 	if !isProperList(obj) {
-		return SignalCondition(e, core.NewParseError(e, obj, core.ListClass), Nil)
+		return SignalCondition(e, core.PushCallFrame(core.NewParseError(e, obj, core.ListClass), obj), Nil)
 	}
 	car := obj.(*core.Cons).Car // Checked at the top of// This function
 	cdr := obj.(*core.Cons).Cdr // Checked at the top of// This function
+	defer core.Step(e, obj)()
+	core.Cover(e, obj)
 
 	// eval if lambda form
-	if a, b, c := evalLambda(e, car, cdr); c {
+	if a, b, c := evalLambda(e, obj, car, cdr); c {
 		return a, b
 	}
 	// get special instance has value of Function interface
-	if a, b, c := evalSpecial(e, car, cdr); c {
+	if a, b, c := evalSpecial(e, obj, car, cdr); c {
 		return a, b
 	}
 	// get macro instance has value of Function interface
-	if a, b, c := evalMacro(e, car, cdr); c {
+	if a, b, c := evalMacro(e, obj, car, cdr); c {
 		return a, b
 	}
 	// get function instance has value of Function interface
-	if a, b, c := evalFunction(e, car, cdr); c {
+	if a, b, c := evalFunction(e, obj, car, cdr); c {
 		return a, b
 	}
 	err := core.NewUndefinedFunction(e, car)
 	return SignalCondition(e, err, Nil)
 }
 
+// pushInnermostFrame adds the frame of the form to the stack trace of the
+// condition err unless it has frames already. The operators of special forms
+// and macro expansions are not frames themselves, but they locate the errors
+// they signal.
+func pushInnermostFrame(err, form core.Instance) core.Instance {
+	if core.HasStacktrace(err) {
+		return err
+	}
+	return core.PushCallFrame(err, form)
+}
+
+// evalExpansion evaluates the expansion of a macro form with eval. It counts
+// as a function application, so that a macro expanding into itself exhausts
+// the stack depth rather than the Go stack.
+func evalExpansion(e core.Environment, eval func(core.Environment, core.Instance) (core.Instance, core.Instance), expansion core.Instance) (core.Instance, core.Instance) {
+	if _, err := core.EnterFunction(e); err != nil {
+		return nil, err
+	}
+	defer core.LeaveFunction(e)
+	return eval(e, expansion)
+}
+
+// locate gives the expansion of the macro form the location of the form if it
+// has none, so that errors in the expansion point at the macro form.
+func locate(expansion, form core.Instance) core.Instance {
+	if c, ok := expansion.(*core.Cons); ok && c.Location() == nil {
+		c.SetLocation(core.LocationOf(form))
+	}
+	return expansion
+}
+
+// evalTail evaluates a form in tail position. A function call is not made
+// here but returned as a core.TailCall, which is performed by the
+// core.Function.Apply that is running the enclosing function or special form.
+func evalTail(e core.Environment, obj core.Instance) (core.Instance, core.Instance) {
+	if !core.InstanceOf(core.ConsClass, obj) || !isProperList(obj) {
+		return Eval(e, obj)
+	}
+	if _, err := core.CountStep(e); err != nil {
+		return nil, err
+	}
+	car := obj.(*core.Cons).Car // Checked at the top of this function
+	cdr := obj.(*core.Cons).Cdr // Checked at the top of this function
+	if core.InstanceOf(core.ConsClass, car) {
+		caar := car.(*core.Cons).Car
+		if !core.DeepEqual(caar, core.NewSymbol("LAMBDA")) {
+			return evalForm(e, obj)
+		}
+		defer core.Step(e, obj)()
+		core.Cover(e, obj)
+		fun, err := Eval(e, car)
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := evalArguments(e, cdr)
+		if err != nil {
+			return nil, err
+		}
+		return core.TailCall{Function: fun, Arguments: arguments.(core.List).Slice(), Form: obj}, nil
+	}
+	if spl, ok := e.Special.Get(car); ok {
+		defer core.Step(e, obj)()
+		core.Cover(e, obj)
+		if err := checkSyntax(e, obj); err != nil {
+			return nil, err
+		}
+		ret, err := spl.(core.Function).ApplyTail(e.NewLexical(), cdr.(core.List).Slice()...)
+		if err != nil {
+			return nil, pushInnermostFrame(err, obj)
+		}
+		return ret, nil
+	}
+	if mac, ok := e.Macro.Get(car); ok {
+		defer core.Step(e, obj)()
+		core.Cover(e, obj)
+		core.MarkCall(e, obj)
+		ret, err := mac.(core.Applicable).Apply(e.NewDynamic(), cdr.(core.List).Slice()...)
+		if err != nil {
+			return nil, core.PushCallFrame(err, obj)
+		}
+		ret, err = evalExpansion(e, evalTail, locate(ret, obj))
+		if err != nil {
+			return nil, pushInnermostFrame(err, obj)
+		}
+		return ret, nil
+	}
+	if fun, ok := e.Function.Get(car); ok {
+		defer core.Step(e, obj)()
+		core.Cover(e, obj)
+		arguments, err := evalArguments(e, cdr)
+		if err != nil {
+			return nil, err
+		}
+		return core.TailCall{Function: fun, Arguments: arguments.(core.List).Slice(), Form: obj}, nil
+	}
+	return evalForm(e, obj)
+}
+
 func evalVariable(e core.Environment, obj core.Instance) (core.Instance, core.Instance) {
 	if val, ok := e.Variable.Get(obj); ok {
 		return val, nil
@@ -207,6 +256,15 @@ func evalVariable(e core.Environment, obj core.Instance) (core.Instance, core.In
 
 // Eval evaluates any classs
 func Eval(e core.Environment, obj core.Instance) (core.Instance, core.Instance) {
+	if _, err := core.CountStep(e); err != nil {
+		return nil, err
+	}
+	return evalForm(e, obj)
+}
+
+// evalForm evaluates obj like Eval without counting it as a step, for the
+// forms already counted by evalTail.
+func evalForm(e core.Environment, obj core.Instance) (core.Instance, core.Instance) {
 	if core.DeepEqual(obj, Nil) {
 		return Nil, nil
 	}
@@ -0,0 +1,187 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+func TestStacktrace(t *testing.T) {
+	e := NewRuntime()
+	stream := core.NewStream(strings.NewReader(`
+(defun stacktrace-f (x)
+  (let ((y 1))
+    (+ (car x) y)))
+(defun stacktrace-g (x)
+  (stacktrace-f x))
+(stacktrace-g 1)`), nil, core.CharacterClass)
+	var err core.Instance
+	for {
+		form, rerr := Read(e, stream)
+		if rerr != nil {
+			break
+		}
+		_, err = Eval(e, form)
+	}
+	if err == nil {
+		t.Fatal("(stacktrace-g 1) err = nil")
+	}
+	got := []string{}
+	for _, frame := range core.Stacktrace(err) {
+		got = append(got, fmt.Sprint(frame))
+	}
+	want := []string{
+		"CAR (4:8)",
+		"STACKTRACE-F (6:3)",
+		"STACKTRACE-G (7:1)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("frames = %v, want %v", got, want)
+	}
+}
+
+func TestSyntax(t *testing.T) {
+	tests := []struct {
+		exp    string
+		frames []string
+	}{
+		{"(defun syntax-f (x)\n  (let ((x 1) (2 3))\n    x))\n(syntax-f 1)", []string{"LET (2:15)", "SYNTAX-F (4:1)"}},
+		{"(progn\n  (if))", []string{"IF (2:3)"}},
+		{"(defun syntax-g (x)\n  (lambda (&rest) x))\n(syntax-g 1)", []string{"LAMBDA (2:12)", "SYNTAX-G (3:1)"}},
+	}
+	for _, tt := range tests {
+		e := NewRuntime()
+		stream := core.NewStream(strings.NewReader(tt.exp), nil, core.CharacterClass)
+		var err core.Instance
+		for {
+			form, rerr := Read(e, stream)
+			if rerr != nil {
+				break
+			}
+			if _, err = Eval(e, form); err != nil {
+				break
+			}
+		}
+		if err == nil || !core.InstanceOf(core.SyntaxErrorClass, err) {
+			t.Errorf("%v err = %v, want <syntax-error>", tt.exp, err)
+			continue
+		}
+		got := []string{}
+		for _, frame := range core.Stacktrace(err) {
+			got = append(got, fmt.Sprint(frame))
+		}
+		if !reflect.DeepEqual(got, tt.frames) {
+			t.Errorf("%v frames = %v, want %v", tt.exp, got, tt.frames)
+		}
+	}
+}
+
+func TestPanic(t *testing.T) {
+	tests := []struct {
+		exp    string
+		frames []string
+	}{
+		{"(defun panic-f (x)\n  (panic-g x))\n(panic-f 1)", []string{"PANIC-G (2:3)", "PANIC-F (3:1)"}},
+		{`(funcall #'panic-g 1)`, []string{"PANIC-G (?)", "FUNCALL (1:1)"}},
+	}
+	for _, tt := range tests {
+		e := NewRuntime()
+		name := core.NewSymbol("PANIC-G")
+		e.Function.Define(name, core.NewFunction(name, func(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
+			panic("a bug")
+		}))
+		panics := []string{}
+		e.Runtime.Panic = func(name core.Instance, value interface{}) {
+			panics = append(panics, fmt.Sprint(name, ": ", value))
+		}
+		stream := core.NewStream(strings.NewReader(tt.exp), nil, core.CharacterClass)
+		var err core.Instance
+		for {
+			form, rerr := Read(e, stream)
+			if rerr != nil {
+				break
+			}
+			if _, err = Eval(e, form); err != nil {
+				break
+			}
+		}
+		if err == nil || err.Class().String() != core.ProgramErrorClass.String() {
+			t.Errorf("%v err = %v, want %v", tt.exp, err, core.ProgramErrorClass)
+			continue
+		}
+		if !reflect.DeepEqual(panics, []string{"PANIC-G: a bug"}) {
+			t.Errorf("%v panics = %v, want PANIC-G: a bug", tt.exp, panics)
+		}
+		if got := ReportString(e, err); got != "Internal error in PANIC-G: a bug" {
+			t.Errorf("%v report = %q, want Internal error in PANIC-G: a bug", tt.exp, got)
+		}
+		got := []string{}
+		for _, frame := range core.Stacktrace(err) {
+			got = append(got, fmt.Sprint(frame))
+		}
+		if !reflect.DeepEqual(got, tt.frames) {
+			t.Errorf("%v frames = %v, want %v", tt.exp, got, tt.frames)
+		}
+	}
+}
+
+func TestPanicTypeAssertion(t *testing.T) {
+	e := NewRuntime()
+	name := core.NewSymbol("PANIC-CHAR")
+	e.Function.Define(name, core.NewFunction(name, func(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
+		return core.NewInteger(int(x.(core.Character))), nil
+	}))
+	obj, _ := readFromString(`(panic-char "a")`)
+	_, err := Eval(e, obj)
+	if err == nil || !core.InstanceOf(core.DomainErrorClass, err) {
+		t.Fatalf("err = %v, want <domain-error>", err)
+	}
+	if got := ReportString(e, err); got != `"a" is not an instance of <CHARACTER>` {
+		t.Errorf("report = %q", got)
+	}
+	if frames := core.Stacktrace(err); len(frames) == 0 || frames[0].Name.String() != "PANIC-CHAR" {
+		t.Errorf("frames = %v, want PANIC-CHAR first", frames)
+	}
+}
+
+func TestStackDepth(t *testing.T) {
+	tests := []struct {
+		exp  string
+		want string
+	}{
+		{"(defun depth-f (n) (+ 1 (depth-f n)))\n(depth-f 0)", "<STORAGE-EXHAUSTED>"},
+		{"(defmacro depth-m () '(depth-m))\n(depth-m)", "<STORAGE-EXHAUSTED>"},
+		{"(defun depth-f (n) (+ 1 (depth-f n)))\n(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (depth-f 0)))", "<STORAGE-EXHAUSTED>"},
+		{"(defun depth-f (n) (+ 1 (depth-f n)))\n(with-handler (lambda (c) (depth-f 0)) (depth-f 0))", "<STORAGE-EXHAUSTED>"},
+		{"(defun depth-f (n) (if (= n 0) 0 (+ 1 (depth-f (- n 1)))))\n(depth-f 90)", "90"},
+	}
+	for _, tt := range tests {
+		e := NewRuntime()
+		e.Runtime.StackDepth = 100
+		stream := core.NewStream(strings.NewReader(tt.exp), nil, core.CharacterClass)
+		var got, err core.Instance
+		for {
+			form, rerr := Read(e, stream)
+			if rerr != nil {
+				break
+			}
+			if got, err = Eval(e, form); err != nil {
+				got = err.Class()
+				break
+			}
+		}
+		if got == nil || got.String() != tt.want {
+			t.Errorf("%v = %v, want %v", tt.exp, got, tt.want)
+		}
+		if e.Runtime.Usage.Depth != 0 {
+			t.Errorf("%v depth = %v, want 0", tt.exp, e.Runtime.Usage.Depth)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package lib
 
 import (
 	"math"
+	"math/big"
 
 	"github.com/islisp-dev/iris/core"
 )
@@ -42,42 +43,68 @@ func Float(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
 // truncated towards negative infinity. An error shall be signaled if x is not a
 // number (error-id. domain-error).
 func Floor(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
+	if core.InstanceOf(core.IntegerClass, x) {
+		return x, nil
+	}
 	f, _, err := convFloat64(e, x)
 	if err != nil {
 		return nil, err
 	}
-	return core.NewInteger(int(math.Floor(f))), nil
+	return floatToInteger(e, x, math.Floor(f))
 }
 
 // Ceiling Returns the smallest integer that is not smaller than x. That is, x
 // is truncated towards positive infinity. An error shall be signaled if x is
 // not a number (error-id. domain-error).
 func Ceiling(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
+	if core.InstanceOf(core.IntegerClass, x) {
+		return x, nil
+	}
 	f, _, err := convFloat64(e, x)
 	if err != nil {
 		return nil, err
 	}
-	return core.NewInteger(int(math.Ceil(f))), nil
+	return floatToInteger(e, x, math.Ceil(f))
 }
 
 // Truncate returns the integer between 0 and x (inclusive) that is nearest to
 // x. That is, x is truncated towards zero. An error shall be signaled if x is
 // not a number (error-id. domain-error).
 func Truncate(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
+	if core.InstanceOf(core.IntegerClass, x) {
+		return x, nil
+	}
 	f, _, err := convFloat64(e, x)
 	if err != nil {
 		return nil, err
 	}
-	return core.NewInteger(int(math.Trunc(f))), nil
+	return floatToInteger(e, x, math.Trunc(f))
 }
 
 // Round returns the integer nearest to x. If x is exactly halfway between two
 // integers, the even one is chosen. An error shall be signaled if x is not a
 // number (error-id. domain-error).
 func Round(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
+	if core.InstanceOf(core.IntegerClass, x) {
+		return x, nil
+	}
 	f, _, err := convFloat64(e, x)
 	if err != nil {
 		return nil, err
 	}
-	return core.NewInteger(int(math.Floor(f + .5))), nil
+	return floatToInteger(e, x, math.Floor(f+.5))
+}
+
+// floatToInteger returns the integer equal to the integral float f computed
+// from x. An error shall be signaled if f is infinite or not a number
+// (error-id. domain-error).
+func floatToInteger(e core.Environment, x core.Instance, f float64) (core.Instance, core.Instance) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return SignalCondition(e, core.NewDomainError(e, x, core.FloatClass), Nil)
+	}
+	if math.Abs(f) < 1<<53 {
+		return core.NewInteger(int(f)), nil
+	}
+	i, _ := big.NewFloat(f).Int(nil)
+	return core.NewBigInteger(i), nil
 }
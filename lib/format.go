@@ -10,7 +10,7 @@ import (
 )
 
 func FormatObject(e core.Environment, stream, object, escapep core.Instance) (core.Instance, core.Instance) {
-	if ok, _ := OpenStreamP(e, stream); ok == Nil {
+	if ok, _ := OutputStreamP(e, stream); ok == Nil {
 		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
 	}
 	if core.DeepEqual(escapep, T) {
@@ -30,7 +30,7 @@ func FormatObject(e core.Environment, stream, object, escapep core.Instance) (co
 }
 
 func FormatChar(e core.Environment, stream, object core.Instance) (core.Instance, core.Instance) {
-	if ok, _ := OpenStreamP(e, stream); core.DeepEqual(ok, Nil) {
+	if ok, _ := OutputStreamP(e, stream); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
 	}
 	if ok, _ := Characterp(e, object); core.DeepEqual(ok, Nil) {
@@ -41,7 +41,7 @@ func FormatChar(e core.Environment, stream, object core.Instance) (core.Instance
 }
 
 func FormatFloat(e core.Environment, stream, object core.Instance) (core.Instance, core.Instance) {
-	if ok, _ := OpenStreamP(e, stream); core.DeepEqual(ok, Nil) {
+	if ok, _ := OutputStreamP(e, stream); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
 	}
 	if ok, _ := Floatp(e, object); core.DeepEqual(ok, Nil) {
@@ -52,23 +52,35 @@ func FormatFloat(e core.Environment, stream, object core.Instance) (core.Instanc
 }
 
 func FormatInteger(e core.Environment, stream, object, radix core.Instance) (core.Instance, core.Instance) {
-	if ok, _ := OpenStreamP(e, stream); core.DeepEqual(ok, Nil) {
+	if ok, _ := OutputStreamP(e, stream); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
 	}
 	if ok, _ := Integerp(e, object); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, object, core.IntegerClass), Nil)
 	}
-	if ok, _ := Integerp(e, radix); core.DeepEqual(ok, Nil) {
+	r, err := convInt(e, radix)
+	if err != nil {
+		return nil, err
+	}
+	if r < 2 || r > 36 {
 		return SignalCondition(e, core.NewDomainError(e, radix, core.IntegerClass), Nil)
 	}
-	i := int(object.(core.Integer))
-	r := int(radix.(core.Integer))
-	fmt.Fprint(stream.(core.Stream), strings.ToUpper(strconv.FormatInt(int64(i), r)))
+	if i, ok := object.(core.Integer); ok {
+		fmt.Fprint(stream.(core.Stream), strings.ToUpper(strconv.FormatInt(int64(i), r)))
+		return Nil, nil
+	}
+	fmt.Fprint(stream.(core.Stream), strings.ToUpper(bigInt(object).Text(r)))
 	return Nil, nil
 }
 
 func FormatTab(e core.Environment, stream, num core.Instance) (core.Instance, core.Instance) {
-	n := int(num.(core.Integer))
+	if ok, _ := OutputStreamP(e, stream); core.DeepEqual(ok, Nil) {
+		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
+	}
+	n, err := convSize(e, num)
+	if err != nil {
+		return nil, err
+	}
 	if *stream.(core.Stream).Column < n {
 		for i := *stream.(core.Stream).Column; i < n; i++ {
 			if _, err := FormatChar(e, stream, core.NewCharacter(' ')); err != nil {
@@ -81,6 +93,9 @@ func FormatTab(e core.Environment, stream, num core.Instance) (core.Instance, co
 }
 
 func FormatFreshLine(e core.Environment, stream core.Instance) (core.Instance, core.Instance) {
+	if ok, _ := OutputStreamP(e, stream); core.DeepEqual(ok, Nil) {
+		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
+	}
 	if *stream.(core.Stream).Column != 0 {
 		return FormatChar(e, stream, core.NewCharacter('\n'))
 	}
@@ -88,6 +103,9 @@ func FormatFreshLine(e core.Environment, stream core.Instance) (core.Instance, c
 }
 
 func Format(e core.Environment, stream, formatString core.Instance, formatArguments ...core.Instance) (core.Instance, core.Instance) {
+	if ok, _ := OutputStreamP(e, stream); core.DeepEqual(ok, Nil) {
+		return SignalCondition(e, core.NewDomainError(e, stream, core.StreamClass), Nil)
+	}
 	if ok, _ := Stringp(e, formatString); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, formatString, core.StringClass), Nil)
 	}
@@ -26,7 +26,7 @@ func TestFormat(t *testing.T) {
 		},
 		{
 			exp:     `(progn (format str "The results are ~S and ~S" 1 #\a) (get-output-stream-string str))`,
-			want:    `"The results are 1 and #\a"`,
+			want:    `"The results are 1 and #\\a"`,
 			wantErr: false,
 		},
 		{
@@ -34,6 +34,21 @@ func TestFormat(t *testing.T) {
 			want:    `"Binary code 10010110"`,
 			wantErr: false,
 		},
+		{
+			exp:     `(progn (format str "~X ~A ~D" 18446744073709551615 -18446744073709551616 (expt 10 20)) (get-output-stream-string str))`,
+			want:    `"FFFFFFFFFFFFFFFF -18446744073709551616 100000000000000000000"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(progn (format str "~36R" 18446744073709551616) (get-output-stream-string str))`,
+			want:    `"3W5E11264SGSG"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(progn (format str "~37R" 10) (get-output-stream-string str))`,
+			want:    `nil`,
+			wantErr: true,
+		},
 		{
 			exp:     `(progn (format str "permission ~O" 493) (get-output-stream-string str))`,
 			want:    `"permission 755"`,
@@ -55,5 +70,35 @@ func TestFormat(t *testing.T) {
 			want:    `"This is a tilde: ~"`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (format-tab str 100000000000000000000000)))`,
+			want:    `(class <storage-exhausted>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(format str "~A ~A" 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(format str "~S")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(format str "~D")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(format str "~C")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(format str "~5R")`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
@@ -106,7 +106,7 @@ func Labels(e core.Environment, functions core.Instance, bodyForm ...core.Instan
 			return SignalCondition(e, core.NewImmutableBinding(e), Nil)
 		}
 	}
-	return Progn(e, bodyForm...)
+	return prognTail(e, bodyForm...)
 }
 
 // Flet special form allow the definition of new identifiers in the function
@@ -135,7 +135,7 @@ func Flet(e core.Environment, functions core.Instance, bodyForm ...core.Instance
 			return SignalCondition(e, core.NewImmutableBinding(e), Nil)
 		}
 	}
-	return Progn(newEnv, bodyForm...)
+	return prognTail(newEnv, bodyForm...)
 }
 
 // Apply applies function to the arguments, obj*, followed by the elements of
@@ -147,6 +147,9 @@ func Apply(e core.Environment, function core.Instance, obj ...core.Instance) (co
 	if err := ensure(e, core.FunctionClass, function); err != nil {
 		return nil, err
 	}
+	if len(obj) == 0 {
+		return SignalCondition(e, core.NewArityError(e), Nil)
+	}
 	if err := ensure(e, core.ListClass, obj[len(obj)-1]); err != nil {
 		return nil, err
 	}
@@ -57,6 +57,21 @@ func TestLambda(t *testing.T) {
 			want:    `-18`,
 			wantErr: false,
 		},
+		{
+			exp:     `(let ((x 1)) (let () (let ((y 2)) (let () (funcall (lambda () (+ x y)))))))`,
+			want:    `3`,
+			wantErr: false,
+		},
+		{
+			exp:     `(funcall (let ((x 1)) (let () (lambda () (let () (let ((y 2)) (+ x y)))))))`,
+			want:    `3`,
+			wantErr: false,
+		},
+		{
+			exp:     `(funcall (labels ((evenp (n) (if (= n 0) t (oddp (- n 1)))) (oddp (n) (if (= n 0) nil (evenp (- n 1))))) (lambda () (let () (oddp 7)))))`,
+			want:    `t`,
+			wantErr: false,
+		},
 	}
 	execTests(t, Lambda, tests)
 }
@@ -143,3 +158,52 @@ func TestFuncall(t *testing.T) {
 	}
 	execTests(t, Funcall, tests)
 }
+
+// TestTailCall loops beyond core.DefaultStackDepth, which signals a
+// <storage-exhausted> unless the calls in tail position reuse the frame.
+func TestTailCall(t *testing.T) {
+	tests := []test{
+		{
+			exp:     `(defun tail-count (n acc) (if (= n 0) acc (tail-count (- n 1) (+ acc 1))))`,
+			want:    `'tail-count`,
+			wantErr: false,
+		},
+		{
+			exp:     `(tail-count 20000 0)`,
+			want:    `20000`,
+			wantErr: false,
+		},
+		{
+			exp: `(labels ((tail-even-p (n) (if (= n 0) t (tail-odd-p (- n 1))))
+			            (tail-odd-p (n) (if (= n 0) nil (tail-even-p (- n 1)))))
+			    (tail-even-p 20000))`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp: `(labels ((tail-loop (n)
+			             (cond ((= n 0) 'done)
+			                   (t (let ((m (- n 1)))
+			                        (progn (and t (or nil (tail-loop m)))))))))
+			    (tail-loop 10000))`,
+			want:    `'done`,
+			wantErr: false,
+		},
+		{
+			exp:     `(block b (labels ((tail-exit (n) (if (= n 0) (return-from b 'exit) (tail-exit (- n 1))))) (tail-exit 10)) 'not-reached)`,
+			want:    `'exit`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (labels ((tail-throw (n) (if (= n 0) (throw 'c 'thrown) (tail-throw (- n 1))))) (tail-throw 10)))`,
+			want:    `'thrown`,
+			wantErr: false,
+		},
+		{
+			exp:     `(tail-count 1 2 3)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	}
+	execTests(t, evalTail, tests)
+}
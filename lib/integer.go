@@ -6,15 +6,57 @@ package lib
 
 import (
 	"math"
+	"math/big"
 
 	"github.com/islisp-dev/iris/core"
 )
 
+// convInt converts an integer small enough to be used as an int, such as a
+// radix.
 func convInt(e core.Environment, z core.Instance) (int, core.Instance) {
-	if err := ensure(e, core.IntegerClass, z); err != nil {
+	i, ok := z.(core.Integer)
+	if !ok {
+		_, err := SignalCondition(e, core.NewDomainError(e, z, core.IntegerClass), Nil)
+		return 0, err
+	}
+	return int(i), nil
+}
+
+// convIndex converts an integer used as an index of a sequence or an array.
+// An integer too large to be an int is out of the range of every index.
+func convIndex(e core.Environment, z core.Instance) (int, core.Instance) {
+	if _, ok := z.(*core.BigInteger); ok {
+		_, err := SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		return 0, err
 	}
-	return int(z.(core.Integer)), nil
+	return convInt(e, z)
+}
+
+// maxSize is the greatest number of elements of a string, vector, list or
+// array which can be created.
+const maxSize = math.MaxInt32
+
+// convSize converts a non-negative integer used as the number of elements of
+// a string, vector, list or array to create. An integer over maxSize is too
+// large to allocate.
+func convSize(e core.Environment, z core.Instance) (int, core.Instance) {
+	switch z := z.(type) {
+	case core.Integer:
+		if int64(z) > maxSize {
+			_, err := SignalCondition(e, core.NewStorageExhausted(e), Nil)
+			return 0, err
+		}
+		if z >= 0 {
+			return int(z), nil
+		}
+	case *core.BigInteger:
+		if z.Int().Sign() > 0 {
+			_, err := SignalCondition(e, core.NewStorageExhausted(e), Nil)
+			return 0, err
+		}
+	}
+	_, err := SignalCondition(e, core.NewDomainError(e, z, core.IntegerClass), Nil)
+	return 0, err
 }
 
 // Integerp returns t if obj is an integer (instance of class integer);
@@ -29,26 +71,31 @@ func Integerp(e core.Environment, obj core.Instance) (core.Instance, core.Instan
 // Div returns the greatest integer less than or equal to the quotient of z1 and
 // z2. An error shall be signaled if z2 is zero (error-id. division-by-zero).
 func Div(e core.Environment, z1, z2 core.Instance) (core.Instance, core.Instance) {
-	a, err := convInt(e, z1)
-	if err != nil {
+	if err := ensure(e, core.IntegerClass, z1, z2); err != nil {
 		return nil, err
 	}
-	b, err := convInt(e, z2)
-	if err != nil {
-		return nil, err
-	}
-	if b == 0 {
+	if b, ok := z2.(core.Integer); ok && b == 0 {
 		operation := core.NewSymbol("DIV")
 		operands, err := List(e, z1, z2)
 		if err != nil {
 			return nil, err
 		}
-		return SignalCondition(e, core.NewArithmeticError(e, operation, operands), Nil)
+		return SignalCondition(e, core.NewDivisionByZero(e, operation, operands), Nil)
+	}
+	a, aok := z1.(core.Integer)
+	b, bok := z2.(core.Integer)
+	if aok && bok && !(b == -1 && a == minInt) {
+		q := a / b
+		if a%b != 0 && (a < 0) != (b < 0) { // Issue #2
+			q--
+		}
+		return core.NewInteger(int(q)), nil
 	}
-	if a*b < 0 { // Issue #2
-		return core.NewInteger(a/b - 1), nil
+	q, m := new(big.Int).QuoRem(bigInt(z1), bigInt(z2), new(big.Int))
+	if m.Sign() != 0 && m.Sign() != bigInt(z2).Sign() {
+		q.Sub(q, big.NewInt(1))
 	}
-	return core.NewInteger(a / b), nil
+	return core.NewBigInteger(q), nil
 }
 
 // Mod returns the remainder of the integer division of z1 by z2. The sign of
@@ -74,54 +121,66 @@ func Mod(e core.Environment, z1, z2 core.Instance) (core.Instance, core.Instance
 // error shall be signaled if either z1 or z2 is not an integer (error-id.
 // domain-error).
 func Gcd(e core.Environment, z1, z2 core.Instance) (core.Instance, core.Instance) {
-	gcd := func(x, y int) int {
-		for y != 0 {
-			x, y = y, x%y
-		}
-		return x
-	}
-	a, err := convInt(e, z1)
-	if err != nil {
+	if err := ensure(e, core.IntegerClass, z1, z2); err != nil {
 		return nil, err
 	}
-	b, err := convInt(e, z2)
-	if err != nil {
-		return nil, err
+	a, aok := z1.(core.Integer)
+	b, bok := z2.(core.Integer)
+	if aok && bok && a != minInt && b != minInt {
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		for b != 0 {
+			a, b = b, a%b
+		}
+		return a, nil
 	}
-	return core.NewInteger(gcd(a, b)), nil
+	x := new(big.Int).Abs(bigInt(z1))
+	y := new(big.Int).Abs(bigInt(z2))
+	return core.NewBigInteger(new(big.Int).GCD(nil, nil, x, y)), nil
 }
 
 // Lcm returns the least common multiple of its integer arguments. An error
 // shall be signaled if either z1 or z2 is not an integer (error-id.
 // domain-error).
 func Lcm(e core.Environment, z1, z2 core.Instance) (core.Instance, core.Instance) {
-	gcd := func(x, y int) int {
-		for y != 0 {
-			x, y = y, x%y
-		}
-		return x
+	gcd, err := Gcd(e, z1, z2)
+	if err != nil {
+		return nil, err
+	}
+	if core.DeepEqual(gcd, core.NewInteger(0)) {
+		return gcd, nil
+	}
+	a, err := Abs(e, z1)
+	if err != nil {
+		return nil, err
 	}
-	a, err := convInt(e, z1)
+	b, err := Abs(e, z2)
 	if err != nil {
 		return nil, err
 	}
-	b, err := convInt(e, z2)
+	q, err := Div(e, a, gcd)
 	if err != nil {
 		return nil, err
 	}
-	return core.NewInteger(a * b / gcd(a, b)), nil
+	return Multiply(e, q, b)
 }
 
 // Isqrt Returns the greatest integer less than or equal to the exact positive
 // square root of z . An error shall be signaled if z is not a non-negative
 // integer (error-id. domain-error).
 func Isqrt(e core.Environment, z core.Instance) (core.Instance, core.Instance) {
-	a, err := convInt(e, z)
-	if err != nil {
+	if err := ensure(e, core.IntegerClass, z); err != nil {
 		return nil, err
 	}
-	if a < 0 {
+	if bigInt(z).Sign() < 0 {
 		return SignalCondition(e, core.NewDomainError(e, z, core.NumberClass), Nil)
 	}
-	return core.NewInteger(int(math.Sqrt(float64(a)))), nil
+	if a, ok := z.(core.Integer); ok && int64(a) < 1<<52 {
+		return core.NewInteger(int(math.Sqrt(float64(a)))), nil
+	}
+	return core.NewBigInteger(new(big.Int).Sqrt(bigInt(z))), nil
 }
@@ -0,0 +1,152 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import "testing"
+
+func TestDiv(t *testing.T) {
+	execTests(t, Div, []test{
+		{
+			exp:     `(div 7 2)`,
+			want:    `3`,
+			wantErr: false,
+		},
+		{
+			exp:     `(div -7 2)`,
+			want:    `-4`,
+			wantErr: false,
+		},
+		{
+			exp:     `(div -8 2)`,
+			want:    `-4`,
+			wantErr: false,
+		},
+		{
+			exp:     `(div -9223372036854775808 -1)`,
+			want:    `9223372036854775808`,
+			wantErr: false,
+		},
+		{
+			exp:     `(div -18446744073709551617 4294967296)`,
+			want:    `-4294967297`,
+			wantErr: false,
+		},
+		{
+			exp:     `(mod -7 2)`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(mod 18446744073709551617 -4294967296)`,
+			want:    `-4294967295`,
+			wantErr: false,
+		},
+		{
+			exp:     `(div 1 0)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (div 1 0)))`,
+			want:    `(class <division-by-zero>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (mod 1 0)))`,
+			want:    `(class <division-by-zero>)`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestGcd(t *testing.T) {
+	execTests(t, Gcd, []test{
+		{
+			exp:     `(gcd 12 -18)`,
+			want:    `6`,
+			wantErr: false,
+		},
+		{
+			exp:     `(gcd 36893488147419103232 27670116110564327424)`,
+			want:    `9223372036854775808`,
+			wantErr: false,
+		},
+		{
+			exp:     `(lcm 4 -6)`,
+			want:    `12`,
+			wantErr: false,
+		},
+		{
+			exp:     `(lcm 0 5)`,
+			want:    `0`,
+			wantErr: false,
+		},
+		{
+			exp:     `(lcm 4294967296 12884901888)`,
+			want:    `12884901888`,
+			wantErr: false,
+		},
+		{
+			exp:     `(lcm 9223372036854775807 2)`,
+			want:    `18446744073709551614`,
+			wantErr: false,
+		},
+		{
+			exp:     `(isqrt 18446744073709551616)`,
+			want:    `4294967296`,
+			wantErr: false,
+		},
+		{
+			exp:     `(isqrt 18446744073709551615)`,
+			want:    `4294967295`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestIntegerConversion(t *testing.T) {
+	execTests(t, Floor, []test{
+		{
+			exp:     `(floor 1e20)`,
+			want:    `100000000000000000000`,
+			wantErr: false,
+		},
+		{
+			exp:     `(truncate (- 2.5))`,
+			want:    `-2`,
+			wantErr: false,
+		},
+		{
+			exp:     `(ceiling 18446744073709551616)`,
+			want:    `18446744073709551616`,
+			wantErr: false,
+		},
+		{
+			exp:     `(float 18446744073709551616)`,
+			want:    `1.8446744073709552e19`,
+			wantErr: false,
+		},
+		{
+			exp:     `(convert 18446744073709551616 <float>)`,
+			want:    `1.8446744073709552e19`,
+			wantErr: false,
+		},
+		{
+			exp:     `(convert 1e20 <integer>)`,
+			want:    `100000000000000000000`,
+			wantErr: false,
+		},
+		{
+			exp:     `(convert 18446744073709551616 <string>)`,
+			want:    `"18446744073709551616"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(convert 18446744073709551616 <character>)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+}
@@ -17,6 +17,9 @@ func While(e core.Environment, testForm core.Instance, bodyForm ...core.Instance
 		return nil, err
 	}
 	for core.DeepEqual(test, T) {
+		if _, err := core.CheckContext(e); err != nil {
+			return nil, err
+		}
 		_, err := Progn(e, bodyForm...)
 		if err != nil {
 			return nil, err
@@ -86,6 +89,9 @@ func For(e core.Environment, iterationSpecs, endTestAndResults core.Instance, fo
 		return nil, err
 	}
 	for core.DeepEqual(test, Nil) {
+		if _, err := core.CheckContext(e); err != nil {
+			return nil, err
+		}
 		_, err := Progn(a, forms...)
 		if err != nil {
 			return nil, err
@@ -4,7 +4,13 @@
 
 package lib
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/islisp-dev/iris/core"
+)
 
 func TestWhile(t *testing.T) {
 	execTests(t, While, []test{
@@ -46,3 +52,37 @@ func TestFor(t *testing.T) {
 		},
 	})
 }
+
+func TestCancellation(t *testing.T) {
+	tests := []struct {
+		exp     string
+		cleanup bool
+	}{
+		{exp: `(while t)`},
+		{exp: `(for ((i 0 (+ i 1))) (nil))`},
+		{exp: `(tagbody loop (go loop))`},
+		{exp: `(labels ((f () (f))) (f))`},
+		{exp: `(labels ((f () (+ 1 (f)))) (f))`},
+		{exp: `(unwind-protect (while t) (setq cleaned t))`, cleanup: true},
+		{exp: `(with-handler (lambda (c) (while t)) (while t))`},
+		{exp: `(with-handler (lambda (c) (labels ((f () (f))) (f))) (while t))`},
+		{exp: `(with-handler (lambda (c) (setq cleaned t) (signal-condition c nil)) (while t))`, cleanup: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			e := NewRuntime()
+			e.Variable.Define(core.NewSymbol("CLEANED"), Nil)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			e.Context = ctx
+			obj, _ := readFromString(tt.exp)
+			_, err := Eval(e, obj)
+			if err == nil || !core.InstanceOf(core.CancelledClass, err) {
+				t.Fatalf("%v err = %v, want <cancelled>", tt.exp, err)
+			}
+			if cleaned, _ := e.Variable.Get(core.NewSymbol("CLEANED")); tt.cleanup && cleaned != T {
+				t.Errorf("%v did not run the cleanup forms", tt.exp)
+			}
+		})
+	}
+}
@@ -22,8 +22,9 @@ func Listp(e core.Environment, obj core.Instance) (core.Instance, core.Instance)
 // shall be signaled if i is not a non-negative integer (error-id.
 // domain-error).initial-element may be any ISLISP object.
 func CreateList(e core.Environment, i core.Instance, initialElement ...core.Instance) (core.Instance, core.Instance) {
-	if ok, _ := Integerp(e, i); core.DeepEqual(ok, Nil) {
-		return SignalCondition(e, core.NewDomainError(e, i, core.IntegerClass), Nil)
+	n, err := convSize(e, i)
+	if err != nil {
+		return nil, err
 	}
 	if len(initialElement) > 1 {
 		return SignalCondition(e, core.NewArityError(e), Nil)
@@ -32,8 +33,11 @@ func CreateList(e core.Environment, i core.Instance, initialElement ...core.Inst
 	if len(initialElement) == 1 {
 		elm = initialElement[0]
 	}
+	if _, err := core.CountAllocation(e, n); err != nil {
+		return nil, err
+	}
 	cons := Nil
-	for j := 0; j < int(i.(core.Integer)); j++ {
+	for j := 0; j < n; j++ {
 		cons = core.NewCons(elm, cons)
 	}
 	return cons, nil
@@ -60,6 +64,9 @@ func Reverse(e core.Environment, list core.Instance) (core.Instance, core.Instan
 	if ok, _ := Listp(e, list); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, list, core.ListClass), Nil)
 	}
+	if _, err := core.CountAllocation(e, list.(core.List).Length()); err != nil {
+		return nil, err
+	}
 	cons := Nil
 	for _, car := range list.(core.List).Slice() {
 		cons = core.NewCons(car, cons)
@@ -77,6 +84,9 @@ func Nreverse(e core.Environment, list core.Instance) (core.Instance, core.Insta
 	if ok, _ := Listp(e, list); core.DeepEqual(ok, Nil) {
 		return SignalCondition(e, core.NewDomainError(e, list, core.ListClass), Nil)
 	}
+	if _, err := core.CountAllocation(e, list.(core.List).Length()); err != nil {
+		return nil, err
+	}
 	cons := Nil
 	for _, car := range list.(core.List).Slice() {
 		cons = core.NewCons(car, cons)
@@ -98,10 +108,15 @@ func Append(e core.Environment, lists ...core.Instance) (core.Instance, core.Ins
 		return nil, err
 	}
 	cdr := result
+	n := 0
 	for _, list := range lists {
 		if ok, _ := Listp(e, list); core.DeepEqual(ok, Nil) {
 			return SignalCondition(e, core.NewDomainError(e, list, core.ListClass), Nil)
 		}
+		n += list.(core.List).Length()
+	}
+	if _, err := core.CountAllocation(e, n); err != nil {
+		return nil, err
 	}
 	for _, list := range lists {
 		for _, elt := range list.(core.List).Slice() {
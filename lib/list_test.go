@@ -78,6 +78,11 @@ func TestCreateList(t *testing.T) {
 			want:    `'(#\a #\a)`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-list 100000000000000000000000)))`,
+			want:    `(class <storage-exhausted>)`,
+			wantErr: false,
+		},
 	})
 }
 
@@ -0,0 +1,156 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+// loadFile is the dynamic variable holding the path of the file being loaded.
+var loadFile = core.NewSymbol("IRIS.LOAD-FILE")
+
+// Load evaluates the forms of the file filename in order at the top level of
+// the current runtime and returns t. A relative filename is searched in the
+// directory of the file being loaded, or in the current directory, and then
+// in the directories listed in the environment variable IRIS_PATH. The
+// extension ".lsp" may be omitted. An error signaled by a form is reported
+// with a frame locating that form in the file.
+func Load(e core.Environment, filename core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.StringClass, filename); err != nil {
+		return nil, err
+	}
+	path, ok := findFile(e, string(filename.(core.String)))
+	if !ok {
+		return signalFileError(e, "Cannot find the file ~S", filename)
+	}
+	if _, err := LoadFile(e, path); err != nil {
+		return nil, err
+	}
+	return T, nil
+}
+
+// LoadFile evaluates the forms of the file at path like Load, and returns the
+// value of the last one. The current module and suite of tests are restored
+// once the file is loaded. The forms are added to the coverage of the runtime
+// as they are read, if it has one.
+func LoadFile(e core.Environment, path string) (core.Instance, core.Instance) {
+	file, err := os.Open(path)
+	if err != nil {
+		return signalFileError(e, "Cannot open the file ~S", core.NewString([]rune(path)))
+	}
+	defer file.Close()
+	defer e.Runtime.SetCurrentModule(e.Runtime.CurrentModule())
+	defer e.Runtime.SetCurrentSuite(e.Runtime.CurrentSuite())
+	e = e.NewDynamic()
+	e.DynamicVariable.Define(loadFile, core.NewString([]rune(path)))
+	stream := core.NewStream(file, nil, core.CharacterClass)
+	var ret core.Instance = Nil
+	for {
+		form, err := Read(e.NewHandler(core.SilentHandler), stream)
+		if err != nil {
+			if core.InstanceOf(core.EndOfStreamClass, err) {
+				return ret, nil
+			}
+			return nil, err
+		}
+		if c := e.Runtime.Coverage(); c != nil {
+			addCoverage(e, c, form)
+		}
+		if ret, err = Eval(e, form); err != nil {
+			return nil, pushFormFrame(err, form)
+		}
+	}
+}
+
+// pushFormFrame adds the frame of the top level form to the stack trace of
+// err unless it is the outermost frame already.
+func pushFormFrame(err, form core.Instance) core.Instance {
+	frames := core.Stacktrace(err)
+	loc := core.LocationOf(form)
+	if n := len(frames); n > 0 && loc != nil && frames[n-1].Location != nil && *frames[n-1].Location == *loc {
+		return err
+	}
+	return core.PushCallFrame(err, form)
+}
+
+// findFile returns the path of the file name searched like Load.
+func findFile(e core.Environment, name string) (string, bool) {
+	dirs := []string{""}
+	if !filepath.IsAbs(name) {
+		dirs[0] = "."
+		if loading, ok := e.DynamicVariable.Get(loadFile); ok {
+			dirs[0] = filepath.Dir(string(loading.(core.String)))
+		}
+		for _, dir := range filepath.SplitList(os.Getenv("IRIS_PATH")) {
+			if dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	names := []string{name}
+	if filepath.Ext(name) == "" {
+		names = append(names, name+".lsp")
+	}
+	for _, dir := range dirs {
+		for _, name := range names {
+			path := name
+			if dir != "" {
+				path = filepath.Join(dir, name)
+			}
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+func signalFileError(e core.Environment, format string, filename core.Instance) (core.Instance, core.Instance) {
+	arguments, err := List(e, filename)
+	if err != nil {
+		return nil, err
+	}
+	return SignalCondition(e, core.NewSimpleError(e, core.NewString([]rune(format)), arguments), Nil)
+}
+
+// Provide records that the module name, a symbol or a string, has been
+// loaded, and returns name.
+func Provide(e core.Environment, name core.Instance) (core.Instance, core.Instance) {
+	module, err := nameOf(e, name)
+	if err != nil {
+		return nil, err
+	}
+	e.Runtime.Provide(module)
+	return name, nil
+}
+
+// Require loads the module name unless it has been provided, and returns t
+// if it loads it or nil otherwise. The module is loaded from filename, or
+// from the file named by name in lower case, and is provided once loaded.
+func Require(e core.Environment, name core.Instance, filename ...core.Instance) (core.Instance, core.Instance) {
+	module, err := nameOf(e, name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Runtime.Provided(module) {
+		return Nil, nil
+	}
+	if len(filename) > 1 {
+		return SignalCondition(e, core.NewArityError(e), Nil)
+	}
+	var file core.Instance = core.NewString([]rune(strings.ToLower(module)))
+	if len(filename) == 1 {
+		file = filename[0]
+	}
+	if _, err := Load(e, file); err != nil {
+		return nil, err
+	}
+	e.Runtime.Provide(module)
+	return T, nil
+}
@@ -0,0 +1,123 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lsp":       `(load "sub/a") (defglobal load-main (+ load-a 1))`,
+		"sub/a.lsp":      `(load "b.lsp") (defglobal load-a (+ load-b 1))`,
+		"sub/b.lsp":      `(defglobal load-b 1)`,
+		"path/count.lsp": `(defglobal load-count (+ (dynamic load-counter) 1)) (provide 'count)`,
+	})
+	defer os.RemoveAll(dir)
+	path := os.Getenv("IRIS_PATH")
+	defer os.Setenv("IRIS_PATH", path)
+	os.Setenv("IRIS_PATH", filepath.Join(dir, "path"))
+	execTests(t, Load, []test{
+		{
+			exp:     `(load ` + strconv.Quote(filepath.Join(dir, "main.lsp")) + `)`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(list load-main load-a load-b)`,
+			want:    `'(3 2 1)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(load "sub/b.lsp")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(defdynamic load-counter 0)`,
+			want:    `'load-counter`,
+			wantErr: false,
+		},
+		{
+			exp:     `(require 'count)`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(dynamic-let ((load-counter 10)) (require "count"))`,
+			want:    `nil`,
+			wantErr: false,
+		},
+		{
+			exp:     `load-count`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(require 'load-nothing)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(provide 'load-nothing)`,
+			want:    `'load-nothing`,
+			wantErr: false,
+		},
+		{
+			exp:     `(require 'load-nothing)`,
+			want:    `nil`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestLoadError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"bad.lsp": "(defglobal load-ok 1)\n(let ((x 1))\n  (car x))\n",
+	})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bad.lsp")
+	_, err := LoadFile(NewRuntime(), path)
+	if err == nil {
+		t.Fatal("LoadFile() err = nil")
+	}
+	got := []string{}
+	for _, frame := range core.Stacktrace(err) {
+		got = append(got, fmt.Sprint(frame))
+	}
+	want := []string{
+		"CAR (" + path + ":3:3)",
+		"LET (" + path + ":2:1)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("frames = %v, want %v", got, want)
+	}
+}
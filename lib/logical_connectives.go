@@ -20,11 +20,11 @@ func Not(e core.Environment, obj core.Instance) (core.Instance, core.Instance) {
 // one of them evaluates to nil, then nil is returned from the and; otherwise,
 // the value of the last evaluated form is returned.
 func And(e core.Environment, forms ...core.Instance) (core.Instance, core.Instance) {
-	var ret core.Instance
-	for _, form := range forms {
-		//fmt.Printf("%v\n%#v\n", form, e.Variable)
-		var err core.Instance
-		ret, err = Eval(e, form)
+	if len(forms) == 0 {
+		return T, nil
+	}
+	for _, form := range forms[:len(forms)-1] {
+		ret, err := Eval(e, form)
 		if err != nil {
 			return nil, err
 		}
@@ -32,10 +32,7 @@ func And(e core.Environment, forms ...core.Instance) (core.Instance, core.Instan
 			return Nil, nil
 		}
 	}
-	if len(forms) == 0 {
-		return T, nil
-	}
-	return ret, nil
+	return evalTail(e, forms[len(forms)-1])
 }
 
 // Or is the sequential logical "or" (or "∨"). forms are evaluated from left to
@@ -43,10 +40,11 @@ func And(e core.Environment, forms ...core.Instance) (core.Instance, core.Instan
 // left. If one of them evaluates to a non-nil value, then this non-nil value is
 // returned, otherwise nil is returned.
 func Or(e core.Environment, forms ...core.Instance) (core.Instance, core.Instance) {
-	var ret core.Instance
-	for _, form := range forms {
-		var err core.Instance
-		ret, err = Eval(e, form)
+	if len(forms) == 0 {
+		return Nil, nil
+	}
+	for _, form := range forms[:len(forms)-1] {
+		ret, err := Eval(e, form)
 		if err != nil {
 			return nil, err
 		}
@@ -54,5 +52,5 @@ func Or(e core.Environment, forms ...core.Instance) (core.Instance, core.Instanc
 			return ret, nil
 		}
 	}
-	return Nil, nil
+	return evalTail(e, forms[len(forms)-1])
 }
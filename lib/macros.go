@@ -19,7 +19,7 @@ func Defmacro(e core.Environment, macroName, lambdaList core.Instance, forms ...
 	if err != nil {
 		return nil, err
 	}
-	e.Macro[:1].Define(macroName, ret)
+	e.Macro[:1].Define(macroName, ret.(core.Function).Macro())
 	return macroName, nil
 }
 
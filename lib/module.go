@@ -0,0 +1,147 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+// Defmodule defines the module name, or extends it if it exists, and makes
+// it the current module, in which the reader qualifies the symbols by name.
+// Each option is one of:
+//
+//	(:export name*)         exports the names of the module
+//	(:import module item*)  imports the names exported by module, or only
+//	                        the items: a name, or (name local-name) to read
+//	                        the name as local-name
+//	(:shadow name*)         qualifies the names even if they name builtins
+//
+// Only the names of the symbols given are used, whichever module they were
+// read in.
+func Defmodule(e core.Environment, name core.Instance, options ...core.Instance) (core.Instance, core.Instance) {
+	moduleName, err := nameOf(e, name)
+	if err != nil {
+		return nil, err
+	}
+	if moduleName == core.UserModule {
+		return SignalCondition(e, core.NewDomainError(e, name, core.SymbolClass), Nil)
+	}
+	m := e.Runtime.DefineModule(moduleName)
+	for _, option := range options {
+		list, ok := option.(*core.Cons)
+		if !ok {
+			return SignalCondition(e, core.NewDomainError(e, option, core.ConsClass), Nil)
+		}
+		items := list.Cdr.(core.List).Slice()
+		switch list.Car.String() {
+		case ":EXPORT":
+			for _, item := range items {
+				itemName, err := nameOf(e, item)
+				if err != nil {
+					return nil, err
+				}
+				m.Export(itemName)
+			}
+		case ":SHADOW":
+			for _, item := range items {
+				itemName, err := nameOf(e, item)
+				if err != nil {
+					return nil, err
+				}
+				m.Shadow(itemName)
+			}
+		case ":IMPORT":
+			if err := importNames(e, m, items); err != nil {
+				return nil, err
+			}
+		default:
+			return SignalCondition(e, core.NewDomainError(e, list.Car, core.SymbolClass), Nil)
+		}
+	}
+	e.Runtime.SetCurrentModule(m)
+	return core.NewSymbol(m.Name), nil
+}
+
+// importNames imports the names exported by the module named items[0] into
+// m, as the option :import of Defmodule.
+func importNames(e core.Environment, m *core.Module, items []core.Instance) core.Instance {
+	if len(items) == 0 {
+		_, err := SignalCondition(e, core.NewArityError(e), Nil)
+		return err
+	}
+	moduleName, err := nameOf(e, items[0])
+	if err != nil {
+		return err
+	}
+	from, ok := e.Runtime.Module(moduleName)
+	if !ok {
+		_, err := SignalCondition(e, core.NewUndefinedModule(e, items[0]), Nil)
+		return err
+	}
+	if len(items) == 1 {
+		for _, name := range from.ExportedNames() {
+			m.Import(name, from.Symbol(name))
+		}
+		return nil
+	}
+	for _, item := range items[1:] {
+		name, local := item, item
+		if list, ok := item.(*core.Cons); ok && list.Length() == 2 {
+			name, local = list.Nth(0), list.Nth(1)
+		}
+		exported, err := nameOf(e, name)
+		if err != nil {
+			return err
+		}
+		localName, err := nameOf(e, local)
+		if err != nil {
+			return err
+		}
+		if !from.Exports(exported) {
+			arguments, err := List(e, core.NewString([]rune(exported)), core.NewString([]rune(moduleName)))
+			if err != nil {
+				return err
+			}
+			_, err = SignalCondition(e, core.NewSimpleError(e, core.NewString([]rune("The symbol ~A is not exported from the module ~A")), arguments), Nil)
+			return err
+		}
+		m.Import(localName, from.Symbol(exported))
+	}
+	return nil
+}
+
+// InModule makes the module name current. The module USER is the one the
+// reader starts in, whose symbols are not qualified.
+func InModule(e core.Environment, name core.Instance) (core.Instance, core.Instance) {
+	moduleName, err := nameOf(e, name)
+	if err != nil {
+		return nil, err
+	}
+	if moduleName == core.UserModule {
+		e.Runtime.SetCurrentModule(nil)
+		return core.NewSymbol(core.UserModule), nil
+	}
+	m, ok := e.Runtime.Module(moduleName)
+	if !ok {
+		return SignalCondition(e, core.NewUndefinedModule(e, name), Nil)
+	}
+	e.Runtime.SetCurrentModule(m)
+	return core.NewSymbol(m.Name), nil
+}
+
+// nameOf returns the name of a symbol without its module, or a string in
+// upper case.
+func nameOf(e core.Environment, name core.Instance) (string, core.Instance) {
+	switch n := name.(type) {
+	case core.Symbol:
+		return core.BaseName(n), nil
+	case core.String:
+		return strings.ToUpper(string(n)), nil
+	}
+	_, err := SignalCondition(e, core.NewDomainError(e, name, core.SymbolClass), Nil)
+	return "", err
+}
@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestModule(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"geometry.lsp": `
+(defmodule geometry (:export area parse))
+(defun parse (x) (list "geometry" x))
+(defun area (r) (* r r))
+(defun helper () "helper")
+`,
+		"text.lsp": `
+(defmodule text (:export parse) (:import geometry (parse geometry-parse) area))
+(defun parse (x) (list "text" x))
+(defun results () (list (parse 1) (geometry-parse 2) (area 3)))
+(in-module user)
+(defglobal module-text (text::results))
+`,
+		"user.lsp": `
+(defglobal module-user (list (geometry:parse 1) (text:parse 2) (geometry::helper)))
+`,
+		"private.lsp": `(geometry:helper)`,
+		"unknown.lsp": `(nothing:parse 1)`,
+		"import.lsp":  `(defmodule other (:import geometry helper))`,
+	})
+	defer os.RemoveAll(dir)
+	load := func(name string) string {
+		return `(load ` + strconv.Quote(filepath.Join(dir, name)) + `)`
+	}
+	execTests(t, Defmodule, []test{
+		{
+			exp:     load("geometry.lsp"),
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     load("text.lsp"),
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `module-text`,
+			want:    `'(("text" 1) ("geometry" 2) 9)`,
+			wantErr: false,
+		},
+		{
+			exp:     load("user.lsp"),
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `module-user`,
+			want:    `'(("geometry" 1) ("text" 2) "helper")`,
+			wantErr: false,
+		},
+		{
+			exp:     `(parse 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     load("private.lsp"),
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     load("unknown.lsp"),
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     load("import.lsp"),
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(in-module nothing)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(defmodule user)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+}
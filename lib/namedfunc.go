@@ -60,6 +60,6 @@ func newNamedFunction(e core.Environment, functionName, lambdaList core.Instance
 				return SignalCondition(e, core.NewImmutableBinding(e), Nil)
 			}
 		}
-		return Progn(e, forms...)
+		return prognTail(e, forms...)
 	}), nil
 }
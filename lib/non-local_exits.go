@@ -34,7 +34,7 @@ is immediately considered invalid.
 */
 
 func Block(e core.Environment, tag core.Instance, body ...core.Instance) (core.Instance, core.Instance) {
-	uid := core.NewInteger(uniqueInt())
+	uid := core.NewInteger(uniqueInt(e))
 	if core.InstanceOf(core.NumberClass, tag) || core.InstanceOf(core.CharacterClass, tag) {
 		return SignalCondition(e, core.NewDomainError(e, tag, core.ObjectClass), Nil)
 	}
@@ -81,7 +81,7 @@ func ReturnFrom(e core.Environment, tag, object core.Instance) (core.Instance, c
 func Catch(e core.Environment, tag core.Instance, body ...core.Instance) (core.Instance, core.Instance) {
 	var err core.Instance
 	tag, err = Eval(e, tag)
-	uid := core.NewInteger(uniqueInt())
+	uid := core.NewInteger(uniqueInt(e))
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +135,7 @@ func Throw(e core.Environment, tag, object core.Instance) (core.Instance, core.I
 }
 
 func Tagbody(e core.Environment, body ...core.Instance) (core.Instance, core.Instance) {
-	uid := core.NewInteger(uniqueInt())
+	uid := core.NewInteger(uniqueInt(e))
 	for _, cadr := range body {
 		if !core.InstanceOf(core.ConsClass, cadr) {
 			if !e.TagbodyTag.Define(cadr, uid) { // ref cddr
@@ -143,37 +143,31 @@ func Tagbody(e core.Environment, body ...core.Instance) (core.Instance, core.Ins
 			}
 		}
 	}
-	for idx, cadr := range body {
-		if core.InstanceOf(core.ConsClass, cadr) {
-			_, fail := Eval(e, cadr)
-			if fail != nil {
-			TAG:
-				if core.InstanceOf(core.TagbodyTagClass, fail) {
-					tag1, _ := fail.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.TAG"), core.EscapeClass) // Checked at the top of// This loop
-					uid1, _ := fail.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.UID"), core.EscapeClass) // Checked at the top of// This loop
-					found := false
-					for _, tag := range body {
-						if core.DeepEqual(tag, tag1) && core.DeepEqual(uid, uid1) {
-							found = true
-							break
-						}
-					}
-					if found {
-						for _, form := range body[idx+1:] {
-							if core.InstanceOf(core.ConsClass, form) {
-								_, fail = Eval(e, form)
-								if fail != nil {
-									goto TAG
-								}
-							}
-						}
-						break
-					}
-
-				}
-				return nil, fail
+	for idx := 0; idx < len(body); idx++ {
+		if !core.InstanceOf(core.ConsClass, body[idx]) {
+			continue
+		}
+		_, fail := Eval(e, body[idx])
+		if fail == nil {
+			continue
+		}
+		if !core.InstanceOf(core.TagbodyTagClass, fail) {
+			return nil, fail
+		}
+		tag1, _ := fail.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.TAG"), core.EscapeClass) // Checked at the top of this loop
+		uid1, _ := fail.(core.BasicInstance).GetSlotValue(core.NewSymbol("IRIS.UID"), core.EscapeClass) // Checked at the top of this loop
+		if !core.DeepEqual(uid, uid1) {
+			return nil, fail
+		}
+		for i, tag := range body {
+			if !core.InstanceOf(core.ConsClass, tag) && core.DeepEqual(tag, tag1) {
+				idx = i // evaluation continues from the form following the tag
+				break
 			}
 		}
+		if _, err := core.CheckContext(e); err != nil {
+			return nil, err
+		}
 	}
 	return Nil, nil
 }
@@ -207,7 +201,11 @@ func Go(e core.Environment, tag core.Instance) (core.Instance, core.Instance) {
 // necessary and would respect these cleanup-forms.
 func UnwindProtect(e core.Environment, form core.Instance, cleanupForms ...core.Instance) (core.Instance, core.Instance) {
 	ret1, err1 := Eval(e, form)
-	ret2, err2 := Progn(e, cleanupForms...)
+	cleanup := e
+	if e.Context != nil && e.Context.Err() != nil {
+		cleanup.Context = nil // The cleanup forms run even after cancellation
+	}
+	ret2, err2 := Progn(cleanup, cleanupForms...)
 	if err2 != nil {
 		if core.InstanceOf(core.EscapeClass, err2) {
 			return SignalCondition(e, core.NewControlError(e), Nil)
@@ -113,6 +113,16 @@ func TestCatch(t *testing.T) {
 			want:    `0`,
 			wantErr: false,
 		},
+		{
+			exp:     `(let ((f (lambda () (throw 'block-sum 1)))) (catch 'block-sum (funcall f)))`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(funcall (catch 'block-sum (lambda () (throw 'block-sum 1))))`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	}
 	execTests(t, Catch, tests)
 }
@@ -205,3 +215,35 @@ func TestUnwindProtect(t *testing.T) {
 	}
 	execTests(t, UnwindProtect, tests)
 }
+
+func TestTagbody(t *testing.T) {
+	tests := []test{
+		{
+			exp: `
+				(let ((i 0))
+				  (tagbody
+				   loop (setq i (+ i 1))
+				        (if (< i 5) (go loop)))
+				  i)
+			`,
+			want:    `5`,
+			wantErr: false,
+		},
+		{
+			exp: `
+				(let ((x '()))
+				  (tagbody
+				        (go b)
+				   a    (setq x (cons 'a x))
+				        (go c)
+				   b    (setq x (cons 'b x))
+				        (go a)
+				   c)
+				  x)
+			`,
+			want:    `'(a b)`,
+			wantErr: false,
+		},
+	}
+	execTests(t, Tagbody, tests)
+}
@@ -6,12 +6,20 @@ package lib
 
 import (
 	"math"
+	"math/big"
+	"strconv"
 
 	"github.com/islisp-dev/iris/reader/parser"
 	"github.com/islisp-dev/iris/reader/tokenizer"
 	"github.com/islisp-dev/iris/core"
 )
 
+// maxInt and minInt are the bounds of the fixnums, which are Go ints.
+const (
+	maxInt = 1<<(strconv.IntSize-1) - 1
+	minInt = -1 << (strconv.IntSize - 1)
+)
+
 // Numberp returns t if obj is a number (instance of class number); otherwise,
 // returns nil. The obj may be any ISLISP object.
 func Numberp(e core.Environment, obj core.Instance) (core.Instance, core.Instance) {
@@ -30,7 +38,8 @@ func ParseNumber(e core.Environment, str core.Instance) (core.Instance, core.Ins
 	if err := ensure(e, core.StringClass, str); err != nil {
 		return nil, err
 	}
-	ret, err := parser.ParseAtom(e, tokenizer.NewToken(string(str.(core.String)), -1, -1))
+	// The handlers must not see the condition signaled by the parser.
+	ret, err := parser.ParseAtom(e.NewHandler(core.SilentHandler), tokenizer.NewToken(string(str.(core.String)), -1, -1))
 	if err != nil || !core.InstanceOf(core.NumberClass, ret) {
 		return SignalCondition(e, core.NewParseError(e, str, core.NumberClass), Nil)
 	}
@@ -43,21 +52,11 @@ func ParseNumber(e core.Environment, str core.Instance) (core.Instance, core.Ins
 // compares only the mathematical values of its arguments, whereas eql also
 // compares the representations
 func NumberEqual(e core.Environment, x1, x2 core.Instance) (core.Instance, core.Instance) {
-	if err := ensure(e, core.NumberClass, x1, x2); err != nil {
+	c, err := compare(e, x1, x2)
+	if err != nil {
 		return nil, err
 	}
-	ret := false
-	switch {
-	case core.InstanceOf(core.IntegerClass, x1) && core.InstanceOf(core.IntegerClass, x2):
-		ret = core.DeepEqual(x1, x2)
-	case core.InstanceOf(core.IntegerClass, x1) && core.InstanceOf(core.FloatClass, x2):
-		ret = core.DeepEqual(float64(x1.(core.Integer)), float64(x2.(core.Float)))
-	case core.InstanceOf(core.FloatClass, x1) && core.InstanceOf(core.IntegerClass, x2):
-		ret = float64(x1.(core.Float)) == float64(x2.(core.Integer))
-	case core.InstanceOf(core.FloatClass, x1) && core.InstanceOf(core.FloatClass, x2):
-		ret = core.DeepEqual(x1, x2)
-	}
-	if ret {
+	if c == 0 {
 		return T, nil
 	}
 	return Nil, nil
@@ -76,21 +75,11 @@ func NumberNotEqual(e core.Environment, x1, x2 core.Instance) (core.Instance, co
 
 // NumberGreaterThan returns t if x1 is greater than x2
 func NumberGreaterThan(e core.Environment, x1, x2 core.Instance) (core.Instance, core.Instance) {
-	if err := ensure(e, core.NumberClass, x1, x2); err != nil {
+	c, err := compare(e, x1, x2)
+	if err != nil {
 		return nil, err
 	}
-	ret := false
-	switch {
-	case core.InstanceOf(core.IntegerClass, x1) && core.InstanceOf(core.IntegerClass, x2):
-		ret = float64(x1.(core.Integer)) > float64(x2.(core.Integer))
-	case core.InstanceOf(core.IntegerClass, x1) && core.InstanceOf(core.FloatClass, x2):
-		ret = float64(x1.(core.Integer)) > float64(x2.(core.Float))
-	case core.InstanceOf(core.FloatClass, x1) && core.InstanceOf(core.IntegerClass, x2):
-		ret = float64(x1.(core.Float)) > float64(x2.(core.Integer))
-	case core.InstanceOf(core.FloatClass, x1) && core.InstanceOf(core.FloatClass, x2):
-		ret = float64(x1.(core.Float)) > float64(x2.(core.Float))
-	}
-	if ret {
+	if c > 0 {
 		return T, nil
 	}
 	return Nil, nil
@@ -135,20 +124,15 @@ func NumberLessThanOrEqual(e core.Environment, x1, x2 core.Instance) (core.Insta
 // a ﬂoat. When given no arguments, + returns 0. An error shall be signaled if
 // any x is not a number (error-id. domain-error).
 func Add(e core.Environment, x ...core.Instance) (core.Instance, core.Instance) {
-	flt := false
-	sum := 0.0
+	sum := core.NewInteger(0)
 	for _, a := range x {
-		f, b, err := convFloat64(e, a)
+		var err core.Instance
+		sum, err = arithmetic(e, sum, a, addInt, (*big.Int).Add, func(a, b float64) float64 { return a + b })
 		if err != nil {
 			return nil, err
 		}
-		flt = flt || b
-		sum += f
-	}
-	if flt {
-		return core.NewFloat(sum), nil
 	}
-	return core.NewInteger(int(sum)), nil
+	return sum, nil
 }
 
 // Multiply returns the product, respectively, of their arguments. If all
@@ -156,20 +140,15 @@ func Add(e core.Environment, x ...core.Instance) (core.Instance, core.Instance)
 // the result is a ﬂoat. When given no arguments, Multiply returns 1. An error
 // shall be signaled if any x is not a number (error-id. domain-error).
 func Multiply(e core.Environment, x ...core.Instance) (core.Instance, core.Instance) {
-	flt := false
-	pdt := 1.0
+	pdt := core.NewInteger(1)
 	for _, a := range x {
-		f, b, err := convFloat64(e, a)
+		var err core.Instance
+		pdt, err = arithmetic(e, pdt, a, mulInt, (*big.Int).Mul, func(a, b float64) float64 { return a * b })
 		if err != nil {
 			return nil, err
 		}
-		pdt *= f
-		flt = flt || b
 	}
-	if flt {
-		return core.NewFloat(pdt), nil
-	}
-	return core.NewInteger(int(pdt)), nil
+	return pdt, nil
 }
 
 // Substruct returns its additive inverse. An error shall be signaled if x is
@@ -183,22 +162,18 @@ func Substruct(e core.Environment, x core.Instance, xs ...core.Instance) (core.I
 		ret, err := Substruct(e, core.NewInteger(0), x)
 		return ret, err
 	}
-	sub, flt, err := convFloat64(e, x)
-	if err != nil {
+	if _, _, err := convFloat64(e, x); err != nil {
 		return nil, err
 	}
+	sub := x
 	for _, a := range xs {
-		f, b, err := convFloat64(e, a)
+		var err core.Instance
+		sub, err = arithmetic(e, sub, a, subInt, (*big.Int).Sub, func(a, b float64) float64 { return a - b })
 		if err != nil {
 			return nil, err
 		}
-		sub -= f
-		flt = flt || b
 	}
-	if flt {
-		return core.NewFloat(sub), nil
-	}
-	return core.NewInteger(int(sub)), nil
+	return sub, nil
 }
 
 // Quotient returns the quotient of those numbers. The result is an integer if
@@ -212,12 +187,12 @@ func Substruct(e core.Environment, x core.Instance, xs ...core.Instance) (core.I
 // error shall be signaled if any divisor is zero (error-id. division-by-zero).
 func Quotient(e core.Environment, dividend, divisor1 core.Instance, divisor ...core.Instance) (core.Instance, core.Instance) {
 	divisor = append([]core.Instance{divisor1}, divisor...)
-	quotient, flt, err := convFloat64(e, dividend)
-	if err != nil {
+	if _, _, err := convFloat64(e, dividend); err != nil {
 		return nil, err
 	}
+	quotient := dividend
 	for _, a := range divisor {
-		f, b, err := convFloat64(e, a)
+		f, _, err := convFloat64(e, a)
 		if err != nil {
 			return nil, err
 		}
@@ -226,17 +201,33 @@ func Quotient(e core.Environment, dividend, divisor1 core.Instance, divisor ...c
 			for i := len(divisor) - 1; i >= 0; i-- {
 				arguments = core.NewCons(divisor[i], arguments)
 			}
-			return SignalCondition(e, core.NewArithmeticError(e, core.NewSymbol("QUOTIENT"), arguments), Nil)
+			arguments = core.NewCons(dividend, arguments)
+			return SignalCondition(e, core.NewDivisionByZero(e, core.NewSymbol("QUOTIENT"), arguments), Nil)
 		}
-		if !flt && !b && int(quotient)%int(f) != 0 {
-			flt = true
-		}
-		quotient /= f
-	}
-	if flt {
-		return core.NewFloat(quotient), nil
+		quotient = quo(e, quotient, a)
 	}
-	return core.NewInteger(int(quotient)), nil
+	return quotient, nil
+}
+
+// quo divides the non-zero number y into x. The result is an integer if both
+// are integers and y evenly divides x, otherwise it is a float.
+func quo(e core.Environment, x, y core.Instance) core.Instance {
+	a, aok := x.(core.Integer)
+	b, bok := y.(core.Integer)
+	if aok && bok && a%b == 0 && !(b == -1 && a == minInt) {
+		return core.NewInteger(int(a / b))
+	}
+	f, af, _ := convFloat64(e, x)
+	g, bf, _ := convFloat64(e, y)
+	if af || bf {
+		return core.NewFloat(f / g)
+	}
+	q, r := new(big.Int).QuoRem(bigInt(x), bigInt(y), new(big.Int))
+	if r.Sign() == 0 {
+		return core.NewBigInteger(q)
+	}
+	f, _ = new(big.Rat).SetFrac(bigInt(x), bigInt(y)).Float64()
+	return core.NewFloat(f)
 }
 
 // Reciprocal returns the reciprocal of its argument x ; that is, 1/x . An error
@@ -249,6 +240,9 @@ func Reciprocal(e core.Environment, x core.Instance) (core.Instance, core.Instan
 // comparison is done by >. An error shall be signaled if any x is not a number
 // (error-id. domain-error).
 func Max(e core.Environment, x core.Instance, xs ...core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.NumberClass, x); err != nil {
+		return nil, err
+	}
 	max := x
 	for _, y := range xs {
 		ret, err := NumberGreaterThan(e, y, max)
@@ -266,6 +260,9 @@ func Max(e core.Environment, x core.Instance, xs ...core.Instance) (core.Instanc
 // comparison is done by <. An error shall be signaled if any x is not a number
 // (error-id. domain-error).
 func Min(e core.Environment, x core.Instance, xs ...core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.NumberClass, x); err != nil {
+		return nil, err
+	}
 	min := x
 	for _, y := range xs {
 		ret, err := NumberLessThan(e, y, min)
@@ -330,7 +327,7 @@ func Expt(e core.Environment, x1, x2 core.Instance) (core.Instance, core.Instanc
 		return nil, err
 	}
 	if !af && !bf && b >= 0 {
-		return core.NewInteger(int(math.Pow(a, b))), nil
+		return exptInteger(e, x1, x2)
 	}
 	if (a == 0 && b < 0) || (a == 0 && bf && b == 0) || (a < 0 && bf) {
 		operation := core.NewSymbol("EXPT")
@@ -343,20 +340,55 @@ func Expt(e core.Environment, x1, x2 core.Instance) (core.Instance, core.Instanc
 	return core.NewFloat(math.Pow(a, b)), nil
 }
 
-// Sqrt returns the non-negative square root of x. An error shall be signaled if
-// x is not a non-negative number (error-id. domain-error).
+// exptInteger raises the integer x1 to the non-negative integer x2. The result
+// counts against the size quota, and an arithmetic-error is signaled if it
+// cannot be represented at all.
+func exptInteger(e core.Environment, x1, x2 core.Instance) (core.Instance, core.Instance) {
+	if a, ok := x1.(core.Integer); ok {
+		if b, ok := x2.(core.Integer); ok {
+			if c, ok := exptInt(int(a), int(b)); ok {
+				return core.NewInteger(c), nil
+			}
+		}
+	}
+	base, exponent := bigInt(x1), bigInt(x2)
+	if base.CmpAbs(big.NewInt(1)) > 0 {
+		if !exponent.IsInt64() || exponent.Int64() > math.MaxInt32 {
+			operation := core.NewSymbol("EXPT")
+			operands, err := List(e, x1, x2)
+			if err != nil {
+				return nil, err
+			}
+			return SignalCondition(e, core.NewArithmeticError(e, operation, operands), Nil)
+		}
+		if _, err := core.CountAllocation(e, base.BitLen()*int(exponent.Int64())/64); err != nil {
+			return nil, err
+		}
+	}
+	return core.NewBigInteger(new(big.Int).Exp(base, exponent, nil)), nil
+}
+
+// Sqrt returns the non-negative square root of x, an integer if x is an
+// integer whose square root is an integer and a float otherwise. An error
+// shall be signaled if x is not a non-negative number (error-id.
+// domain-error).
 func Sqrt(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
-	a, _, err := convFloat64(e, x)
+	a, af, err := convFloat64(e, x)
 	if err != nil {
 		return nil, err
 	}
 	if a < 0.0 {
 		return SignalCondition(e, core.NewDomainError(e, x, core.NumberClass), Nil)
 	}
-	if math.Ceil(math.Sqrt(a)) == math.Sqrt(a) {
-		return core.NewInteger(int(math.Sqrt(a))), nil
+	if af {
+		return core.NewFloat(math.Sqrt(a)), nil
 	}
-	return core.NewFloat(math.Sqrt(a)), nil
+	r := new(big.Int).Sqrt(bigInt(x))
+	if new(big.Int).Mul(r, r).Cmp(bigInt(x)) == 0 {
+		return core.NewBigInteger(r), nil
+	}
+	f, _ := new(big.Float).Sqrt(new(big.Float).SetInt(bigInt(x))).Float64()
+	return core.NewFloat(f), nil
 }
 
 // Pi is an approximation of π.
@@ -472,3 +504,100 @@ func Atanh(e core.Environment, x core.Instance) (core.Instance, core.Instance) {
 	}
 	return core.NewFloat(math.Atanh(a)), nil
 }
+
+// compare returns -1, 0 or +1 as the number x1 is less than, equal to or
+// greater than the number x2. Integers are compared exactly; if either is a
+// float, both are compared as floats.
+func compare(e core.Environment, x1, x2 core.Instance) (int, core.Instance) {
+	if a, ok := x1.(core.Integer); ok {
+		if b, ok := x2.(core.Integer); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	a, af, err := convFloat64(e, x1)
+	if err != nil {
+		return 0, err
+	}
+	b, bf, err := convFloat64(e, x2)
+	if err != nil {
+		return 0, err
+	}
+	if af || bf {
+		switch {
+		case a < b:
+			return -1, nil
+		case a == b:
+			return 0, nil
+		}
+		return 1, nil
+	}
+	return bigInt(x1).Cmp(bigInt(x2)), nil
+}
+
+// arithmetic applies a binary operation to the numbers x1 and x2. The result
+// is a float computed by float if either is a float. Otherwise it is computed
+// on ints by fixed, which reports whether the result did not overflow, or on
+// big.Ints by bignum if it did or if either already is a bignum.
+func arithmetic(e core.Environment, x1, x2 core.Instance, fixed func(a, b int) (int, bool), bignum func(z, a, b *big.Int) *big.Int, float func(a, b float64) float64) (core.Instance, core.Instance) {
+	if a, ok := x1.(core.Integer); ok {
+		if b, ok := x2.(core.Integer); ok {
+			if c, ok := fixed(int(a), int(b)); ok {
+				return core.NewInteger(c), nil
+			}
+		}
+	}
+	a, af, err := convFloat64(e, x1)
+	if err != nil {
+		return nil, err
+	}
+	b, bf, err := convFloat64(e, x2)
+	if err != nil {
+		return nil, err
+	}
+	if af || bf {
+		return core.NewFloat(float(a, b)), nil
+	}
+	return core.NewBigInteger(bignum(new(big.Int), bigInt(x1), bigInt(x2))), nil
+}
+
+func addInt(a, b int) (int, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func subInt(a, b int) (int, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func mulInt(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	return c, c/b == a && !(a == -1 && b == minInt) && !(b == -1 && a == minInt)
+}
+
+func exptInt(a, b int) (int, bool) {
+	c := 1
+	for ; b > 0; b >>= 1 {
+		var ok bool
+		if b&1 == 1 {
+			if c, ok = mulInt(c, a); !ok {
+				return 0, false
+			}
+		}
+		if b > 1 {
+			if a, ok = mulInt(a, a); !ok {
+				return 0, false
+			}
+		}
+	}
+	return c, true
+}
@@ -0,0 +1,227 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import "testing"
+
+func TestArithmetic(t *testing.T) {
+	execTests(t, Add, []test{
+		{
+			exp:     `(+ 1 2 3)`,
+			want:    `6`,
+			wantErr: false,
+		},
+		{
+			exp:     `(+ 1 2.5)`,
+			want:    `3.5`,
+			wantErr: false,
+		},
+		{
+			exp:     `(+ 9223372036854775807 1)`,
+			want:    `9223372036854775808`,
+			wantErr: false,
+		},
+		{
+			exp:     `(+ 9223372036854775808 -1)`,
+			want:    `9223372036854775807`,
+			wantErr: false,
+		},
+		{
+			exp:     `(integerp (+ 9223372036854775808 -1))`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(- -9223372036854775808 1)`,
+			want:    `-9223372036854775809`,
+			wantErr: false,
+		},
+		{
+			exp:     `(- -9223372036854775808)`,
+			want:    `9223372036854775808`,
+			wantErr: false,
+		},
+		{
+			exp:     `(* 4294967296 4294967296)`,
+			want:    `18446744073709551616`,
+			wantErr: false,
+		},
+		{
+			exp:     `(* 18446744073709551616 0.5)`,
+			want:    `9223372036854775808.0`,
+			wantErr: false,
+		},
+		{
+			exp:     `(quotient 18446744073709551616 4294967296)`,
+			want:    `4294967296`,
+			wantErr: false,
+		},
+		{
+			exp:     `(floatp (quotient 18446744073709551616 3))`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(+ 1 'a)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (quotient 1 0)))`,
+			want:    `(class <division-by-zero>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (quotient 1 2 0)))`,
+			want:    `(class <division-by-zero>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (arithmetic-error-operands c))) (quotient 1 2 0)))`,
+			want:    `'(1 2 0)`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestNumberComparison(t *testing.T) {
+	execTests(t, NumberEqual, []test{
+		{
+			exp:     `(= 18446744073709551616 18446744073709551616)`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(= 18446744073709551616 18446744073709551617)`,
+			want:    `nil`,
+			wantErr: false,
+		},
+		{
+			exp:     `(< 9223372036854775807 9223372036854775808)`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(> -9223372036854775809 -9223372036854775808)`,
+			want:    `nil`,
+			wantErr: false,
+		},
+		{
+			exp:     `(= 18446744073709551616 1.8446744073709552e19)`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(max 1 18446744073709551616 2.0)`,
+			want:    `18446744073709551616`,
+			wantErr: false,
+		},
+		{
+			exp:     `(abs -18446744073709551616)`,
+			want:    `18446744073709551616`,
+			wantErr: false,
+		},
+		{
+			exp:     `(eql 18446744073709551616 18446744073709551616)`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(equal '(18446744073709551616) '(18446744073709551616))`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(eql 18446744073709551616 1.8446744073709552e19)`,
+			want:    `nil`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestExpt(t *testing.T) {
+	execTests(t, Expt, []test{
+		{
+			exp:     `(expt 2 10)`,
+			want:    `1024`,
+			wantErr: false,
+		},
+		{
+			exp:     `(expt 2 100)`,
+			want:    `1267650600228229401496703205376`,
+			wantErr: false,
+		},
+		{
+			exp:     `(expt -3 41)`,
+			want:    `-36472996377170786403`,
+			wantErr: false,
+		},
+		{
+			exp:     `(expt 2 -1)`,
+			want:    `0.5`,
+			wantErr: false,
+		},
+		{
+			exp:     `(expt 2 18446744073709551616)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(expt 1 18446744073709551616)`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(sqrt 1267650600228229401496703205376)`,
+			want:    `1125899906842624`,
+			wantErr: false,
+		},
+		{
+			exp:     `(sqrt 2)`,
+			want:    `1.4142135623730951`,
+			wantErr: false,
+		},
+		{
+			exp:     `(sqrt 4.0)`,
+			want:    `2.0`,
+			wantErr: false,
+		},
+		{
+			exp:     `(sqrt (+ (expt 2 80) 1))`,
+			want:    `1099511627776.0`,
+			wantErr: false,
+		},
+		{
+			exp:     `(sqrt (+ (expt 10 400) 1))`,
+			want:    `1e200`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestParseNumber(t *testing.T) {
+	execTests(t, ParseNumber, []test{
+		{
+			exp:     `(parse-number "123")`,
+			want:    `123`,
+			wantErr: false,
+		},
+		{
+			exp:     `(parse-number "-123456789012345678901234567890")`,
+			want:    `-123456789012345678901234567890`,
+			wantErr: false,
+		},
+		{
+			exp:     `(parse-number "#xFFFFFFFFFFFFFFFFFF")`,
+			want:    `4722366482869645213695`,
+			wantErr: false,
+		},
+		{
+			exp:     `(parse-number "12a")`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+}
@@ -0,0 +1,42 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"os"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+// WithProfiling evaluates filename-form to the name of a file, and then the
+// forms as progn does, recording the number of calls of the functions, generic
+// functions, methods and macro expanders applied and the time spent in them.
+// The profile is written to the file in the format of pprof when the forms
+// are done, even if they exit non-locally. The value of the last form is
+// returned.
+func WithProfiling(e core.Environment, filenameForm core.Instance, forms ...core.Instance) (core.Instance, core.Instance) {
+	filename, err := Eval(e, filenameForm)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensure(e, core.StringClass, filename); err != nil {
+		return nil, err
+	}
+	profile := core.NewProfile()
+	e.Runtime.StartProfile(profile)
+	ret, err := Progn(e, forms...)
+	e.Runtime.StopProfile(profile)
+	file, ferr := os.Create(string(filename.(core.String)))
+	if ferr == nil {
+		ferr = profile.WritePprof(file)
+		if cerr := file.Close(); ferr == nil {
+			ferr = cerr
+		}
+	}
+	if ferr != nil && err == nil {
+		return SignalCondition(e, core.NewStreamError(e, Nil), Nil)
+	}
+	return ret, err
+}
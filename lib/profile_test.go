@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestWithProfiling(t *testing.T) {
+	execTests(t, WithProfiling, []test{
+		{
+			exp: `
+			(progn
+			  (defun profile-fib (n) (if (< n 2) n (+ (profile-fib (- n 1)) (profile-fib (- n 2)))))
+			  (defgeneric profile-area (s))
+			  (defmethod profile-area ((s <integer>)) (* s s))
+			  (defmacro profile-twice (x) ` + "`" + `(+ ,x ,x))
+			  nil)
+			`,
+			want:    `nil`,
+			wantErr: false,
+		},
+		{
+			exp:     `(with-profiling "__profile.pb.gz" (profile-fib 10) (profile-area (profile-twice 2)))`,
+			want:    `16`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'profile (with-profiling "__profile_exit.pb.gz" (throw 'profile (profile-fib 5))))`,
+			want:    `5`,
+			wantErr: false,
+		},
+		{
+			exp:     `(with-profiling 'profile (profile-fib 5))`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+	for _, test := range []struct {
+		file string
+		want []string
+	}{
+		{"__profile.pb.gz", []string{"calls", "time", "nanoseconds", "PROFILE-FIB", "(METHOD PROFILE-AREA (<INTEGER>))", "(MACRO PROFILE-TWICE)"}},
+		{"__profile_exit.pb.gz", []string{"PROFILE-FIB"}},
+	} {
+		file, err := os.Open(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		z, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(z)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range test.want {
+			if !bytes.Contains(data, []byte(want)) {
+				t.Errorf("%s lacks %q", test.file, want)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package lib
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"time"
@@ -14,39 +15,99 @@ import (
 
 var Time time.Time
 
-var TopLevel = core.NewEnvironment(
-	core.NewStream(os.Stdin, nil, core.CharacterClass),
-	core.NewStream(nil, os.Stdout, core.CharacterClass),
-	core.NewStream(nil, os.Stderr, core.CharacterClass),
-	core.DefaultHandler,
-)
+// TopLevel is the runtime used by the command line interpreter. Programs
+// embedding the interpreter should create their own runtimes with NewRuntime.
+var TopLevel core.Environment
+
+// builtins are the definitions made by init, replayed in every new runtime.
+var builtins = []func(e core.Environment){}
+
+// NewRuntime returns a new top level environment with all the builtins
+// defined. Each runtime has its own globals, classes, properties and counters,
+// so definitions made in one runtime are never visible from another.
+func NewRuntime() core.Environment {
+	e := core.NewEnvironment(
+		core.NewStream(os.Stdin, nil, core.CharacterClass),
+		core.NewStream(nil, os.Stdout, core.CharacterClass),
+		core.NewStream(nil, os.Stderr, core.CharacterClass),
+		core.DefaultHandler,
+	)
+	for _, define := range builtins {
+		define(e)
+	}
+	core.MarkBuiltins(e)
+	return e
+}
 
 func defclass(name string, class core.Class) {
 	symbol := core.NewSymbol(name)
-	TopLevel.Class.Define(symbol, class)
+	builtins = append(builtins, func(e core.Environment) {
+		e.Class.Define(symbol, class)
+	})
 }
 
 func defspecial(name string, function interface{}) {
 	symbol := core.NewSymbol(name)
-	TopLevel.Special.Define(symbol, core.NewFunction(func2symbol(function), function))
+	builtins = append(builtins, func(e core.Environment) {
+		e.Special.Define(symbol, core.NewFunction(symbol, function))
+	})
 }
 
 func defun(name string, function interface{}) {
 	symbol := core.NewSymbol(name)
-	TopLevel.Function.Define(symbol, core.NewFunction(symbol, function))
+	builtins = append(builtins, func(e core.Environment) {
+		e.Function.Define(symbol, core.NewFunction(symbol, function))
+	})
 }
 
 func defgeneric(name string, function interface{}) {
 	symbol := core.NewSymbol(name)
-	lambdaList, _ := List(TopLevel, core.NewSymbol("FIRST"), core.NewSymbol("&REST"), core.NewSymbol("REST"))
-	generic := core.NewGenericFunction(symbol, lambdaList, T, core.GenericFunctionClass)
-	generic.(*core.GenericFunction).AddMethod(nil, lambdaList, []core.Class{core.StandardClassClass}, core.NewFunction(symbol, function))
-	TopLevel.Function.Define(symbol, generic)
+	builtins = append(builtins, func(e core.Environment) {
+		lambdaList, _ := List(e, core.NewSymbol("FIRST"), core.NewSymbol("&REST"), core.NewSymbol("REST"))
+		generic := core.NewGenericFunction(symbol, lambdaList, T, core.GenericFunctionClass)
+		generic.(*core.GenericFunction).AddMethod(nil, lambdaList, []core.Class{core.StandardClassClass}, core.NewFunction(symbol, function))
+		e.Function.Define(symbol, generic)
+	})
+}
+
+// defgenericfunction defines a generic function with no methods taking the
+// parameters.
+func defgenericfunction(name string, parameters ...string) {
+	symbol := core.NewSymbol(name)
+	builtins = append(builtins, func(e core.Environment) {
+		lambdaList := lambdaList(e, len(parameters), parameters...)
+		e.Function.Define(symbol, core.NewGenericFunction(symbol, lambdaList, T, core.StandardGenericFunctionClass))
+	})
+}
+
+// defmethod adds a method specialized on classes to the generic function
+// defined by defgenericfunction.
+func defmethod(name string, function interface{}, classes ...core.Class) {
+	symbol := core.NewSymbol(name)
+	builtins = append(builtins, func(e core.Environment) {
+		generic, _ := e.Function.Get(symbol)
+		generic.(*core.GenericFunction).AddMethod(nil, lambdaList(e, len(classes)), classes, core.NewFunction(symbol, function))
+	})
+}
+
+func lambdaList(e core.Environment, n int, parameters ...string) core.Instance {
+	symbols := make([]core.Instance, n)
+	for i := range symbols {
+		if i < len(parameters) {
+			symbols[i] = core.NewSymbol(parameters[i])
+		} else {
+			symbols[i] = core.NewSymbol(fmt.Sprintf("X%d", i))
+		}
+	}
+	list, _ := List(e, symbols...)
+	return list
 }
 
 func defglobal(name string, value core.Instance) {
 	symbol := core.NewSymbol(name)
-	TopLevel.Variable.Define(symbol, value)
+	builtins = append(builtins, func(e core.Environment) {
+		e.Variable.Define(symbol, value)
+	})
 }
 
 func init() {
@@ -70,8 +131,11 @@ func init() {
 	defun("APPLY", Apply)
 	defun("ARRAY-DIMENSIONS", ArrayDimensions)
 	defun("AREF", Aref)
+	defspecial("ASSERT-EQUAL", AssertEqual)
+	defspecial("ASSERT-ERROR", AssertError)
+	defspecial("ASSERT-TRUE", AssertTrue)
 	defun("ASSOC", Assoc)
-	// TODO: defspecial2("ASSURE", Assure)
+	defspecial("ASSURE", Assure)
 	defun("ATAN", Atan)
 	defun("ATAN2", Atan2)
 	defun("ATANH", Atanh)
@@ -120,6 +184,9 @@ func init() {
 	defspecial("DEFMETHOD", Defmethod)
 	defspecial("DEFGLOBAL", Defglobal)
 	defspecial("DEFMACRO", Defmacro)
+	defspecial("DEFMODULE", Defmodule)
+	defspecial("DEFSUITE", Defsuite)
+	defspecial("DEFTEST", Deftest)
 	defspecial("DEFUN", Defun)
 	defun("DIV", Div)
 	defspecial("DYNAMIC", Dynamic)
@@ -164,6 +231,8 @@ func init() {
 	defun("IDENTITY", Identity)
 	defspecial("IF", If)
 	defspecial("IGNORE-ERRORS", IgnoreErrors)
+	defspecial("IN-MODULE", InModule)
+	defspecial("IN-SUITE", InSuite)
 	defgeneric("INITIALIZE-OBJECT", InitializeObject) // TODO change generic function
 	defun("INPUT-STREAM-P", InputStreamP)
 	defun("INSTANCEP", Instancep)
@@ -178,6 +247,7 @@ func init() {
 	defspecial("LET*", LetStar)
 	defun("LIST", List)
 	defun("LISTP", Listp)
+	defun("LOAD", Load)
 	defun("LOG", Log)
 	defun("MAP-INTO", MapInto)
 	defun("MAPC", Mapc)
@@ -205,8 +275,9 @@ func init() {
 	defun("PARSE-NUMBER", ParseNumber)
 	defun("PREVIEW-CHAR", PreviewChar)
 	defun("PROBE-FILE", ProbeFile)
-	defspecial("PROGN", Progn)
+	defspecial("PROGN", prognTail)
 	defun("PROPERTY", Property)
+	defun("PROVIDE", Provide)
 	defspecial("QUASIQUOTE", Quasiquote)
 	defspecial("QUOTE", Quote)
 	defun("QUOTIENT", Quotient)
@@ -215,7 +286,7 @@ func init() {
 	defun("READ-CHAR", ReadChar)
 	defun("READ-LINE", ReadLine)
 	defun("REMOVE-PROPERTY", RemoveProperty)
-	defun("REPORT-CONDITION", ReportCondition)
+	defun("REQUIRE", Require)
 	defspecial("RETURN-FROM", ReturnFrom)
 	defun("REVERSE", Reverse)
 	defun("ROUND", Round)
@@ -258,11 +329,13 @@ func init() {
 	defun("SYMBOLP", Symbolp)
 	defglobal("T", T)
 	defspecial("TAGBODY", Tagbody)
-	defspecial("TAN", Tan)
-	defspecial("TANH", Tanh)
-	// TODO defspecial2("THE", The)
+	defun("TAN", Tan)
+	defun("TANH", Tanh)
+	defspecial("THE", The)
 	defspecial("THROW", Throw)
+	defspecial("TRACE", Trace)
 	defun("TRUNCATE", Truncate)
+	defspecial("UNTRACE", Untrace)
 	defspecial("UNWIND-PROTECT", UnwindProtect)
 	defun("VECTOR", Vector)
 	defspecial("WHILE", While)
@@ -270,6 +343,7 @@ func init() {
 	defspecial("WITH-HANDLER", WithHandler)
 	defspecial("WITH-OPEN-INPUT-FILE", WithOpenInputFile)
 	defspecial("WITH-OPEN-OUTPUT-FILE", WithOpenOutputFile)
+	defspecial("WITH-PROFILING", WithProfiling)
 	defspecial("WITH-STANDARD-INPUT", WithStandardInput)
 	defspecial("WITH-STANDARD-OUTPUT", WithStandardOutput)
 	defun("WRITE-BYTE", WriteByte)
@@ -303,28 +377,54 @@ func init() {
 	defclass("<PARSE-ERROR>", core.ParseErrorClass)
 	defclass("<PROGRAM-ERROR>", core.ProgramErrorClass)
 	defclass("<DOMAIN-ERROR>", core.DomainErrorClass)
+	defclass("<SYNTAX-ERROR>", core.SyntaxErrorClass)
 	defclass("<UNDEFINED-ENTITY>", core.UndefinedEntityClass)
 	defclass("<UNBOUND-VARIABLE>", core.UnboundVariableClass)
 	defclass("<UNDEFINED-FUNCTION>", core.UndefinedFunctionClass)
 	defclass("<SIMPLE-ERROR>", core.SimpleErrorClass)
+	defclass("<ASSERTION-FAILURE>", core.AssertionFailureClass)
 	defclass("<STREAM-ERROR>", core.StreamErrorClass)
 	defclass("<END-OF-STREAM>", core.EndOfStreamClass)
 	defclass("<STORAGE-EXHAUSTED>", core.StorageExhaustedClass)
+	defclass("<QUOTA-EXCEEDED>", core.QuotaExceededClass)
+	defclass("<CANCELLED>", core.CancelledClass)
 	defclass("<STANDARD-OBJECT>", core.StandardObjectClass)
 	defclass("<STREAM>", core.StreamClass)
 
 	defun("ARITHMETIC-ERROR-OPERATION", CreateReader(core.ArithmeticErrorClass, "OPERATION"))
 	defun("ARITHMETIC-ERROR-OPERANDS", CreateReader(core.ArithmeticErrorClass, "OPERANDS"))
-	defun("DOMAIN-ERROR-OBJECT", CreateReader(core.DomainErrorClass, "OPERANDS"))
+	defun("DOMAIN-ERROR-OBJECT", CreateReader(core.DomainErrorClass, "OBJECT"))
 	defun("DOMAIN-ERROR-EXPECTED-CLASS", CreateReader(core.DomainErrorClass, "EXPECTED-CLASS"))
 	defun("PARSE-ERROR-STRING", CreateReader(core.ParseErrorClass, "STRING"))
 	defun("PARSE-ERROR-EXPECTED-CLASS", CreateReader(core.ParseErrorClass, "EXPECTED-CLASS"))
 	defun("SIMPLE-ERROR-FORMAT-STRING", CreateReader(core.SimpleErrorClass, "FORMAT-STRING"))
 	defun("SIMPLE-ERROR-FORMAT-ARGUMENTS", CreateReader(core.SimpleErrorClass, "FORMAT-ARGUMENTS"))
 	defun("STREAM-ERROR-STREAM", CreateReader(core.StreamErrorClass, "STREAM"))
-	defun("UNDEFINED-ENTITY-NAME", CreateReader(core.SimpleErrorClass, "NAME"))
-	defun("UNDEFINED-ENTITY-NAMESPACE", CreateReader(core.StreamErrorClass, "NAMESPACE"))
+	defun("QUOTA-EXCEEDED-QUOTA", CreateReader(core.QuotaExceededClass, "QUOTA"))
+	defun("QUOTA-EXCEEDED-LIMIT", CreateReader(core.QuotaExceededClass, "LIMIT"))
+	defun("CANCELLED-REASON", CreateReader(core.CancelledClass, "REASON"))
+	defun("SYNTAX-ERROR-FORM", CreateReader(core.SyntaxErrorClass, "FORM"))
+	defun("SYNTAX-ERROR-SYNTAX", CreateReader(core.SyntaxErrorClass, "SYNTAX"))
+	defun("SYNTAX-ERROR-OBJECT", CreateReader(core.SyntaxErrorClass, "OBJECT"))
+	defun("UNDEFINED-ENTITY-NAME", CreateReader(core.UndefinedEntityClass, "NAME"))
+	defun("UNDEFINED-ENTITY-NAMESPACE", CreateReader(core.UndefinedEntityClass, "NAMESPACE"))
+
+	defgenericfunction("REPORT-CONDITION", "CONDITION", "STREAM")
+	defmethod("REPORT-CONDITION", ReportCondition, core.SeriousConditionClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportArithmeticError, core.ArithmeticErrorClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportDivisionByZero, core.DivisionByZeroClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportProgramError, core.ProgramErrorClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportParseError, core.ParseErrorClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportDomainError, core.DomainErrorClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportSyntaxError, core.SyntaxErrorClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportUndefinedEntity, core.UndefinedEntityClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportSimpleError, core.SimpleErrorClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportEndOfStream, core.EndOfStreamClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportStorageExhausted, core.StorageExhaustedClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportQuotaExceeded, core.QuotaExceededClass, core.ObjectClass)
+	defmethod("REPORT-CONDITION", ReportCancelled, core.CancelledClass, core.ObjectClass)
 
 	defspecial("IMPORT", Import)
 	Time = time.Now()
+	TopLevel = NewRuntime()
 }
@@ -0,0 +1,240 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+func TestNewRuntime(t *testing.T) {
+	eval := func(e core.Environment, exp string) (core.Instance, core.Instance) {
+		obj, err := readFromString(exp)
+		if err != nil {
+			t.Fatalf("ParseError %v, want %v", err, exp)
+		}
+		return Eval(e, obj)
+	}
+	a, b := NewRuntime(), NewRuntime()
+	for _, exp := range []string{
+		`(defun runtime-f () 'a)`,
+		`(defglobal runtime-x 1)`,
+		`(defclass <runtime-c> () ())`,
+		`(setf (property 'runtime-s 'p) 1)`,
+		`(defgeneric runtime-g (x))`,
+		`(defmethod runtime-g ((x <runtime-c>)) 'a)`,
+	} {
+		if _, err := eval(a, exp); err != nil {
+			t.Fatalf("%v: err = %v", exp, err)
+		}
+	}
+	for _, exp := range []string{
+		`(runtime-f)`,
+		`runtime-x`,
+		`(class <runtime-c>)`,
+		`(runtime-g (create (class <runtime-c>)))`,
+	} {
+		if _, err := eval(b, exp); err == nil {
+			t.Errorf("%v: defined in another runtime", exp)
+		}
+		if _, err := eval(a, exp); err != nil {
+			t.Errorf("%v: err = %v", exp, err)
+		}
+	}
+	if got, _ := eval(b, `(property 'runtime-s 'p)`); got != Nil {
+		t.Errorf("(property 'runtime-s 'p) got = %v, want NIL", got)
+	}
+	if got, _ := eval(b, `(gensym)`); !core.DeepEqual(got, core.NewSymbol("#:0")) {
+		t.Errorf("(gensym) got = %v, want #:0", got)
+	}
+}
+
+// anyObject are the builtin functions whose arguments may be any object.
+var anyObject = map[string]bool{
+	"BASIC-ARRAY*-P": true, "BASIC-ARRAY-P": true, "BASIC-VECTOR-P": true,
+	"CHARACTERP": true, "CLASS-OF": true, "CONS": true, "CONSP": true,
+	"EQ": true, "EQL": true, "EQUAL": true, "EVAL": true, "FLOATP": true,
+	"FUNCTIONP": true, "GENERAL-ARRAY*-P": true, "GENERAL-VECTOR-P": true,
+	"GENERIC-FUNCTION-P": true, "IDENTITY": true, "INPUT-STREAM-P": true,
+	"INTEGERP": true, "LIST": true, "LISTP": true, "NOT": true, "NULL": true,
+	"NUMBERP": true, "OPEN-STREAM-P": true, "OUTPUT-STREAM-P": true,
+	"STREAMP": true, "STRINGP": true, "SYMBOLP": true, "VECTOR": true,
+}
+
+// TestWrongTypes calls every builtin function with arguments of every type,
+// which must return a value or signal a condition rather than panic, and with
+// an instance of a class of its own, which must signal a condition unless the
+// function takes any object.
+func TestWrongTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wrong-types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	e := NewRuntime()
+	var exps []string
+	e.Runtime.Panic = func(name core.Instance, value interface{}) {
+		t.Errorf("%v %v panics: %v", name, exps, value)
+	}
+	eval := func(exp string) core.Instance {
+		obj, err := readFromString(exp)
+		if err != nil {
+			t.Fatalf("ParseError %v, want %v", err, exp)
+		}
+		ret, err := Eval(e, obj)
+		if err != nil {
+			t.Fatalf("%v: err = %v", exp, err)
+		}
+		return ret
+	}
+	eval(`(defclass <wrong-type> () ())`)
+	samples := []string{
+		`(create (class <wrong-type>))`, `1`, `-1`, `100000000000000000000000`, `1.5`,
+		`"s"`, `#\c`, `'a`, `nil`, `'(1 2)`, `#(1 2)`, `(create-array '(2 2))`,
+		`#'car`, `(class <integer>)`, `(create-string-input-stream "a")`,
+		`(create-string-output-stream)`,
+	}
+	names := []string{}
+	for _, key := range e.Function[:1].Keys() {
+		names = append(names, key.(core.Instance).String())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		function, _ := e.Function.Get(core.NewSymbol(name))
+		required, _ := function.(interface{ Arity() (int, bool) }).Arity()
+		if required == 0 {
+			required = 1
+		}
+		call := func(indices ...int) (ret, err core.Instance) {
+			// The samples are read again for each call, as it may modify them.
+			arguments := []core.Instance{}
+			exps = []string{}
+			for i := 0; i < required; i++ {
+				exp := samples[indices[len(indices)-1]]
+				if i < len(indices) {
+					exp = samples[indices[i]]
+				}
+				arguments = append(arguments, eval(exp))
+				exps = append(exps, exp)
+			}
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%v %v panics: %v", name, exps, r)
+				}
+			}()
+			ret, err = function.(core.Applicable).Apply(e.NewDynamic(), arguments...)
+			if err != nil && !core.InstanceOf(core.SeriousConditionClass, err) && !core.InstanceOf(core.EscapeClass, err) {
+				t.Errorf("%v %v err = %v, want a condition", name, exps, err)
+			}
+			return ret, err
+		}
+		if _, err := call(0); err == nil && !anyObject[name] {
+			t.Errorf("%v %v err = nil, want a condition", name, fmt.Sprint(samples[0]))
+		}
+		for i := range samples {
+			if required == 1 {
+				call(i)
+				continue
+			}
+			for j := range samples {
+				call(i, j)
+			}
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		exp    string
+		limits core.Limits
+		quota  string
+	}{
+		{`(while t)`, core.Limits{Steps: 1000}, "STEPS"},
+		{`(labels ((f () (f))) (f))`, core.Limits{Steps: 1000}, "STEPS"},
+		{`(labels ((f (n) (+ 1 (f n)))) (f 0))`, core.Limits{Depth: 100}, "DEPTH"},
+		{`(create-string 1001)`, core.Limits{Size: 1000}, "SIZE"},
+		{`(create-list 1001)`, core.Limits{Size: 1000}, "SIZE"},
+		{`(create-array '(10 10 11))`, core.Limits{Size: 1000}, "SIZE"},
+		{`(create-vector 1000)`, core.Limits{Size: 100}, "SIZE"},
+		{`(string-append (create-string 60) (create-string 60))`, core.Limits{Size: 100}, "SIZE"},
+		{`(convert (create-string 101) <list>)`, core.Limits{Size: 100}, "SIZE"},
+		{`(append (create-list 600) (create-list 600))`, core.Limits{Size: 1000}, "SIZE"},
+		{`(create-string 1000)`, core.Limits{Size: 1000}, ""},
+		{`(labels ((f (n) (if (= n 0) 0 (+ 1 (f (- n 1)))))) (f 90))`, core.Limits{Depth: 100}, ""},
+		{`(with-handler (lambda (c) (while t)) (while t))`, core.Limits{Steps: 10000}, "STEPS"},
+		{`(with-handler (lambda (c) (labels ((f () (+ 1 (f)))) (f))) (labels ((f () (+ 1 (f)))) (f)))`, core.Limits{Depth: 100}, "DEPTH"},
+		{`(with-handler (lambda (c) (create-string 1001)) (create-string 1001))`, core.Limits{Size: 1000}, "SIZE"},
+		{`(catch 'c (with-handler (lambda (c) (throw 'c 1)) (while t)))`, core.Limits{Steps: 10000}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			e := NewRuntime()
+			e.Runtime.Limits = tt.limits
+			obj, _ := readFromString(tt.exp)
+			_, err := Eval(e, obj)
+			if tt.quota == "" {
+				if err != nil {
+					t.Fatalf("%v err = %v", tt.exp, err)
+				}
+				return
+			}
+			if err == nil || !core.InstanceOf(core.StorageExhaustedClass, err) {
+				t.Fatalf("%v err = %v, want <quota-exceeded>", tt.exp, err)
+			}
+			quota, _ := err.(core.BasicInstance).GetSlotValue(core.NewSymbol("QUOTA"), core.QuotaExceededClass)
+			if !core.DeepEqual(quota, core.NewSymbol(tt.quota)) {
+				t.Errorf("%v quota = %v, want %v", tt.exp, quota, tt.quota)
+			}
+			if e.Runtime.Usage.Depth != 0 {
+				t.Errorf("%v depth = %v after the evaluation", tt.exp, e.Runtime.Usage.Depth)
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	e := NewRuntime()
+	obj, _ := readFromString(`(progn (create-list 3) (create-string 4) (labels ((f (n) (if (= n 0) 0 (+ 1 (f (- n 1)))))) (f 10)))`)
+	if _, err := Eval(e, obj); err != nil {
+		t.Fatal(err)
+	}
+	u := e.Runtime.Usage
+	if u.Steps == 0 || u.Depth != 0 || u.MaxDepth < 11 || u.Allocated != 7 {
+		t.Errorf("usage = %+v", u)
+	}
+	again := NewRuntime()
+	Eval(again, obj)
+	if again.Runtime.Usage != u {
+		t.Errorf("usage = %+v, want %+v", again.Runtime.Usage, u)
+	}
+}
+
+func TestTailStepCount(t *testing.T) {
+	steps := func(exp string) int {
+		e := NewRuntime()
+		obj, _ := readFromString(exp)
+		Eval(e, obj)
+		return e.Runtime.Usage.Steps
+	}
+	// (g) is undefined, so the evaluation stops at it in tail position or not.
+	tail := steps(`(labels ((f () (g))) (f))`)
+	notTail := steps(`(labels ((f () (g) nil)) (f))`)
+	if tail != notTail {
+		t.Errorf("steps = %v in tail position, want %v", tail, notTail)
+	}
+}
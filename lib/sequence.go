@@ -34,28 +34,26 @@ func Length(e core.Environment, sequence core.Instance) (core.Instance, core.Ins
 // error shall be signaled if sequence is not a basic-vector or a list or if z
 // is not an integer (error-id. domain-error).
 func Elt(e core.Environment, sequence, z core.Instance) (core.Instance, core.Instance) {
-	if err := ensure(e, core.IntegerClass, z); err != nil {
+	idx, err := convIndex(e, z)
+	if err != nil {
 		return nil, err
 	}
 	switch {
 	case core.InstanceOf(core.StringClass, sequence):
 		seq := sequence.(core.String)
-		idx := int(z.(core.Integer))
-		if idx > 0 && len(seq) <= idx {
+		if idx < 0 || len(seq) <= idx {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return core.NewCharacter(seq[idx]), nil
 	case core.InstanceOf(core.GeneralVectorClass, sequence):
 		seq := sequence.(core.GeneralVector)
-		idx := int(z.(core.Integer))
-		if idx > 0 && len(seq) <= idx {
+		if idx < 0 || len(seq) <= idx {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return seq[idx], nil
 	case core.InstanceOf(core.ListClass, sequence):
 		seq := sequence.(core.List).Slice()
-		idx := int(z.(core.Integer))
-		if idx > 0 && len(seq) <= idx {
+		if idx < 0 || len(seq) <= idx {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return seq[idx], nil
@@ -70,14 +68,14 @@ func Elt(e core.Environment, sequence, z core.Instance) (core.Instance, core.Ins
 // be signaled if sequence is not a basic-vector or a list or if z is not an
 // integer (error-id. domain-error). obj may be any ISLISP object.
 func SetElt(e core.Environment, obj, sequence, z core.Instance) (core.Instance, core.Instance) {
-	if err := ensure(e, core.IntegerClass, z); err != nil {
+	idx, err := convIndex(e, z)
+	if err != nil {
 		return nil, err
 	}
 	switch {
 	case core.InstanceOf(core.StringClass, sequence):
 		seq := sequence.(core.String)
-		idx := int(z.(core.Integer))
-		if idx > 0 && len(seq) <= idx {
+		if idx < 0 || len(seq) <= idx {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		if err := ensure(e, core.CharacterClass, obj); err != nil {
@@ -87,16 +85,14 @@ func SetElt(e core.Environment, obj, sequence, z core.Instance) (core.Instance,
 		return obj, nil
 	case core.InstanceOf(core.GeneralVectorClass, sequence):
 		seq := sequence.(core.GeneralVector)
-		idx := int(z.(core.Integer))
-		if idx > 0 && len(seq) <= idx {
+		if idx < 0 || len(seq) <= idx {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		seq[idx] = obj
 		return obj, nil
 	case core.InstanceOf(core.ListClass, sequence):
 		seq := sequence.(core.List).Slice()
-		idx := int(z.(core.Integer))
-		if idx > 0 && len(seq) <= idx {
+		if idx < 0 || len(seq) <= idx {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		for idx != 0 && core.InstanceOf(core.ConsClass, sequence) {
@@ -119,29 +115,35 @@ func SetElt(e core.Environment, obj, sequence, z core.Instance) (core.Instance,
 // signaled if sequence is not a basic-vector or a list, or if z1 is not an
 // integer, or if z2 is not an integer (error-id. domain-error).
 func Subseq(e core.Environment, sequence, z1, z2 core.Instance) (core.Instance, core.Instance) {
-	if err := ensure(e, core.IntegerClass, z1, z2); err != nil {
+	start, err := convIndex(e, z1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := convIndex(e, z2)
+	if err != nil {
 		return nil, err
 	}
-	start := int(z1.(core.Integer))
-	end := int(z2.(core.Integer))
 	switch {
 	case core.InstanceOf(core.StringClass, sequence):
 		seq := sequence.(core.String)
-		if !(0 <= start && start < len(seq) && 0 <= end && end < len(seq) && start <= end) {
+		if !(0 <= start && start <= end && end <= len(seq)) {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return seq[start:end], nil
 	case core.InstanceOf(core.GeneralVectorClass, sequence):
 		seq := sequence.(core.GeneralVector)
-		if !(0 <= start && start < len(seq) && 0 <= end && end < len(seq) && start <= end) {
+		if !(0 <= start && start <= end && end <= len(seq)) {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
 		return seq[start:end], nil
 	case core.InstanceOf(core.ListClass, sequence):
 		seq := sequence.(core.List).Slice()
-		if !(0 < start && start < len(seq) && 0 < end && end < len(seq) && start <= end) {
+		if !(0 <= start && start <= end && end <= len(seq)) {
 			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
 		}
+		if _, err := core.CountAllocation(e, end-start); err != nil {
+			return nil, err
+		}
 		return List(e, seq[start:end]...)
 	}
 	return SignalCondition(e, core.NewDomainError(e, sequence, core.ObjectClass), Nil)
@@ -180,7 +182,11 @@ func MapInto(e core.Environment, destination, function core.Instance, sequences
 			return nil, err
 		}
 	}
-	for i := 0; i < int(min.(core.Integer)); i++ {
+	n, err := convInt(e, min)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
 		arguments := make([]core.Instance, len(sequences))
 		for j, seq := range sequences {
 			var err core.Instance
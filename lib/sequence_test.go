@@ -47,6 +47,46 @@ func TestElt(t *testing.T) {
 			want:    `#\a`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (elt "abc" 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (elt '(a b c) -100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (elt '(a b c) -1)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (elt '() 0)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(elt "abc" 3)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(elt '(a b c) 3)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(elt (vector 'a) 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(elt '(a b c) -1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
 
@@ -70,6 +110,11 @@ func TestSetElt(t *testing.T) {
 			want:    `'(x x O x x)`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (set-elt 'x (vector 'a 'b) 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
 	})
 }
 
@@ -90,6 +135,46 @@ func TestSubseq(t *testing.T) {
 			want:    `#(b c d)`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (subseq "abc" 1 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(subseq "abcdef" 0 6)`,
+			want:    `"abcdef"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(subseq '(a b c) 0 3)`,
+			want:    `'(a b c)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(subseq (vector 'a 'b) 2 2)`,
+			want:    `#()`,
+			wantErr: false,
+		},
+		{
+			exp:     `(subseq "abc" 1 4)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(subseq "abc" 2 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(subseq '(a b c) 0 4)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(subseq (vector 'a 'b) -1 1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
 
@@ -22,3 +22,16 @@ func Progn(e core.Environment, forms ...core.Instance) (core.Instance, core.Inst
 	}
 	return ret, nil
 }
+
+// prognTail is Progn with the last form evaluated in tail position. It is
+// the definition of the progn special form and of the bodies of functions;
+// Go callers that need the value of the forms use Progn.
+func prognTail(e core.Environment, forms ...core.Instance) (core.Instance, core.Instance) {
+	if len(forms) == 0 {
+		return Nil, nil
+	}
+	if _, err := Progn(e, forms[:len(forms)-1]...); err != nil {
+		return nil, err
+	}
+	return evalTail(e, forms[len(forms)-1])
+}
@@ -279,6 +279,9 @@ func FinishOutput(e core.Environment, stream core.Instance) (core.Instance, core
 }
 
 func CreateStringInputStream(e core.Environment, str core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.StringClass, str); err != nil {
+		return nil, err
+	}
 	return core.NewStream(strings.NewReader(string(str.(core.String))), nil, core.CharacterClass), nil
 }
 
@@ -308,7 +311,7 @@ func Read(e core.Environment, options ...core.Instance) (core.Instance, core.Ins
 	eosErrorP := true
 	if len(options) > 1 {
 		if core.DeepEqual(options[1], Nil) {
-			env = env.NewHandler(core.DefaultHandler)
+			env = env.NewHandler(core.SilentHandler)
 			eosErrorP = false
 		}
 	}
@@ -323,6 +326,12 @@ func Read(e core.Environment, options ...core.Instance) (core.Instance, core.Ins
 		}
 		return eosValue, nil
 	}
+	if err != nil {
+		if !eosErrorP { // not signaled yet
+			return SignalCondition(e, err, Nil)
+		}
+		return nil, err
+	}
 	return v, nil
 }
 
@@ -427,6 +436,9 @@ func ReadLine(e core.Environment, options ...core.Instance) (core.Instance, core
 }
 
 func StreamReadyP(e core.Environment, inputStream core.Instance) (core.Instance, core.Instance) {
+	if ok, _ := InputStreamP(e, inputStream); ok == Nil {
+		return SignalCondition(e, core.NewDomainError(e, inputStream, core.StreamClass), Nil)
+	}
 	// TODO: stream-ready-p
 	return T, nil
 }
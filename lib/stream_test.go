@@ -70,6 +70,25 @@ func TestWithStandardInput(t *testing.T) {
 	})
 }
 
+func TestWithStandardOutput(t *testing.T) {
+	execTests(t, WithStandardOutput, []test{
+		{
+			exp:     `(defun put-standard (x) (format (standard-output) "~A" x))`,
+			want:    `'put-standard`,
+			wantErr: false,
+		},
+		{
+			exp: `
+			(let ((s (create-string-output-stream)))
+				(with-standard-output s (put-standard 1))
+				(get-output-stream-string s))
+			`,
+			want:    `"1"`,
+			wantErr: false,
+		},
+	})
+}
+
 func TestWithOpenIoFile(t *testing.T) {
 	execTests(t, WithOpenIoFile, []test{
 		{
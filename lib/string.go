@@ -4,11 +4,7 @@
 
 package lib
 
-import (
-	"strings"
-
-	"github.com/islisp-dev/iris/core"
-)
+import "github.com/islisp-dev/iris/core"
 
 // Stringp returns t if obj is a string (instance of class string); otherwise,
 // returns nil. obj may be any ISLISP object.
@@ -26,13 +22,16 @@ func Stringp(e core.Environment, obj core.Instance) (core.Instance, core.Instanc
 // cannot-create-string). An error shall be signaled if i is not a non-negative
 // integer or if initial-character is not a character (error-id. domain-error).
 func CreateString(e core.Environment, i core.Instance, initialElement ...core.Instance) (core.Instance, core.Instance) {
-	if !core.InstanceOf(core.IntegerClass, i) || int(i.(core.Integer)) < 0 {
-		return SignalCondition(e, core.NewDomainError(e, i, core.ObjectClass), Nil)
+	n, err := convSize(e, i)
+	if err != nil {
+		return nil, err
 	}
 	if len(initialElement) > 1 {
 		return SignalCondition(e, core.NewArityError(e), Nil)
 	}
-	n := int(i.(core.Integer))
+	if _, err := core.CountAllocation(e, n); err != nil {
+		return nil, err
+	}
 	v := make([]rune, n)
 	for i := 0; i < n; i++ {
 		if len(initialElement) == 0 {
@@ -131,18 +130,20 @@ func CharIndex(e core.Environment, char, str core.Instance, startPosition ...cor
 	}
 	n := 0
 	if len(startPosition) == 1 {
-		if err := ensure(e, core.IntegerClass, startPosition[0]); err != nil {
+		var err core.Instance
+		if n, err = convIndex(e, startPosition[0]); err != nil {
 			return nil, err
 		}
-		n = int(startPosition[0].(core.Integer))
+		if n < 0 || len(str.(core.String)) < n {
+			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
+		}
 	}
-	s := string(str.(core.String)[n:])
-	c := rune(char.(core.Character))
-	i := strings.IndexRune(s, c)
-	if i < 0 {
-		return Nil, nil
+	for i, c := range str.(core.String)[n:] {
+		if c == rune(char.(core.Character)) {
+			return core.NewInteger(i + n), nil
+		}
 	}
-	return core.NewInteger(i + n), nil
+	return Nil, nil
 }
 
 // StringIndex returns the position of the given substring within string. The
@@ -165,18 +166,21 @@ func StringIndex(e core.Environment, sub, str core.Instance, startPosition ...co
 	}
 	n := 0
 	if len(startPosition) == 1 {
-		if err := ensure(e, core.IntegerClass, startPosition[0]); err != nil {
+		var err core.Instance
+		if n, err = convIndex(e, startPosition[0]); err != nil {
 			return nil, err
 		}
-		n = int(startPosition[0].(core.Integer))
+		if n < 0 || len(str.(core.String)) < n {
+			return SignalCondition(e, core.NewIndexOutOfRange(e), Nil)
+		}
 	}
-	s := string(str.(core.String)[n:])
-	c := string(sub.(core.String))
-	i := strings.Index(s, c)
-	if i < 0 {
-		return Nil, nil
+	s, c := str.(core.String), sub.(core.String)
+	for i := n; i+len(c) <= len(s); i++ {
+		if string(s[i:i+len(c)]) == string(c) {
+			return core.NewInteger(i), nil
+		}
 	}
-	return core.NewInteger(i + n), nil
+	return Nil, nil
 }
 
 // StringAppend returns a single string containing a sequence of characters that
@@ -187,12 +191,19 @@ func StringIndex(e core.Environment, sub, str core.Instance, startPosition ...co
 // with its string arguments. An error shall be signaled if the string cannot be
 // allocated (error-id. cannot-create-string).
 func StringAppend(e core.Environment, str ...core.Instance) (core.Instance, core.Instance) {
-	ret := ""
+	if err := ensure(e, core.StringClass, str...); err != nil {
+		return nil, err
+	}
+	n := 0
 	for _, s := range str {
-		if err := ensure(e, core.StringClass, s); err != nil {
-			return nil, err
-		}
-		ret += string(s.(core.String))
+		n += len(s.(core.String))
+	}
+	if _, err := core.CountAllocation(e, n); err != nil {
+		return nil, err
+	}
+	ret := make([]rune, 0, n)
+	for _, s := range str {
+		ret = append(ret, s.(core.String)...)
 	}
-	return core.NewString([]rune(ret)), nil
+	return core.NewString(ret), nil
 }
@@ -29,6 +29,26 @@ func TestCreateString(t *testing.T) {
 			want:    `""`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-string 100000000000000000000000)))`,
+			want:    `(class <storage-exhausted>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-string -100000000000000000000000)))`,
+			want:    `(class <domain-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(create-string 100000000000000)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-string 100000000000000)))`,
+			want:    `(class <storage-exhausted>)`,
+			wantErr: false,
+		},
 	})
 }
 
@@ -139,6 +159,31 @@ func TestCharIndex(t *testing.T) {
 			want:    `nil`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (char-index #\a "abcab" 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (char-index #\a "abcab" 6)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(char-index #\b "äb")`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(char-index #\a "abc" 4)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(char-index #\a "abc" -1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
 
@@ -179,6 +224,26 @@ func TestStringIndex(t *testing.T) {
 			want:    `0`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (string-index "a" "abcab" 100000000000000000000000)))`,
+			want:    `(class <program-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(string-index "b" "äb")`,
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			exp:     `(string-index "a" "abc" 4)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(string-index "a" "abc" -1)`,
+			want:    `nil`,
+			wantErr: true,
+		},
 	})
 }
 
@@ -73,6 +73,6 @@ func RemoveProperty(e core.Environment, symbol, propertyName core.Instance) (cor
 // Gensym returns an unnamed symbol. gensym is useful for writing macros. It is
 // impossible for an identifier to name an unnamed symbol.
 func Gensym(e core.Environment) (core.Instance, core.Instance) {
-	symbol := core.NewSymbol(fmt.Sprintf("#:%v", uniqueInt()))
+	symbol := core.NewSymbol(fmt.Sprintf("#:%v", uniqueInt(e)))
 	return symbol, nil
 }
@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import (
+	"github.com/islisp-dev/iris/core"
+	v "github.com/islisp-dev/iris/validator"
+)
+
+// syntax is the syntax of a special form: the shape of the form, as written
+// in the specification, and the validator of its arguments.
+type syntax struct {
+	shape string
+	valid v.Validator
+}
+
+// syntaxes are the syntaxes of the special forms by their names.
+var syntaxes = newSyntaxes()
+
+func newSyntaxes() map[string]syntax {
+	var slotOptions v.Validator
+	var (
+		forms         = v.Repeat(v.Any)
+		name          = v.And(v.InstanceOf(core.SymbolClass), v.Not(v.Nil))
+		restKeyword   = v.SymbolNamed("&REST", ":REST")
+		variable      = v.And(name, v.Not(restKeyword))
+		lambdaList    = v.Append(v.Repeat(variable), v.Or(v.Nil, v.List(restKeyword, variable)))
+		bindings      = v.Repeat(v.List(variable, v.Any))
+		definitions   = v.Repeat(v.Append(v.List(name, lambdaList), forms))
+		lambda        = v.Append(v.List(v.SymbolNamed("LAMBDA"), lambdaList), forms)
+		clause        = v.Append(v.List(v.Any), forms)
+		caseClause    = v.Append(v.List(v.Or(forms, v.SymbolNamed("T"))), forms)
+		iterationSpec = v.Or(v.List(variable, v.Any), v.List(variable, v.Any, v.Any))
+		profile       = v.Append(v.Repeat(v.Or(variable, v.List(variable, name))), v.Or(v.Nil, v.List(restKeyword, variable)))
+		method        = v.Append(v.Repeat(v.SymbolNamed(":AROUND", ":BEFORE", ":AFTER")), v.List(profile), forms)
+		slotOption    = v.Or(v.List(v.SymbolNamed(":READER", ":WRITER", ":ACCESSOR", ":BOUNDP", ":INITARG"), name), v.List(v.SymbolNamed(":INITFORM"), v.Any))
+		slotSpec      = v.Or(name, v.Append(v.List(name), func(options core.Instance) error { return slotOptions(options) }))
+		classOption   = v.Or(v.List(v.SymbolNamed(":METACLASS"), name), v.List(v.SymbolNamed(":ABSTRACTP"), v.Any))
+		fileSpec      = v.Or(v.List(variable, v.Any), v.List(variable, v.Any, v.Any))
+	)
+	// The options of a slot spec are pairs of an option and its value.
+	slotOptions = v.Or(v.Nil, v.Append(slotOption, func(options core.Instance) error { return slotOptions(options) }))
+	return map[string]syntax{
+		"AND":                   {"(and form*)", forms},
+		"ASSERT-EQUAL":          {"(assert-equal expected-form form)", v.List(v.Any, v.Any)},
+		"ASSERT-ERROR":          {"(assert-error class-name form)", v.List(name, v.Any)},
+		"ASSERT-TRUE":           {"(assert-true form)", v.List(v.Any)},
+		"ASSURE":                {"(assure class-name form)", v.List(name, v.Any)},
+		"BLOCK":                 {"(block name form*)", v.Append(v.List(name), forms)},
+		"CASE":                  {"(case keyform ((key*) form*)* [(t form*)])", v.Append(v.List(v.Any), v.Repeat(caseClause))},
+		"CASE-USING":            {"(case-using predform keyform ((key*) form*)* [(t form*)])", v.Append(v.List(v.Any, v.Any), v.Repeat(caseClause))},
+		"CATCH":                 {"(catch tag-form form*)", v.Append(v.List(v.Any), forms)},
+		"CLASS":                 {"(class class-name)", v.List(name)},
+		"COND":                  {"(cond (test form*)*)", v.Repeat(clause)},
+		"CONVERT":               {"(convert obj class-name)", v.List(v.Any, name)},
+		"DEFCLASS":              {"(defclass class-name (sc-name*) (slot-spec*) class-opt*)", v.Append(v.List(name, v.Repeat(name), v.Repeat(slotSpec)), v.Repeat(classOption))},
+		"DEFCONSTANT":           {"(defconstant name form)", v.List(name, v.Any)},
+		"DEFDYNAMIC":            {"(defdynamic name form)", v.List(name, v.Any)},
+		"DEFGENERIC":            {"(defgeneric func-spec lambda-list {option | method-desc}*)", v.Append(v.List(name, lambdaList), v.Repeat(v.Or(v.List(v.SymbolNamed(":METHOD-COMBINATION"), v.Any), v.List(v.SymbolNamed(":GENERIC-FUNCTION-CLASS"), name), v.Append(v.List(v.SymbolNamed(":METHOD")), method))))},
+		"DEFGLOBAL":             {"(defglobal name form)", v.List(name, v.Any)},
+		"DEFMACRO":              {"(defmacro macro-name lambda-list form*)", v.Append(v.List(name, lambdaList), forms)},
+		"DEFMETHOD":             {"(defmethod func-spec method-qualifier* parameter-profile form*)", v.Append(v.List(name), method)},
+		"DEFMODULE":             {"(defmodule name {(:export name*) | (:import module item*) | (:shadow name*)}*)", v.Append(v.List(name), v.Repeat(v.Append(v.List(v.SymbolNamed(":EXPORT", ":IMPORT", ":SHADOW")), forms)))},
+		"DEFSUITE":              {"(defsuite suite-name {(:setup form*) | (:teardown form*)}*)", v.Append(v.List(name), v.Repeat(v.Append(v.List(v.SymbolNamed(":SETUP", ":TEARDOWN")), forms)))},
+		"DEFTEST":               {"(deftest test-name form*)", v.Append(v.List(name), forms)},
+		"DEFUN":                 {"(defun function-name lambda-list form*)", v.Append(v.List(name, lambdaList), forms)},
+		"DYNAMIC":               {"(dynamic var)", v.List(variable)},
+		"DYNAMIC-LET":           {"(dynamic-let ((var form)*) body-form*)", v.Append(v.List(bindings), forms)},
+		"FLET":                  {"(flet ((function-name lambda-list form*)*) body-form*)", v.Append(v.List(definitions), forms)},
+		"FOR":                   {"(for (iteration-spec*) (end-test result*) form*)", v.Append(v.List(v.Repeat(iterationSpec), clause), forms)},
+		"FUNCTION":              {"(function function-name)", v.List(v.Or(name, lambda))},
+		"GO":                    {"(go go-tag)", v.List(v.Not(v.InstanceOf(core.ConsClass)))},
+		"IF":                    {"(if test-form then-form [else-form])", v.Or(v.List(v.Any, v.Any), v.List(v.Any, v.Any, v.Any))},
+		"IGNORE-ERRORS":         {"(ignore-errors form*)", forms},
+		"IMPORT":                {"(import name* :from path)", v.Append(v.Repeat(name), v.List(v.SymbolNamed(":FROM"), v.InstanceOf(core.StringClass)))},
+		"IN-MODULE":             {"(in-module name)", v.List(name)},
+		"IN-SUITE":              {"(in-suite suite-name)", v.List(v.Or(name, v.Nil))},
+		"LABELS":                {"(labels ((function-name lambda-list form*)*) body-form*)", v.Append(v.List(definitions), forms)},
+		"LAMBDA":                {"(lambda lambda-list form*)", v.Append(v.List(lambdaList), forms)},
+		"LET":                   {"(let ((var form)*) body-form*)", v.Append(v.List(bindings), forms)},
+		"LET*":                  {"(let* ((var form)*) body-form*)", v.Append(v.List(bindings), forms)},
+		"OR":                    {"(or form*)", forms},
+		"PROGN":                 {"(progn form*)", forms},
+		"QUASIQUOTE":            {"(quasiquote obj)", v.List(v.Any)},
+		"QUOTE":                 {"(quote obj)", v.List(v.Any)},
+		"RETURN-FROM":           {"(return-from name result-form)", v.List(name, v.Any)},
+		"SETF":                  {"(setf place form)", v.List(v.Or(variable, v.InstanceOf(core.ConsClass)), v.Any)},
+		"SETQ":                  {"(setq var form)", v.List(variable, v.Any)},
+		"TAGBODY":               {"(tagbody {go-tag | form}*)", forms},
+		"THE":                   {"(the class-name form)", v.List(name, v.Any)},
+		"THROW":                 {"(throw tag-form result-form)", v.List(v.Any, v.Any)},
+		"TRACE":                 {"(trace function-name*)", v.Repeat(name)},
+		"UNTRACE":               {"(untrace function-name*)", v.Repeat(name)},
+		"UNWIND-PROTECT":        {"(unwind-protect form cleanup-form*)", v.Append(v.List(v.Any), forms)},
+		"WHILE":                 {"(while test-form body-form*)", v.Append(v.List(v.Any), forms)},
+		"WITH-ERROR-OUTPUT":     {"(with-error-output stream-form form*)", v.Append(v.List(v.Any), forms)},
+		"WITH-HANDLER":          {"(with-handler handler form*)", v.Append(v.List(v.Any), forms)},
+		"WITH-OPEN-INPUT-FILE":  {"(with-open-input-file (name filename [element-class]) form*)", v.Append(v.List(fileSpec), forms)},
+		"WITH-OPEN-OUTPUT-FILE": {"(with-open-output-file (name filename [element-class]) form*)", v.Append(v.List(fileSpec), forms)},
+		"WITH-PROFILING":        {"(with-profiling filename-form form*)", v.Append(v.List(v.Any), forms)},
+		"WITH-STANDARD-INPUT":   {"(with-standard-input stream-form form*)", v.Append(v.List(v.Any), forms)},
+		"WITH-STANDARD-OUTPUT":  {"(with-standard-output stream-form form*)", v.Append(v.List(v.Any), forms)},
+	}
+}
+
+// Syntax returns the shape of the special form of the operator named name,
+// as written in the specification.
+func Syntax(name string) (string, bool) {
+	s, ok := syntaxes[name]
+	return s.shape, ok
+}
+
+// CheckSyntax returns a <syntax-error> if form is a special form which does
+// not have the syntax of its operator, or nil. The condition is not
+// signaled.
+func CheckSyntax(e core.Environment, form core.Instance) core.Instance {
+	cons, ok := form.(*core.Cons)
+	if !ok {
+		return nil
+	}
+	s, ok := syntaxes[cons.Car.String()]
+	if !ok {
+		return nil
+	}
+	err := s.valid(cons.Cdr)
+	if err == nil {
+		return nil
+	}
+	object := form
+	if err, ok := err.(v.ValidationError); ok && err.Actual != cons.Cdr {
+		object = err.Actual
+	}
+	return core.NewSyntaxError(e, form, core.NewString([]rune(s.shape)), object)
+}
+
+// checkSyntax signals the <syntax-error> of CheckSyntax.
+func checkSyntax(e core.Environment, form core.Instance) core.Instance {
+	if c := CheckSyntax(e, form); c != nil {
+		_, err := SignalCondition(e, c, Nil)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import "github.com/islisp-dev/iris/core"
+
+// Trace traces the applications of the functions named by the function-names,
+// which are not evaluated, and returns the names. Each application prints a
+// line with the arguments to the error output, and another line with the value
+// returned or the condition signaled. The methods applied by a traced generic
+// function are traced too. Without function-names, Trace returns the names of
+// the functions traced.
+func Trace(e core.Environment, functionNames ...core.Instance) (core.Instance, core.Instance) {
+	if len(functionNames) == 0 {
+		return List(e, e.Runtime.Traced()...)
+	}
+	for _, name := range functionNames {
+		e.Runtime.Trace(name)
+	}
+	return List(e, functionNames...)
+}
+
+// Untrace stops tracing the functions named by the function-names, which are
+// not evaluated, or all of them without function-names. It returns the names
+// of the functions which are no longer traced.
+func Untrace(e core.Environment, functionNames ...core.Instance) (core.Instance, core.Instance) {
+	if len(functionNames) == 0 {
+		functionNames = e.Runtime.Traced()
+	}
+	untraced := []core.Instance{}
+	for _, name := range functionNames {
+		if e.Runtime.Untrace(name) {
+			untraced = append(untraced, name)
+		}
+	}
+	return List(e, untraced...)
+}
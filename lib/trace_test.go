@@ -0,0 +1,100 @@
+package lib
+
+import "testing"
+
+func TestTrace(t *testing.T) {
+	execTests(t, Trace, []test{
+		{
+			exp: `
+			(defmacro traced (&rest forms)
+			  (let ((s (gensym)))
+			    ` + "`" + `(let ((,s (create-string-output-stream)))
+			       (with-error-output ,s (ignore-errors ,@forms))
+			       (get-output-stream-string ,s))))
+			`,
+			want:    `'traced`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defun trace-fact (n) (if (= n 0) 1 (* n (trace-fact (- n 1)))))`,
+			want:    `'trace-fact`,
+			wantErr: false,
+		},
+		{
+			exp:     `(trace trace-fact car)`,
+			want:    `'(trace-fact car)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(trace)`,
+			want:    `'(car trace-fact)`,
+			wantErr: false,
+		},
+		{
+			exp: `(traced (trace-fact 2))`,
+			want: `"0: (TRACE-FACT 2)
+  1: (TRACE-FACT 1)
+    2: (TRACE-FACT 0)
+    2: TRACE-FACT returned 1
+  1: TRACE-FACT returned 1
+0: TRACE-FACT returned 2
+"`,
+			wantErr: false,
+		},
+		{
+			exp: `(traced (car 1))`,
+			want: `"0: (CAR 1)
+0: CAR signaled <DOMAIN-ERROR>
+"`,
+			wantErr: false,
+		},
+		{
+			exp: `(traced (flet ((trace-fact (x) (car x))) (trace-fact '(1))))`,
+			want: `"0: (TRACE-FACT (1))
+  1: (CAR (1))
+  1: CAR returned 1
+0: TRACE-FACT returned 1
+"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(defgeneric trace-area (s))`,
+			want:    `'trace-area`,
+			wantErr: false,
+		},
+		{
+			exp: `(progn
+			       (defmethod trace-area ((s <integer>)) (* s s))
+			       (defmethod trace-area :before ((s <integer>)) nil)
+			       (trace trace-area))`,
+			want:    `'(trace-area)`,
+			wantErr: false,
+		},
+		{
+			exp: `(traced (trace-area 2))`,
+			want: `"0: (TRACE-AREA 2)
+  1: ((METHOD TRACE-AREA :BEFORE (<INTEGER>)) 2)
+  1: (METHOD TRACE-AREA :BEFORE (<INTEGER>)) returned NIL
+  1: ((METHOD TRACE-AREA (<INTEGER>)) 2)
+  1: (METHOD TRACE-AREA (<INTEGER>)) returned 4
+0: TRACE-AREA returned 4
+"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(untrace car)`,
+			want:    `'(car)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(untrace)`,
+			want:    `'(trace-area trace-fact)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(traced (trace-fact 2))`,
+			want:    `""`,
+			wantErr: false,
+		},
+	})
+}
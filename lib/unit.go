@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lib
+
+import "github.com/islisp-dev/iris/core"
+
+// Deftest defines the test named testName in the current suite. The forms are
+// evaluated as the body of a function of no arguments when the test is run,
+// and the test fails if they signal a condition, such as the
+// <assertion-failure> of an assertion which does not hold.
+func Deftest(e core.Environment, testName core.Instance, forms ...core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.SymbolClass, testName); err != nil {
+		return nil, err
+	}
+	fun, err := newNamedFunction(e, testName, Nil, forms...)
+	if err != nil {
+		return nil, err
+	}
+	e.Runtime.DefineTest(&core.Test{
+		Name:     testName,
+		Suite:    e.Runtime.CurrentSuite(),
+		Location: core.LocationOf(testName),
+		Function: fun,
+	})
+	return testName, nil
+}
+
+// Defsuite defines the suite of tests suiteName and makes it the current
+// suite, which the tests defined next belong to until the end of the file
+// being loaded. Each option is one of:
+//
+//	(:setup form*)     the forms evaluated before each test of the suite
+//	(:teardown form*)  the forms evaluated after each test of the suite, even
+//	                   if it failed
+func Defsuite(e core.Environment, suiteName core.Instance, options ...core.Instance) (core.Instance, core.Instance) {
+	if err := ensure(e, core.SymbolClass, suiteName); err != nil {
+		return nil, err
+	}
+	suite := &core.Suite{Name: suiteName}
+	for _, option := range options {
+		list, ok := option.(*core.Cons)
+		if !ok {
+			return SignalCondition(e, core.NewDomainError(e, option, core.ConsClass), Nil)
+		}
+		fun, err := newNamedFunction(e, suiteName, Nil, list.Cdr.(core.List).Slice()...)
+		if err != nil {
+			return nil, err
+		}
+		switch list.Car.String() {
+		case ":SETUP":
+			suite.Setup = fun
+		case ":TEARDOWN":
+			suite.Teardown = fun
+		default:
+			return SignalCondition(e, core.NewDomainError(e, list.Car, core.SymbolClass), Nil)
+		}
+	}
+	e.Runtime.DefineSuite(suite)
+	return suiteName, nil
+}
+
+// InSuite makes the suite of tests suiteName current, or leaves the suites
+// if suiteName is nil.
+func InSuite(e core.Environment, suiteName core.Instance) (core.Instance, core.Instance) {
+	if core.DeepEqual(suiteName, Nil) {
+		e.Runtime.SetCurrentSuite(nil)
+		return Nil, nil
+	}
+	suite, ok := e.Runtime.Suite(suiteName.String())
+	if !ok {
+		return SignalCondition(e, core.NewUndefinedSuite(e, suiteName), Nil)
+	}
+	e.Runtime.SetCurrentSuite(suite)
+	return suiteName, nil
+}
+
+// AssertTrue evaluates form and returns its value. An <assertion-failure> is
+// signaled if it is nil.
+func AssertTrue(e core.Environment, form core.Instance) (core.Instance, core.Instance) {
+	ret, err := Eval(e, form)
+	if err != nil {
+		return nil, err
+	}
+	if core.DeepEqual(ret, Nil) {
+		return assertionFailure(e, "~S is false", form)
+	}
+	return ret, nil
+}
+
+// AssertEqual evaluates expected and form and returns the value of form. An
+// <assertion-failure> is signaled if the values are not equal.
+func AssertEqual(e core.Environment, expected, form core.Instance) (core.Instance, core.Instance) {
+	want, err := Eval(e, expected)
+	if err != nil {
+		return nil, err
+	}
+	got, err := Eval(e, form)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := Equal(e, want, got); core.DeepEqual(ok, Nil) {
+		return assertionFailure(e, "~S returned ~S, expected ~S", form, got, want)
+	}
+	return got, nil
+}
+
+// AssertError evaluates form and returns the condition it signals, which
+// must be an instance of the class className. An <assertion-failure> is
+// signaled if form returns, or signals a condition of another class.
+func AssertError(e core.Environment, className, form core.Instance) (core.Instance, core.Instance) {
+	class, err := Class(e, className)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := Eval(e.NewHandler(core.SilentHandler), form)
+	switch {
+	case err == nil:
+		return assertionFailure(e, "~S returned ~S, expected ~A to be signaled", form, ret, class)
+	case !core.InstanceOf(core.SeriousConditionClass, err):
+		return nil, err
+	case !core.InstanceOf(class, err):
+		return assertionFailure(e, "~S signaled ~A, expected ~A", form, err.Class(), class)
+	}
+	return err, nil
+}
+
+func assertionFailure(e core.Environment, format string, arguments ...core.Instance) (core.Instance, core.Instance) {
+	list, _ := List(e, arguments...)
+	return SignalCondition(e, core.NewAssertionFailure(e, core.NewString([]rune(format)), list), Nil)
+}
+
+// RunTest runs the test with the fixtures of its suite, and returns the
+// condition it failed with, or nil if it passed. The teardown of the suite is
+// run even if the test failed, and its own failure is returned if the test
+// passed.
+func RunTest(e core.Environment, test *core.Test) core.Instance {
+	if test.Suite != nil && test.Suite.Setup != nil {
+		if _, err := test.Suite.Setup.(core.Applicable).Apply(e.NewDynamic()); err != nil {
+			return err
+		}
+	}
+	_, err := test.Function.(core.Applicable).Apply(e.NewDynamic())
+	if test.Suite != nil && test.Suite.Teardown != nil {
+		if _, terr := test.Suite.Teardown.(core.Applicable).Apply(e.NewDynamic()); err == nil {
+			err = terr
+		}
+	}
+	return err
+}
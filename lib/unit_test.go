@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+)
+
+func TestAssertions(t *testing.T) {
+	execTests(t, AssertEqual, []test{
+		{
+			exp:     `(assert-true (< 1 2))`,
+			want:    `t`,
+			wantErr: false,
+		},
+		{
+			exp:     `(assert-true (> 1 2))`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(assert-equal '(1 2) (list 1 2))`,
+			want:    `'(1 2)`,
+			wantErr: false,
+		},
+		{
+			exp: `(let ((s (create-string-output-stream)))
+			        (report-condition (assert-error <assertion-failure> (assert-equal 3 (+ 1 1))) s)
+			        (get-output-stream-string s))`,
+			want:    `"(+ 1 1) returned 2, expected 3"`,
+			wantErr: false,
+		},
+		{
+			exp:     `(class-of (assert-error <domain-error> (car 1)))`,
+			want:    `(class <domain-error>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(assert-error <error> (car '(1)))`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(class-of (assert-error <assertion-failure> (assert-error <arithmetic-error> (car 1))))`,
+			want:    `(class <assertion-failure>)`,
+			wantErr: false,
+		},
+		{
+			exp:     `(catch 'assert (assert-error <error> (throw 'assert 1)))`,
+			want:    `1`,
+			wantErr: false,
+		},
+	})
+}
+
+func TestDeftest(t *testing.T) {
+	execTests(t, Deftest, []test{
+		{
+			exp: `
+			(progn
+			  (defglobal unit-log nil)
+			  (defsuite unit-suite
+			    (:setup (setq unit-log (cons 'setup unit-log)))
+			    (:teardown (setq unit-log (cons 'teardown unit-log))))
+			  (deftest unit-pass (assert-equal 2 (+ 1 1)))
+			  (deftest unit-fail (assert-true nil))
+			  (in-suite nil)
+			  (deftest unit-alone t))
+			`,
+			want:    `'unit-alone`,
+			wantErr: false,
+		},
+		{
+			exp:     `(in-suite unit-missing)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+		{
+			exp:     `(deftest (unit-bad) t)`,
+			want:    `nil`,
+			wantErr: true,
+		},
+	})
+	tests := TopLevel.Runtime.Tests()
+	names := []string{}
+	for _, test := range tests {
+		names = append(names, test.FullName())
+	}
+	want := []string{"UNIT-SUITE/UNIT-PASS", "UNIT-SUITE/UNIT-FAIL", "UNIT-ALONE"}
+	if len(names) < 3 || names[len(names)-3] != want[0] || names[len(names)-2] != want[1] || names[len(names)-1] != want[2] {
+		t.Fatalf("tests = %v, want %v last", names, want)
+	}
+	tests = tests[len(tests)-3:]
+	if err := RunTest(TopLevel, tests[0]); err != nil {
+		t.Errorf("RunTest(%v) = %v, want nil", names[0], err)
+	}
+	if err := RunTest(TopLevel, tests[1]); err == nil || err.Class().String() != "<ASSERTION-FAILURE>" {
+		t.Errorf("RunTest(%v) = %v, want an <assertion-failure>", names[1], err)
+	}
+	log, _ := Eval(TopLevel, core.NewSymbol("UNIT-LOG"))
+	if got := log.String(); got != "(TEARDOWN SETUP TEARDOWN SETUP)" {
+		t.Errorf("unit-log = %v, want the fixtures run twice", got)
+	}
+}
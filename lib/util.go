@@ -5,6 +5,7 @@
 package lib
 
 import (
+	"math/big"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -33,17 +34,29 @@ func createList(es ...core.Instance) core.Instance {
 }
 
 func convFloat64(e core.Environment, x core.Instance) (float64, bool, core.Instance) {
-	switch {
-	case core.InstanceOf(core.IntegerClass, x):
-		return float64(x.(core.Integer)), false, nil
-	case core.InstanceOf(core.FloatClass, x):
-		return float64(x.(core.Float)), true, nil
+	switch x := x.(type) {
+	case core.Integer:
+		return float64(x), false, nil
+	case *core.BigInteger:
+		f, _ := new(big.Float).SetInt(x.Int()).Float64()
+		return f, false, nil
+	case core.Float:
+		return float64(x), true, nil
 	default:
 		_, err := SignalCondition(e, core.NewDomainError(e, x, core.NumberClass), Nil)
 		return 0.0, false, err
 	}
 }
 
+// bigInt returns the value of the integer x as a big.Int, which must not be
+// modified.
+func bigInt(x core.Instance) *big.Int {
+	if i, ok := x.(*core.BigInteger); ok {
+		return i.Int()
+	}
+	return big.NewInt(int64(x.(core.Integer)))
+}
+
 func readFromString(s string) (core.Instance, core.Instance) {
 	e := core.NewEnvironment(nil, nil, nil, core.DefaultHandler)
 	return parser.Parse(e, tokenizer.NewBufferedTokenReader(strings.NewReader(s)))
@@ -59,12 +72,8 @@ func ensure(e core.Environment, c core.Class, i ...core.Instance) core.Instance
 	return nil
 }
 
-var unique = 0
-
-func uniqueInt() int {
-	i := unique
-	unique++
-	return i
+func uniqueInt(e core.Environment) int {
+	return e.Runtime.UniqueInt()
 }
 
 func func2symbol(function interface{}) core.Instance {
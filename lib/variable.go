@@ -81,7 +81,7 @@ func Let(e core.Environment, varForm core.Instance, bodyForm ...core.Instance) (
 			return SignalCondition(e, core.NewImmutableBinding(e), Nil)
 		}
 	}
-	return Progn(e, bodyForm...)
+	return prognTail(e, bodyForm...)
 }
 
 // LetStar form is used to define a scope for a group of identifiers for a
@@ -116,5 +116,5 @@ func LetStar(e core.Environment, varForm core.Instance, bodyForm ...core.Instanc
 			return SignalCondition(e, core.NewImmutableBinding(e), Nil)
 		}
 	}
-	return Progn(e, bodyForm...)
+	return prognTail(e, bodyForm...)
 }
@@ -31,13 +31,16 @@ func GeneralVectorP(e core.Environment, obj core.Instance) (core.Instance, core.
 // cannot-create-vector). An error shall be signaled if i is not a non-negative
 // integer (error-id. domain-error). initial-element may be any ISLISP object.
 func CreateVector(e core.Environment, i core.Instance, initialElement ...core.Instance) (core.Instance, core.Instance) {
-	if !core.InstanceOf(core.IntegerClass, i) || int(i.(core.Integer)) < 0 {
-		return SignalCondition(e, core.NewDomainError(e, i, core.IntegerClass), Nil)
+	n, err := convSize(e, i)
+	if err != nil {
+		return nil, err
 	}
 	if len(initialElement) > 1 {
 		return SignalCondition(e, core.NewArityError(e), Nil)
 	}
-	n := int(i.(core.Integer))
+	if _, err := core.CountAllocation(e, n); err != nil {
+		return nil, err
+	}
 	v := make([]core.Instance, n)
 	for i := 0; i < n; i++ {
 		if len(initialElement) == 0 {
@@ -33,6 +33,11 @@ func TestCreateVector(t *testing.T) {
 			want:    `#(#\a #\a)`,
 			wantErr: false,
 		},
+		{
+			exp:     `(catch 'c (with-handler (lambda (c) (throw 'c (class-of c))) (create-vector 100000000000000000000000)))`,
+			want:    `(class <storage-exhausted>)`,
+			wantErr: false,
+		},
 	})
 }
 
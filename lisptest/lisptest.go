@@ -0,0 +1,301 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package lisptest runs the ISLisp files of a directory as Go subtests. The
+// cases of a file are its top level forms annotated by the comments following
+// them:
+//
+//	(+ 1 2)
+//	;=> 3
+//	(car 1)
+//	;!! <domain-error>
+//	(format (standard-output) "a~%b")
+//	;>> a
+//	;>> b
+//
+// ";=>" gives the value the form returns, as it is printed; ";!!" the class of
+// the condition the form signals; and ";>>" the lines the form prints to the
+// standard output. An annotation is a comment starting with a lone ";", so the
+// ";;" comments are prose. The forms without annotations are not cases. Each case is
+// run in a fresh runtime, once the forms before it in the file are evaluated.
+package lisptest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/reader/parser"
+	"github.com/islisp-dev/iris/reader/tokenizer"
+)
+
+// Case is an annotated form of a file.
+type Case struct {
+	File     string
+	Index    int // among the top level forms of the file
+	Location *core.Location
+	Source   string // the first line of the form
+	// Value is the printed value expected, if HasValue.
+	Value    string
+	HasValue bool
+	// Condition is the name of the class of the condition expected, or
+	// empty.
+	Condition string
+	// Output is the lines printed expected, if HasOutput.
+	Output    []string
+	HasOutput bool
+
+	annotated []bool // whether the forms of the file are cases, by index
+}
+
+// annotation matches a comment that is an annotation. A comment starting with
+// ";;" is prose, not an annotation.
+var annotation = regexp.MustCompile(`^;\s*(=>|!!|>>) ?(.*)$`)
+
+// scanner finds the comments of lines, skipping the semicolons in strings,
+// symbols, characters and block comments, which may span lines.
+type scanner struct {
+	delimiter byte // '"' or '|' when in a string or a symbol
+	depth     int  // of the nested block comments
+}
+
+// comment returns the index of the comment of line, or -1 if it has none.
+func (s *scanner) comment(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case s.depth > 0:
+			if strings.HasPrefix(line[i:], "|#") {
+				s.depth--
+				i++
+			} else if strings.HasPrefix(line[i:], "#|") {
+				s.depth++
+				i++
+			}
+		case s.delimiter != 0:
+			if c == '\\' {
+				i++
+			} else if c == s.delimiter {
+				s.delimiter = 0
+			}
+		case c == '"' || c == '|':
+			s.delimiter = c
+		case strings.HasPrefix(line[i:], "#\\"):
+			i += 2
+		case strings.HasPrefix(line[i:], "#|"):
+			s.depth++
+			i++
+		case c == ';':
+			return i
+		}
+	}
+	return -1
+}
+
+// Cases returns the cases of the file at path, in order.
+func Cases(path string) ([]*Case, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(src), "\n")
+	t := tokenizer.NewBufferedTokenReader(bytes.NewReader(src))
+	t.File = path
+	e := lib.NewRuntime()
+	locations := []*core.Location{} // of the forms
+	for {
+		form, err := parser.Parse(e, t)
+		if err != nil {
+			if core.InstanceOf(core.EndOfStreamClass, err) {
+				break
+			}
+			return nil, fmt.Errorf("%s: %s", path, lib.ReportString(e, err))
+		}
+		location := core.LocationOf(form)
+		if location == nil {
+			return nil, fmt.Errorf("%s: %v is not a form to annotate", path, form)
+		}
+		locations = append(locations, location)
+	}
+	cases := []*Case{}
+	annotated := make([]bool, len(locations))
+	for i, location := range locations {
+		start, end := location.Line, len(lines)
+		if i+1 < len(locations) {
+			end = locations[i+1].Line - 1
+		}
+		c := &Case{File: path, Index: i, Location: location, annotated: annotated}
+		c.Source = lines[start-1]
+		s := &scanner{}
+		for j, line := range lines[start-1 : end] {
+			k := s.comment(line)
+			if k < 0 {
+				continue
+			}
+			m := annotation.FindStringSubmatch(line[k:])
+			if m == nil {
+				continue
+			}
+			if j == 0 {
+				c.Source = line[:k]
+			}
+			switch m[1] {
+			case "=>":
+				c.Value, c.HasValue = strings.TrimSpace(m[2]), true
+			case "!!":
+				c.Condition = strings.TrimSpace(m[2])
+			case ">>":
+				c.Output, c.HasOutput = append(c.Output, m[2]), true
+			}
+		}
+		c.Source = strings.TrimSpace(c.Source)
+		if c.HasValue || c.HasOutput || c.Condition != "" {
+			cases = append(cases, c)
+			annotated[i] = true
+		}
+	}
+	return cases, nil
+}
+
+// Check runs the case in a fresh runtime, and returns an error describing the
+// differences from the annotations, if any. The conditions signaled by the
+// cases before it are left to their own checks.
+func (c *Case) Check() error {
+	src, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+	t := tokenizer.NewBufferedTokenReader(bytes.NewReader(src))
+	t.File = c.File
+	e := lib.NewRuntime()
+	output := new(bytes.Buffer)
+	e.StandardOutput = core.NewStream(nil, output, core.CharacterClass)
+	e.ErrorOutput = core.NewStream(nil, ioutil.Discard, core.CharacterClass)
+	var form core.Instance
+	for i := 0; i <= c.Index; i++ {
+		var err core.Instance
+		if form, err = parser.Parse(e, t); err != nil {
+			return fmt.Errorf("%v: %s", c.Location, lib.ReportString(e, err))
+		}
+		if i == c.Index {
+			break
+		}
+		if _, err := lib.Eval(e.NewHandler(core.SilentHandler), form); err != nil && !c.annotated[i] {
+			return fmt.Errorf("%v: the form at %v signaled %v: %s", c.Location, core.LocationOf(form), err.Class(), lib.ReportString(e, err))
+		}
+	}
+	output.Reset()
+	ret, cond := lib.Eval(e.NewHandler(core.SilentHandler), form)
+	e.StandardOutput.(core.Stream).Flush()
+	problems := []string{}
+	switch {
+	case c.Condition != "":
+		class, err := lib.Class(e, core.NewSymbol(strings.ToUpper(c.Condition)))
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("the class %s is undefined", c.Condition))
+		case cond == nil:
+			problems = append(problems, fmt.Sprintf("returned %v, want %v signaled", ret, class))
+		case !core.InstanceOf(class, cond):
+			problems = append(problems, fmt.Sprintf("signaled %v: %s, want %v", cond.Class(), lib.ReportString(e, cond), class))
+		}
+	case cond != nil:
+		problems = append(problems, fmt.Sprintf("signaled %v: %s", cond.Class(), lib.ReportString(e, cond)))
+	case c.HasValue:
+		if got, want := fmt.Sprint(ret), printed(e, c.Value); got != want {
+			problems = append(problems, fmt.Sprintf("returned %s, want %s", got, want))
+		}
+	}
+	if c.HasOutput {
+		got := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		if diff := diff(c.Output, got); diff != "" {
+			problems = append(problems, "printed, -want +got:\n"+diff)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%v: %s %s", c.Location, c.Source, strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// printed returns the value of text as it is printed, or text itself if it
+// cannot be read.
+func printed(e core.Environment, text string) string {
+	t := tokenizer.NewBufferedTokenReader(strings.NewReader(text))
+	form, err := parser.Parse(e, t)
+	if err != nil {
+		return text
+	}
+	if _, err := parser.Parse(e, t); !core.InstanceOf(core.EndOfStreamClass, err) {
+		return text
+	}
+	return fmt.Sprint(form)
+}
+
+// diff returns the lines of want and got, marked with - and + where they
+// differ, or "" if they are the same.
+func diff(want, got []string) string {
+	if strings.Join(want, "\n") == strings.Join(got, "\n") {
+		return ""
+	}
+	b := new(strings.Builder)
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i < len(want) && i < len(got) && want[i] == got[i]:
+			fmt.Fprintf(b, "  %s\n", want[i])
+		default:
+			if i < len(want) {
+				fmt.Fprintf(b, "- %s\n", want[i])
+			}
+			if i < len(got) {
+				fmt.Fprintf(b, "+ %s\n", got[i])
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// RunFile runs the cases of the file at path as subtests of t, named after
+// their lines and sources.
+func RunFile(t *testing.T, path string) {
+	t.Helper()
+	cases, err := Cases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(fmt.Sprintf("%d:%s", c.Location.Line, c.Source), func(t *testing.T) {
+			if err := c.Check(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Run runs the cases of the files of dir whose names end in .lsp as subtests
+// of t, one for each file.
+func Run(t *testing.T, dir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.lsp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no .lsp files in %s", dir)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			RunFile(t, file)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lisptest
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	Run(t, "testdata/pass")
+}
+
+func TestCases(t *testing.T) {
+	cases, err := Cases("testdata/pass/basic.lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2:(+ 1 2) => 3",
+		"5:(twice 'a) !! <domain-error>",
+		"7:(list 'a \"b\" #\\c (twice 2)) => (a \"b\" #\\c 4)",
+		"8:(progn => done >> [one two]",
+		"14:(length \"a;=> b\") => 6",
+		"16:(length (string-append \"x => 16",
+		"19:(char= #\\; #\\;) => t",
+	}
+	if len(cases) != len(want) {
+		t.Fatalf("got %d cases, want %d", len(cases), len(want))
+	}
+	for i, c := range cases {
+		got := fmt.Sprintf("%d:%s", c.Location.Line, c.Source)
+		if c.HasValue {
+			got += " => " + c.Value
+		}
+		if c.Condition != "" {
+			got += " !! " + c.Condition
+		}
+		if c.HasOutput {
+			got += fmt.Sprintf(" >> %v", c.Output)
+		}
+		if got != want[i] {
+			t.Errorf("case %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases, err := Cases("testdata/fail/wrong.lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"testdata/fail/wrong.lsp:1:1: (+ 1 2) returned 3, want 4",
+		"testdata/fail/wrong.lsp:3:1: (car 1) signaled <DOMAIN-ERROR>: 1 is not an instance of <CONS>",
+		"testdata/fail/wrong.lsp:5:1: (+ 1 2) returned 3, want <DOMAIN-ERROR> signaled",
+		"testdata/fail/wrong.lsp:7:1: (format (standard-output) \"one~%three~%\") printed, -want +got:\n  one\n- two\n+ three",
+	}
+	if len(cases) != len(want) {
+		t.Fatalf("got %d cases, want %d", len(cases), len(want))
+	}
+	for i, c := range cases {
+		err := c.Check()
+		if err == nil || err.Error() != want[i] {
+			t.Errorf("Check() = %v, want %q", err, want[i])
+		}
+	}
+}
@@ -0,0 +1,9 @@
+(+ 1 2)
+;=> 4
+(car 1)
+;=> 1
+(+ 1 2)
+;!! <domain-error>
+(format (standard-output) "one~%three~%")
+;>> one
+;>> two
@@ -0,0 +1,20 @@
+;; Cases of the annotations understood by lisptest.
+(+ 1 2)
+;=> 3
+(defun twice (x) (* x 2))
+(twice 'a)
+;!! <domain-error>
+(list 'a "b" #\c (twice 2)) ;=> (a "b" #\c 4)
+(progn
+  (format (standard-output) "one~%two~%")
+  'done)
+;=> done
+;>> one
+;>> two
+(length "a;=> b") ;=> 6
+;; => prose, not an annotation
+(length (string-append "x
+;=> y" "#|;=> z|#"))
+;=> 16
+(char= #\; #\;)
+;=> t
@@ -0,0 +1,286 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/reader/parser"
+	"github.com/islisp-dev/iris/reader/tokenizer"
+)
+
+// point is a position in a document by its line and the runes before it in
+// the line, from 0.
+type point struct {
+	line, column int
+}
+
+// document is a text document opened by the client. Its text is the one of
+// the client, which may not be saved.
+type document struct {
+	uri         string
+	text        string
+	lines       [][]rune
+	definitions []*definition
+}
+
+// definition is a top level form defining a name.
+type definition struct {
+	operator  string // DEFUN, DEFCLASS and so on
+	name      string // the name of the symbol defined
+	label     string // the name as it is written
+	signature string // the form up to its lambda list or superclasses, closed
+	doc       string // the docstring of the body, if any
+	start     point  // of the form
+	end       point  // of the form
+	nameStart point
+	nameEnd   point
+}
+
+// heads are the numbers of the elements of the definition forms shown in
+// their signatures.
+var heads = map[string]int{
+	"DEFCLASS":    3,
+	"DEFCONSTANT": 2,
+	"DEFDYNAMIC":  2,
+	"DEFGENERIC":  3,
+	"DEFGLOBAL":   2,
+	"DEFMACRO":    3,
+	"DEFMETHOD":   3,
+	"DEFUN":       3,
+}
+
+// newDocument reads the forms of text in the environment e, up to the first
+// read error, for the definitions they make.
+func newDocument(e core.Environment, uri, text string) *document {
+	d := &document{uri: uri, text: text, lines: splitLines(text)}
+	t := tokenizer.NewBufferedTokenReader(strings.NewReader(text))
+	t.File = uri
+	e = e.NewHandler(core.SilentHandler)
+	for {
+		form, err := parser.Parse(e, t)
+		if err != nil {
+			break
+		}
+		if def := d.define(form); def != nil {
+			d.definitions = append(d.definitions, def)
+		}
+	}
+	return d
+}
+
+// splitLines returns the lines of text.
+func splitLines(text string) [][]rune {
+	lines := [][]rune{}
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, []rune(line))
+	}
+	return lines
+}
+
+// define returns the definition made by a top level form, or nil.
+func (d *document) define(form core.Instance) *definition {
+	cons, ok := form.(*core.Cons)
+	if !ok || cons.Location() == nil {
+		return nil
+	}
+	operator := cons.Car.String()
+	head, ok := heads[operator]
+	if !ok {
+		return nil
+	}
+	args := []core.Instance{}
+	for x := cons.Cdr; ; {
+		c, ok := x.(*core.Cons)
+		if !ok {
+			break
+		}
+		args = append(args, c.Car)
+		x = c.Cdr
+	}
+	if len(args) == 0 || !core.InstanceOf(core.SymbolClass, args[0]) || core.LocationOf(args[0]) == nil {
+		return nil
+	}
+	def := &definition{operator: operator, name: args[0].String(), start: d.pointOf(cons.Location())}
+	def.nameStart = d.pointOf(core.LocationOf(args[0]))
+	def.nameEnd = d.end(def.nameStart)
+	def.label = d.slice(def.nameStart, def.nameEnd)
+	body := head - 1
+	if operator == "DEFMETHOD" {
+		for body < len(args) && core.InstanceOf(core.SymbolClass, args[body-1]) && args[body-1] != core.Nil {
+			body++
+		}
+		head = body + 1
+	}
+	ends := d.elements(def.start)
+	def.end = d.end(def.start)
+	if len(ends) > 0 {
+		last := ends[len(ends)-1]
+		if head <= len(ends) {
+			last = ends[head-1]
+		}
+		def.signature = strings.Join(strings.Fields(d.slice(def.start, last)), " ") + ")"
+	}
+	switch operator {
+	case "DEFUN", "DEFMACRO", "DEFMETHOD":
+		if body+1 < len(args) && core.InstanceOf(core.StringClass, args[body]) {
+			def.doc = string(args[body].(core.String))
+		}
+	}
+	return def
+}
+
+// pointOf returns the point of a location of the document.
+func (d *document) pointOf(l *core.Location) point {
+	return point{l.Line - 1, l.Column - 1}
+}
+
+// slice returns the text between the points.
+func (d *document) slice(start, end point) string {
+	b := new(strings.Builder)
+	for line := start.line; line <= end.line && line < len(d.lines); line++ {
+		runes := d.lines[line]
+		from, to := 0, len(runes)
+		if line == start.line {
+			from = start.column
+		}
+		if line == end.line {
+			to = end.column
+		}
+		if from > to || to > len(runes) {
+			break
+		}
+		if line > start.line {
+			b.WriteString("\n")
+		}
+		b.WriteString(string(runes[from:to]))
+	}
+	return b.String()
+}
+
+// scanForm scans the form beginning at start, and returns the point it ends
+// at and, if it is a list, the points its elements end at.
+func (d *document) scanForm(start point) (end point, elements []point) {
+	s := new(scanner)
+	depth := 0
+	done := false
+	for line := start.line; line < len(d.lines) && !done; line++ {
+		offset := 0
+		if line == start.line {
+			offset = start.column
+		}
+		if offset > len(d.lines[line]) {
+			break
+		}
+		s.scan(d.lines[line][offset:], func(kind tokenKind, from, to int) {
+			if done {
+				return
+			}
+			at := point{line, offset + to}
+			switch kind {
+			case tokenOpen, tokenString:
+				depth++
+				return
+			case tokenClose, tokenStringEnd:
+				depth--
+			case tokenPrefix:
+				return
+			}
+			switch {
+			case depth <= 0:
+				end, done = at, true
+			case depth == 1:
+				elements = append(elements, at)
+			}
+		})
+	}
+	if !done {
+		last := len(d.lines) - 1
+		end = point{last, len(d.lines[last])}
+	}
+	return end, elements
+}
+
+// end returns the point the form beginning at start ends at.
+func (d *document) end(start point) point {
+	end, _ := d.scanForm(start)
+	return end
+}
+
+// elements returns the points the elements of the list beginning at start
+// end at, the operator first.
+func (d *document) elements(start point) []point {
+	_, elements := d.scanForm(start)
+	return elements
+}
+
+// position returns the position of a point as the client counts it, in
+// UTF-16 code units.
+func (d *document) position(p point) Position {
+	if p.line >= len(d.lines) {
+		return Position{Line: p.line}
+	}
+	runes := d.lines[p.line]
+	if p.column > len(runes) {
+		p.column = len(runes)
+	}
+	return Position{Line: p.line, Character: len(utf16.Encode(runes[:p.column]))}
+}
+
+// rangeOf returns the range between the points.
+func (d *document) rangeOf(start, end point) Range {
+	return Range{d.position(start), d.position(end)}
+}
+
+// checkPosition returns an error if a position of the client is before the
+// beginning of any document. The positions after its end are those of its
+// end.
+func checkPosition(p Position) *Error {
+	if p.Line < 0 || p.Character < 0 {
+		return &Error{InvalidParams, fmt.Sprintf("position %d:%d is out of the document", p.Line, p.Character)}
+	}
+	return nil
+}
+
+// point returns the point of a position of the client.
+func (d *document) point(p Position) point {
+	if p.Line >= len(d.lines) {
+		return point{p.Line, 0}
+	}
+	units := 0
+	for i, r := range d.lines[p.Line] {
+		if units >= p.Character {
+			return point{p.Line, i}
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return point{p.Line, len(d.lines[p.Line])}
+}
+
+// word returns the start and the end of the name around p, which are the
+// same if there is none.
+func (d *document) word(p point) (point, point) {
+	if p.line >= len(d.lines) {
+		return p, p
+	}
+	runes := d.lines[p.line]
+	start, end := p.column, p.column
+	for start > 0 && !delimiter(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && !delimiter(runes[end]) {
+		end++
+	}
+	return point{p.line, start}, point{p.line, end}
+}
+
+// fullRange returns the range of the whole text.
+func (d *document) fullRange() Range {
+	last := len(d.lines) - 1
+	return d.rangeOf(point{0, 0}, point{last, len(d.lines[last])})
+}
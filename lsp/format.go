@@ -0,0 +1,282 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lsp
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenOpen      tokenKind = iota // (
+	tokenClose                      // )
+	tokenAtom                       // a symbol, a number or a character
+	tokenString                     // the start of a string
+	tokenStringEnd                  // the end of a string
+	tokenPrefix                     // ' ` , ,@ #' or the # of a vector or an array
+)
+
+// scanner splits the lines of a source into tokens, keeping the strings and
+// the block comments that go on across the lines.
+type scanner struct {
+	inString bool
+	comments int // the depth of the block comments
+}
+
+// scan calls emit with the kind and the columns of the tokens of the line,
+// from 0, a token starting at start and ending before end. The comments are
+// skipped.
+func (s *scanner) scan(line []rune, emit func(kind tokenKind, start, end int)) {
+	at := func(i int, r rune) bool { return i < len(line) && line[i] == r }
+	for i := 0; i < len(line); {
+		switch {
+		case s.inString:
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i < len(line) {
+				emit(tokenStringEnd, i, i+1)
+				s.inString = false
+				i++
+			}
+		case s.comments > 0:
+			switch {
+			case at(i, '|') && at(i+1, '#'):
+				s.comments--
+				i += 2
+			case at(i, '#') && at(i+1, '|'):
+				s.comments++
+				i += 2
+			default:
+				i++
+			}
+		case unicode.IsSpace(line[i]):
+			i++
+		case line[i] == ';':
+			return
+		case line[i] == '(':
+			emit(tokenOpen, i, i+1)
+			i++
+		case line[i] == ')':
+			emit(tokenClose, i, i+1)
+			i++
+		case line[i] == '"':
+			emit(tokenString, i, i+1)
+			s.inString = true
+			i++
+		case line[i] == '\'' || line[i] == '`':
+			emit(tokenPrefix, i, i+1)
+			i++
+		case line[i] == ',':
+			j := i + 1
+			if at(j, '@') {
+				j++
+			}
+			emit(tokenPrefix, i, j)
+			i = j
+		case line[i] == '#' && at(i+1, '|'):
+			s.comments++
+			i += 2
+		case line[i] == '#' && at(i+1, '\''):
+			emit(tokenPrefix, i, i+2)
+			i += 2
+		case line[i] == '#' && at(i+1, '\\'):
+			j := i + 3
+			for j < len(line) && !delimiter(line[j]) {
+				j++
+			}
+			if j > len(line) {
+				j = len(line)
+			}
+			emit(tokenAtom, i, j)
+			i = j
+		case line[i] == '#' && (at(i+1, '(') || i+1 < len(line) && unicode.IsDigit(line[i+1])):
+			j := i + 1
+			for j < len(line) && unicode.IsDigit(line[j]) {
+				j++
+			}
+			if at(j, 'a') || at(j, 'A') {
+				j++
+			}
+			emit(tokenPrefix, i, j)
+			i = j
+		default:
+			j := i
+			for j < len(line) && !delimiter(line[j]) {
+				if line[j] == '|' {
+					for j++; j < len(line) && line[j] != '|'; j++ {
+						if line[j] == '\\' {
+							j++
+						}
+					}
+				}
+				j++
+			}
+			if j > len(line) {
+				j = len(line)
+			}
+			emit(tokenAtom, i, j)
+			i = j
+		}
+	}
+}
+
+// delimiter reports whether r ends an atom.
+func delimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("()\";'`,", r)
+}
+
+// bodies are the numbers of the arguments before the bodies of the forms
+// whose bodies are indented by two columns.
+var bodies = map[string]int{
+	"BLOCK":                 1,
+	"CASE":                  1,
+	"CASE-USING":            2,
+	"CATCH":                 1,
+	"DEFCLASS":              2,
+	"DEFGENERIC":            2,
+	"DEFMACRO":              2,
+	"DEFMETHOD":             2,
+	"DEFMODULE":             1,
+	"DEFSUITE":              1,
+	"DEFTEST":               1,
+	"DEFUN":                 2,
+	"DYNAMIC-LET":           1,
+	"FLET":                  1,
+	"FOR":                   2,
+	"IGNORE-ERRORS":         0,
+	"LABELS":                1,
+	"LAMBDA":                1,
+	"LET":                   1,
+	"LET*":                  1,
+	"PROGN":                 0,
+	"TAGBODY":               0,
+	"UNWIND-PROTECT":        1,
+	"WHILE":                 1,
+	"WITH-ERROR-OUTPUT":     1,
+	"WITH-HANDLER":          1,
+	"WITH-OPEN-INPUT-FILE":  1,
+	"WITH-OPEN-OUTPUT-FILE": 1,
+	"WITH-PROFILING":        1,
+	"WITH-STANDARD-INPUT":   1,
+	"WITH-STANDARD-OUTPUT":  1,
+}
+
+// list is a list open at a line being indented.
+type list struct {
+	column   int    // of the parenthesis
+	line     int    // of the parenthesis
+	data     bool   // quoted, or a vector or an array
+	operator string // the first element if it is an atom
+	elements int
+	argument int  // the column of the second element if it is on the line of the parenthesis, or -1
+	body     int  // the arguments before the body, or -1
+	local    bool // the definitions of flet or labels
+}
+
+// indent returns the column the elements of the list begin at on a new line.
+func (l *list) indent() int {
+	switch {
+	case l.data || l.elements == 0:
+		return l.column + 1
+	case l.body >= 0 && l.elements-1 >= l.body:
+		return l.column + 2
+	case l.body >= 0:
+		return l.column + 4
+	case l.argument >= 0 && l.operator != "":
+		return l.argument
+	}
+	return l.column + 1
+}
+
+// Format returns the text with its lines indented as the forms they are in,
+// by the usual style of Lisp, and without their trailing spaces. The lines
+// going on in strings and block comments are left as they are.
+func Format(text string) string {
+	lines := strings.Split(text, "\n")
+	s := new(scanner)
+	lists := []*list{}
+	quote := 0        // 1 if the next list is quoted, -1 if it is unquoted
+	prefixed := false // whether the next element follows a prefix
+	for n, line := range lines {
+		continued := s.inString || s.comments > 0
+		rest := strings.TrimLeft(line, " \t")
+		if !continued {
+			column := 0
+			if len(lists) > 0 && rest != "" {
+				column = lists[len(lists)-1].indent()
+			}
+			line = strings.Repeat(" ", column) + rest
+		}
+		runes := []rune(line)
+		element := func(start int) bool {
+			if prefixed {
+				prefixed = false
+				return false
+			}
+			if len(lists) == 0 {
+				return false
+			}
+			l := lists[len(lists)-1]
+			l.elements++
+			if l.elements == 2 && l.line == n {
+				l.argument = start
+			}
+			return true
+		}
+		s.scan(runes, func(kind tokenKind, start, end int) {
+			switch kind {
+			case tokenOpen:
+				element(start)
+				l := &list{column: start, line: n, data: quote > 0, argument: -1, body: -1}
+				if len(lists) > 0 {
+					outer := lists[len(lists)-1]
+					l.data = l.data || quote == 0 && outer.data
+					if outer.local {
+						l.body = 1
+					}
+					if (outer.operator == "FLET" || outer.operator == "LABELS") && outer.elements == 2 {
+						l.local = true
+					}
+				}
+				lists = append(lists, l)
+				quote = 0
+			case tokenClose:
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				quote, prefixed = 0, false
+			case tokenPrefix:
+				element(start)
+				prefixed = true
+				quote = 1
+				if token := string(runes[start:end]); token == "," || token == ",@" || token == "#'" {
+					quote = -1
+				}
+			case tokenAtom, tokenString:
+				if element(start) && kind == tokenAtom {
+					l := lists[len(lists)-1]
+					if l.elements == 1 {
+						l.operator = strings.ToUpper(string(runes[start:end]))
+						if body, ok := bodies[l.operator]; ok && !l.data {
+							l.body = body
+						}
+					}
+				}
+				quote = 0
+			}
+		})
+		if !s.inString {
+			line = strings.TrimRight(line, " \t\r")
+		}
+		lines[n] = line
+	}
+	return strings.Join(lines, "\n")
+}
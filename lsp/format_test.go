@@ -0,0 +1,59 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lsp
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"body",
+			"(defun f (x)\n(let ((y 1)\n(z 2))\n(+ x y z)))\n",
+			"(defun f (x)\n  (let ((y 1)\n        (z 2))\n    (+ x y z)))\n",
+		},
+		{
+			"arguments",
+			"(list 1\n2\n   3)\n(list\n1)\n",
+			"(list 1\n      2\n      3)\n(list\n 1)\n",
+		},
+		{
+			"distinguished arguments",
+			"(defun f\n(x)\nx)\n",
+			"(defun f\n    (x)\n  x)\n",
+		},
+		{
+			"cond",
+			"(cond ((= x 1) 'one)\n((= x 2)\n'two))",
+			"(cond ((= x 1) 'one)\n      ((= x 2)\n       'two))",
+		},
+		{
+			"flet",
+			"(flet ((f (x)\nx)\n(g (y)\ny))\n(f (g 1)))",
+			"(flet ((f (x)\n         x)\n       (g (y)\n         y))\n  (f (g 1)))",
+		},
+		{
+			"data",
+			"'(let a\nb)\n#(defun 1\n2)\n`(let ,(list 1\n2)\nc)",
+			"'(let a\n  b)\n#(defun 1\n  2)\n`(let ,(list 1\n             2)\n  c)",
+		},
+		{
+			"strings and comments",
+			"(f \"a (\n  b\" ; (\n#| ( \n|# #\\( x   \ny)",
+			"(f \"a (\n  b\" ; (\n   #| (\n|# #\\( x\n   y)",
+		},
+		{
+			"blank lines",
+			"(progn\n   \n\t(f))\n\n",
+			"(progn\n\n  (f))\n\n",
+		},
+	}
+	for _, test := range tests {
+		if got := Format(test.src); got != test.want {
+			t.Errorf("%s: Format(%q) = %q, want %q", test.name, test.src, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package lsp is a server of the Language Server Protocol for ISLisp. It
+// reports the errors found by the reader and the static checker, and offers
+// the completion of names, hovers, definitions, document symbols and
+// formatting. The documents are analyzed as the client has them, saved or
+// not.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/islisp-dev/iris/checker"
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/protocol"
+)
+
+// Server serves a client over a pair of streams, one message at a time.
+type Server struct {
+	in          *bufio.Reader
+	out         io.Writer
+	env         core.Environment // the builtins
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer returns a server reading the messages of the client from r and
+// writing its own to w.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		in:        bufio.NewReader(r),
+		out:       w,
+		env:       lib.NewRuntime(),
+		documents: map[string]*document{},
+	}
+}
+
+// Run serves the client until it sends exit, or until r ends. It returns an
+// error if the client exits without shutting the server down first, or if
+// the messages cannot be read or written.
+func (s *Server) Run() error {
+	for {
+		content, err := protocol.ReadMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			if err := s.reply(nil, nil, &Error{ParseError, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+		result, rerr := s.handle(req.Method, req.Params)
+		if req.ID == nil {
+			continue
+		}
+		if err := s.reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err *Error) error {
+	raw := json.RawMessage("null")
+	if id != nil {
+		raw = *id
+	}
+	if err != nil {
+		return protocol.WriteMessage(s.out, errorResponse{"2.0", raw, err})
+	}
+	return protocol.WriteMessage(s.out, response{"2.0", raw, result})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	protocol.WriteMessage(s.out, notification{"2.0", method, params})
+}
+
+// handle handles a request or a notification, and returns the result of a
+// request. A panic in handling it is returned as an internal error, so that
+// the server keeps serving the client.
+func (s *Server) handle(method string, params json.RawMessage) (result interface{}, rerr *Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, rerr = nil, &Error{InternalError, fmt.Sprintf("%s: %v", method, r)}
+		}
+	}()
+	decode := func(v interface{}) *Error {
+		if err := json.Unmarshal(params, v); err != nil {
+			return &Error{InvalidParams, err.Error()}
+		}
+		if p, ok := v.(*TextDocumentPositionParams); ok {
+			return checkPosition(p.Position)
+		}
+		return nil
+	}
+	if s.shutdown {
+		return nil, &Error{InvalidRequest, "the server is shut down"}
+	}
+	if method == "initialize" {
+		s.initialized = true
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           map[string]interface{}{"openClose": true, "change": 1},
+				"completionProvider":         map[string]interface{}{},
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentSymbolProvider":     true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "iris"},
+		}, nil
+	}
+	if !s.initialized {
+		return nil, &Error{ServerNotInitialized, "the server is not initialized"}
+	}
+	switch method {
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		s.documents[p.TextDocument.URI] = newDocument(s.env, p.TextDocument.URI, p.TextDocument.Text)
+		s.publish(p.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		d, ok := s.documents[p.TextDocument.URI]
+		if !ok || len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := d.text
+		for _, change := range p.ContentChanges {
+			if change.Range == nil {
+				text = change.Text
+				continue
+			}
+			if err := checkPosition(change.Range.Start); err != nil {
+				return nil, err
+			}
+			if err := checkPosition(change.Range.End); err != nil {
+				return nil, err
+			}
+			d := &document{lines: splitLines(text)}
+			text = d.slice(point{0, 0}, d.point(change.Range.Start)) + change.Text + d.slice(d.point(change.Range.End), point{len(d.lines), 0})
+		}
+		s.documents[d.uri] = newDocument(s.env, d.uri, text)
+		s.publish(d.uri)
+		return nil, nil
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		delete(s.documents, p.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{p.TextDocument.URI, []Diagnostic{}})
+		s.publish("")
+		return nil, nil
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return s.completion(p), nil
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return s.hover(p), nil
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return s.definition(p), nil
+	case "textDocument/documentSymbol":
+		var p DocumentSymbolParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return s.symbols(p.TextDocument.URI), nil
+	case "textDocument/formatting":
+		var p DocumentFormattingParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return s.format(p.TextDocument.URI), nil
+	}
+	if strings.HasPrefix(method, "$/") || method == "initialized" || method == "textDocument/didSave" {
+		return nil, nil
+	}
+	return nil, &Error{MethodNotFound, fmt.Sprintf("method %s not found", method)}
+}
+
+// uris returns the URIs of the documents in order.
+func (s *Server) uris() []string {
+	uris := []string{}
+	for uri := range s.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// publish publishes the diagnostics of all the documents, which are checked
+// together so that the names they define are known to each other. The
+// diagnostics without locations are given to the document of the URI
+// changed.
+func (s *Server) publish(changed string) {
+	c := checker.New()
+	diagnostics := map[string][]Diagnostic{}
+	for _, uri := range s.uris() {
+		c.Add(uri, strings.NewReader(s.documents[uri].text))
+		diagnostics[uri] = []Diagnostic{}
+	}
+	for _, found := range c.Check() {
+		uri, start := changed, point{0, 0}
+		if found.Location != nil {
+			uri, start = found.Location.File, point{found.Location.Line - 1, found.Location.Column - 1}
+		}
+		d, ok := s.documents[uri]
+		if !ok {
+			continue
+		}
+		end := d.end(start)
+		if end.line != start.line && start.line < len(d.lines) {
+			end = point{start.line, len(d.lines[start.line])}
+		}
+		diagnostics[uri] = append(diagnostics[uri], Diagnostic{
+			Range:    d.rangeOf(start, end),
+			Severity: 1,
+			Source:   "iris",
+			Message:  found.Message,
+		})
+	}
+	for _, uri := range s.uris() {
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{uri, diagnostics[uri]})
+	}
+}
+
+// lookup returns the definitions of the name in the documents.
+func (s *Server) lookup(name string) []*definition {
+	found := []*definition{}
+	for _, uri := range s.uris() {
+		for _, def := range s.documents[uri].definitions {
+			if def.name == name {
+				found = append(found, def)
+			}
+		}
+	}
+	return found
+}
+
+// completion returns the names defined by the builtins and the documents
+// which begin with the name before the position.
+func (s *Server) completion(p TextDocumentPositionParams) []CompletionItem {
+	d, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return []CompletionItem{}
+	}
+	at := d.point(p.Position)
+	start, _ := d.word(at)
+	prefix := strings.ToUpper(d.slice(start, at))
+	items := map[string]CompletionItem{}
+	add := func(name string, item CompletionItem) {
+		if _, ok := items[name]; !ok && strings.HasPrefix(name, prefix) {
+			items[name] = item
+		}
+	}
+	for _, uri := range s.uris() {
+		for _, def := range s.documents[uri].definitions {
+			kind := map[string]int{"DEFCLASS": CompletionClass, "DEFGLOBAL": CompletionVariable, "DEFDYNAMIC": CompletionVariable, "DEFCONSTANT": CompletionConstant}[def.operator]
+			if kind == 0 {
+				kind = CompletionFunction
+			}
+			add(def.name, CompletionItem{Label: def.label, Kind: kind, Detail: def.signature})
+		}
+	}
+	for _, table := range []struct {
+		keys   []core.Instance
+		kind   int
+		detail string
+	}{
+		{s.env.Special.Keys(), CompletionKeyword, "special operator"},
+		{s.env.Macro.Keys(), CompletionFunction, "macro"},
+		{s.env.Function.Keys(), CompletionFunction, "function"},
+		{s.env.Class.Keys(), CompletionClass, "class"},
+		{s.env.Constant.Keys(), CompletionConstant, "constant"},
+		{s.env.Variable.Keys(), CompletionVariable, "variable"},
+	} {
+		for _, key := range table.keys {
+			detail := table.detail
+			if shape, ok := lib.Syntax(key.String()); ok && table.kind == CompletionKeyword {
+				detail = shape
+			}
+			add(key.String(), CompletionItem{Label: strings.ToLower(key.String()), Kind: table.kind, Detail: detail})
+		}
+	}
+	result := []CompletionItem{}
+	for _, item := range items {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Label < result[j].Label })
+	return result
+}
+
+// hover describes the name at the position: its signatures and docstrings
+// if the documents define it, or what it is among the builtins.
+func (s *Server) hover(p TextDocumentPositionParams) *Hover {
+	d, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	start, end := d.word(d.point(p.Position))
+	word := d.slice(start, end)
+	if word == "" {
+		return nil
+	}
+	name := strings.ToUpper(word)
+	parts := []string{}
+	for _, def := range s.lookup(name) {
+		part := "```islisp\n" + def.signature + "\n```"
+		if def.doc != "" {
+			part += "\n\n" + def.doc
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		parts = s.describe(name)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	r := d.rangeOf(start, end)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: strings.Join(parts, "\n\n---\n\n")}, Range: &r}
+}
+
+// describe describes what the builtin name is.
+func (s *Server) describe(name string) []string {
+	symbol := core.NewSymbol(name)
+	lower := strings.ToLower(name)
+	parts := []string{}
+	if _, ok := s.env.Special.Get(symbol); ok {
+		part := "special operator"
+		if shape, ok := lib.Syntax(name); ok {
+			part = "```islisp\n" + shape + "\n```\n\n" + part
+		}
+		parts = append(parts, part)
+	}
+	if _, ok := s.env.Macro.Get(symbol); ok {
+		parts = append(parts, fmt.Sprintf("`%s` is a macro", lower))
+	}
+	if fun, ok := s.env.Function.Get(symbol); ok {
+		kind := "function"
+		if core.InstanceOf(core.GenericFunctionClass, fun) {
+			kind = "generic function"
+		}
+		if f, ok := fun.(interface{ Arity() (int, bool) }); ok {
+			required, rest := f.Arity()
+			arity := fmt.Sprintf("%d argument", required)
+			if rest {
+				arity = "at least " + arity
+			}
+			if required != 1 {
+				arity += "s"
+			}
+			kind += " of " + arity
+		}
+		parts = append(parts, fmt.Sprintf("`%s` is a %s", lower, kind))
+	}
+	if value, ok := s.env.Constant.Get(symbol); ok {
+		parts = append(parts, fmt.Sprintf("`%s` is a constant whose value is `%v`", lower, value))
+	}
+	if _, ok := s.env.Class.Get(symbol); ok {
+		parts = append(parts, fmt.Sprintf("`%s` is a class", lower))
+	}
+	return parts
+}
+
+// definition returns the locations of the definitions of the name at the
+// position.
+func (s *Server) definition(p TextDocumentPositionParams) []Location {
+	locations := []Location{}
+	d, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return locations
+	}
+	start, end := d.word(d.point(p.Position))
+	name := strings.ToUpper(d.slice(start, end))
+	for _, uri := range s.uris() {
+		d := s.documents[uri]
+		for _, def := range d.definitions {
+			if def.name == name {
+				locations = append(locations, Location{uri, d.rangeOf(def.nameStart, def.nameEnd)})
+			}
+		}
+	}
+	return locations
+}
+
+// symbols returns the definitions of a document.
+func (s *Server) symbols(uri string) []SymbolInformation {
+	symbols := []SymbolInformation{}
+	d, ok := s.documents[uri]
+	if !ok {
+		return symbols
+	}
+	for _, def := range d.definitions {
+		kind := map[string]int{"DEFCLASS": SymbolClass, "DEFMETHOD": SymbolMethod, "DEFGLOBAL": SymbolVariable, "DEFDYNAMIC": SymbolVariable, "DEFCONSTANT": SymbolConstant}[def.operator]
+		if kind == 0 {
+			kind = SymbolFunction
+		}
+		symbols = append(symbols, SymbolInformation{def.label, kind, Location{uri, d.rangeOf(def.start, def.end)}})
+	}
+	return symbols
+}
+
+// format returns the edit formatting a document, if it changes it.
+func (s *Server) format(uri string) []TextEdit {
+	edits := []TextEdit{}
+	d, ok := s.documents[uri]
+	if !ok {
+		return edits
+	}
+	if text := Format(d.text); text != d.text {
+		edits = append(edits, TextEdit{d.fullRange(), text})
+	}
+	return edits
+}
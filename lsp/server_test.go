@@ -0,0 +1,309 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/protocol"
+)
+
+// client is a client scripted by a test, talking to a server over pipes.
+type client struct {
+	*protocol.Client
+	t           *testing.T
+	id          int
+	diagnostics map[string][]Diagnostic // the last published by URI
+}
+
+func newClient(t *testing.T) *client {
+	serve := func(r io.Reader, w io.Writer) error { return NewServer(r, w).Run() }
+	return &client{Client: protocol.NewClient(serve), t: t, diagnostics: map[string][]Diagnostic{}}
+}
+
+func (c *client) send(v interface{}) {
+	c.t.Helper()
+	if err := c.Send(v); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// call sends a request and decodes the result of its response into result,
+// recording the diagnostics published meanwhile. It returns the error of
+// the response.
+func (c *client) call(method string, params, result interface{}) *Error {
+	c.t.Helper()
+	c.id++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+	for m := range c.Messages {
+		if method, ok := m["method"]; ok {
+			if string(method) == `"textDocument/publishDiagnostics"` {
+				var p PublishDiagnosticsParams
+				json.Unmarshal(m["params"], &p)
+				c.diagnostics[p.URI] = p.Diagnostics
+			}
+			continue
+		}
+		if string(m["id"]) != fmt.Sprint(c.id) {
+			c.t.Fatalf("response %s to request %d", m["id"], c.id)
+		}
+		if raw, ok := m["error"]; ok {
+			var err Error
+			json.Unmarshal(raw, &err)
+			return &err
+		}
+		if result != nil {
+			if err := json.Unmarshal(m["result"], result); err != nil {
+				c.t.Fatalf("%s returned %s: %v", method, m["result"], err)
+			}
+		}
+		return nil
+	}
+	c.t.Fatalf("the server closed before responding to %s: %v", method, c.Err)
+	return nil
+}
+
+func at(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocumentIdentifier{uri}, Position{line, character}}
+}
+
+const (
+	shapes = "file:///shapes.lsp"
+	main   = "file:///main.lsp"
+)
+
+var shapesText = `(defclass <square> () ((side :reader side :initarg side)))
+(defun area (s)
+  "Returns the area of the square s."
+  (* (side s) (side s)))
+(defgeneric perimeter (s))
+(defmethod perimeter ((s <square>))
+  (* 4 (side s)))
+`
+
+var mainText = `(defglobal *unit* (create (class <square>) 'side 2))
+(defun report ()
+(format (standard-output) "~A ~A~%"
+(area *unit*) (perimeter *unit* 1)))
+(aera *unit*)
+`
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+	if err := c.call("textDocument/hover", at(main, 0, 0), nil); err == nil || err.Code != ServerNotInitialized {
+		t.Errorf("hover before initialize = %v, want an error", err)
+	}
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	if err := c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &init); err != nil {
+		t.Fatal(err)
+	}
+	for _, capability := range []string{"completionProvider", "hoverProvider", "definitionProvider", "documentSymbolProvider", "documentFormattingProvider"} {
+		if _, ok := init.Capabilities[capability]; !ok {
+			t.Errorf("initialize lacks %s", capability)
+		}
+	}
+	c.notify("initialized", struct{}{})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocumentItem{URI: shapes, Text: shapesText, Version: 1}})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocumentItem{URI: main, Text: mainText, Version: 1}})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocumentIdentifier{main}}, nil)
+		got := []string{}
+		for _, d := range c.diagnostics[main] {
+			got = append(got, show(d.Range)+" "+d.Message)
+		}
+		want := []string{
+			"3:14-3:34 PERIMETER takes 1 argument but is given 2",
+			"4:1-4:5 undefined function AERA",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("diagnostics of main = %q, want %q", got, want)
+		}
+		if len(c.diagnostics[shapes]) != 0 {
+			t.Errorf("diagnostics of shapes = %v, want none", c.diagnostics[shapes])
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		var items []CompletionItem
+		c.call("textDocument/completion", at(main, 3, 4), &items)
+		labels := map[string]CompletionItem{}
+		for _, item := range items {
+			labels[item.Label] = item
+		}
+		if item, ok := labels["area"]; !ok || item.Kind != CompletionFunction || item.Detail != "(defun area (s))" {
+			t.Errorf("completion of ar = %v, want area", items)
+		}
+		if _, ok := labels["aref"]; !ok {
+			t.Errorf("completion of ar = %v, want aref", items)
+		}
+		if _, ok := labels["car"]; ok {
+			t.Errorf("completion of ar = %v, want no car", items)
+		}
+		c.call("textDocument/completion", at(main, 0, 36), &items)
+		if len(items) != 1 || items[0].Label != "<square>" || items[0].Kind != CompletionClass {
+			t.Errorf("completion of <sq = %v, want <square>", items)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		tests := []struct {
+			position TextDocumentPositionParams
+			want     string
+		}{
+			{at(main, 3, 3), "```islisp\n(defun area (s))\n```\n\nReturns the area of the square s."},
+			{at(main, 3, 17), "```islisp\n(defgeneric perimeter (s))\n```\n\n---\n\n```islisp\n(defmethod perimeter ((s <square>)))\n```"},
+			{at(main, 2, 3), "`format` is a function of at least 2 arguments"},
+			{at(main, 1, 2), "```islisp\n(defun function-name lambda-list form*)\n```\n\nspecial operator"},
+		}
+		for _, test := range tests {
+			var hover Hover
+			c.call("textDocument/hover", test.position, &hover)
+			if hover.Contents.Value != test.want {
+				t.Errorf("hover at %v = %q, want %q", test.position.Position, hover.Contents.Value, test.want)
+			}
+		}
+		var hover *Hover
+		c.call("textDocument/hover", at(main, 2, 26), &hover)
+		if hover != nil {
+			t.Errorf("hover in a string = %v, want nil", hover)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var locations []Location
+		c.call("textDocument/definition", at(main, 3, 18), &locations)
+		got := []string{}
+		for _, l := range locations {
+			got = append(got, l.URI+" "+show(l.Range))
+		}
+		want := []string{shapes + " 4:12-4:21", shapes + " 5:11-5:20"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("definition of perimeter = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("symbols", func(t *testing.T) {
+		var symbols []SymbolInformation
+		c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocumentIdentifier{shapes}}, &symbols)
+		got := []string{}
+		for _, s := range symbols {
+			got = append(got, s.Name+" "+show(s.Location.Range))
+		}
+		want := []string{"<square> 0:0-0:58", "area 1:0-3:24", "perimeter 4:0-4:26", "perimeter 5:0-6:17"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("symbols of shapes = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("formatting", func(t *testing.T) {
+		var edits []TextEdit
+		c.call("textDocument/formatting", DocumentFormattingParams{TextDocumentIdentifier{main}}, &edits)
+		want := `(defglobal *unit* (create (class <square>) 'side 2))
+(defun report ()
+  (format (standard-output) "~A ~A~%"
+          (area *unit*) (perimeter *unit* 1)))
+(aera *unit*)
+`
+		if len(edits) != 1 || edits[0].NewText != want || show(edits[0].Range) != "0:0-5:0" {
+			t.Errorf("formatting of main = %v, want %q", edits, want)
+		}
+		c.call("textDocument/formatting", DocumentFormattingParams{TextDocumentIdentifier{shapes}}, &edits)
+		if len(edits) != 0 {
+			t.Errorf("formatting of shapes = %v, want none", edits)
+		}
+	})
+
+	t.Run("change", func(t *testing.T) {
+		fixed := strings.Replace(strings.Replace(mainText, "aera", "area", 1), " *unit* 1)", " *unit*)", 1)
+		c.notify("textDocument/didChange", DidChangeTextDocumentParams{TextDocumentIdentifier{main}, []TextDocumentContentChangeEvent{{Text: fixed}}})
+		c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocumentIdentifier{main}}, nil)
+		if len(c.diagnostics[main]) != 0 {
+			t.Errorf("diagnostics of the main fixed = %v, want none", c.diagnostics[main])
+		}
+		r := Range{Position{1, 7}, Position{1, 13}}
+		c.notify("textDocument/didChange", DidChangeTextDocumentParams{TextDocumentIdentifier{main}, []TextDocumentContentChangeEvent{{Range: &r, Text: "summary"}}})
+		var symbols []SymbolInformation
+		c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocumentIdentifier{main}}, &symbols)
+		if len(symbols) != 2 || symbols[1].Name != "summary" {
+			t.Errorf("symbols of main changed = %v, want *unit* and summary", symbols)
+		}
+		c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocumentIdentifier{shapes}})
+		c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocumentIdentifier{main}}, nil)
+		if len(c.diagnostics[main]) != 3 {
+			t.Errorf("diagnostics of main without shapes = %v, want 3", c.diagnostics[main])
+		}
+	})
+
+	if err := c.call("workspace/symbol", map[string]string{"query": ""}, nil); err == nil || err.Code != MethodNotFound {
+		t.Errorf("workspace/symbol = %v, want an error", err)
+	}
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.call("textDocument/hover", at(main, 0, 1), nil); err == nil || err.Code != InvalidRequest {
+		t.Errorf("hover after shutdown = %v, want an invalid request", err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.Done; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+}
+
+// show shows a range as "line:character-line:character".
+func show(r Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
+
+func TestPositions(t *testing.T) {
+	c := newClient(t)
+	c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, nil)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocumentItem{URI: main, Text: mainText, Version: 1}})
+	for _, method := range []string{"textDocument/hover", "textDocument/completion", "textDocument/definition"} {
+		if err := c.call(method, at(main, -1, 0), nil); err == nil || err.Code != InvalidParams {
+			t.Errorf("%s at -1:0 = %v, want an error", method, err)
+		}
+		if err := c.call(method, at(main, 0, -1), nil); err == nil || err.Code != InvalidParams {
+			t.Errorf("%s at 0:-1 = %v, want an error", method, err)
+		}
+		if err := c.call(method, at(main, 100, 100), nil); err != nil {
+			t.Errorf("%s at 100:100 = %v, want nil", method, err)
+		}
+	}
+	r := Range{Position{-1, 0}, Position{0, 1}}
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{TextDocumentIdentifier{main}, []TextDocumentContentChangeEvent{{Range: &r, Text: "x"}}})
+	var symbols []SymbolInformation
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocumentIdentifier{main}}, &symbols); err != nil || len(symbols) != 2 {
+		t.Errorf("symbols after a change out of main = %v, %v, want main unchanged", symbols, err)
+	}
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.Done; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+}
+
+func TestHandlePanic(t *testing.T) {
+	s := NewServer(strings.NewReader(""), ioutil.Discard)
+	s.initialized = true
+	s.documents[main] = &document{uri: main, text: "(defun f ()\n1)"} // without lines
+	params, _ := json.Marshal(DocumentFormattingParams{TextDocumentIdentifier{main}})
+	if _, err := s.handle("textDocument/formatting", params); err == nil || err.Code != InternalError {
+		t.Errorf("formatting of a broken document = %v, want an internal error", err)
+	}
+}
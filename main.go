@@ -7,69 +7,219 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	golang "runtime"
 
+	"github.com/islisp-dev/iris/checker"
 	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/dap"
 	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/lsp"
+	"github.com/islisp-dev/iris/repl"
+	"github.com/islisp-dev/iris/unit"
 )
 
 var commit string
 
-func repl(quiet bool) {
+// historyFile returns the file of the REPL history, $IRIS_HISTORY or
+// ~/.iris_history.
+func historyFile() string {
+	if path, ok := os.LookupEnv("IRIS_HISTORY"); ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".iris_history")
+}
+
+func interact(quiet, debug bool) {
 	if !quiet {
 		if commit == "" {
 			commit = "HEAD"
 		}
 		fmt.Printf("Iris ISLisp Interpreter Commit %v on %v\n", commit, golang.Version())
 		fmt.Printf("Copyright 2017 islisp-dev All Rights Reserved.\n")
-		fmt.Print(">>> ")
 	}
-	lib.TopLevel.StandardInput = core.NewStream(os.Stdin, nil, core.CharacterClass)
 	lib.TopLevel.StandardOutput = core.NewStream(nil, os.Stdout, core.CharacterClass)
 	lib.TopLevel.ErrorOutput = core.NewStream(nil, os.Stderr, core.CharacterClass)
-	for exp, err := lib.Read(lib.TopLevel); err == nil; exp, err = lib.Read(lib.TopLevel) {
-		ret, err := lib.Eval(lib.TopLevel, exp)
-		if err != nil {
-			fmt.Println(err)
-		} else {
-			fmt.Println(ret)
+	r := repl.New(lib.TopLevel, os.Stdin, os.Stdout)
+	r.Quiet = quiet
+	r.Debug = debug
+	r.HistoryFile = historyFile()
+	if err := r.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// script loads the files in order and reports whether they all loaded.
+func script(paths ...string) bool {
+	lib.TopLevel.StandardOutput = core.NewStream(nil, os.Stdout, core.CharacterClass)
+	lib.TopLevel.ErrorOutput = core.NewStream(nil, os.Stderr, core.CharacterClass)
+	for _, path := range paths {
+		if !repl.Load(lib.TopLevel, os.Stdout, path) {
+			return false
 		}
-		if !quiet {
-			fmt.Print(">>> ")
+	}
+	return true
+}
+
+// run loads the files as script does. With -profile, the applications of the
+// Lisp functions are profiled and the profile is written in the format of
+// pprof. With -coverage, the forms of the files evaluated and the branches
+// taken are written in the tracefile format of lcov, or as HTML if the name
+// of the file ends in .html.
+func run(args ...string) bool {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	output := flags.String("profile", "", "write a pprof profile of the Lisp functions to this file")
+	coverage := flags.String("coverage", "", "write the coverage of the files as lcov, or HTML for .html, to this file")
+	flags.Parse(args)
+	profile := core.NewProfile()
+	if *output != "" {
+		lib.TopLevel.Runtime.StartProfile(profile)
+	}
+	covered := core.NewCoverage()
+	if *coverage != "" {
+		lib.TopLevel.Runtime.StartCoverage(covered)
+	}
+	ok := script(flags.Args()...)
+	lib.TopLevel.Runtime.StopProfile(profile)
+	lib.TopLevel.Runtime.StopCoverage()
+	if *output != "" && !writeFile(*output, profile.WritePprof) {
+		ok = false
+	}
+	write := covered.WriteLcov
+	if filepath.Ext(*coverage) == ".html" {
+		write = covered.WriteHTML
+	}
+	if *coverage != "" && !writeFile(*coverage, write) {
+		ok = false
+	}
+	return ok
+}
+
+// writeFile writes the file at path with write, and reports whether it is
+// written.
+func writeFile(path string, write func(io.Writer) error) bool {
+	file, err := os.Create(path)
+	if err == nil {
+		err = write(file)
+		if cerr := file.Close(); err == nil {
+			err = cerr
 		}
 	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return true
 }
 
-func script(path string) {
-	file, err := os.Open(path)
+// test runs the tests of the files of tests found in the paths, or in the
+// current directory, and writes their results in the format given by
+// -format. It reports whether they all passed.
+func test(args ...string) bool {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	format := flags.String("format", "text", "write the results as text, tap or junit")
+	flags.Parse(args)
+	write, ok := map[string]func(io.Writer, []unit.Result) error{
+		"text":  unit.WriteText,
+		"tap":   unit.WriteTAP,
+		"junit": unit.WriteJUnit,
+	}[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return false
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := unit.Find(paths...)
 	if err != nil {
-		return
+		fmt.Fprintln(os.Stderr, err)
+		return false
 	}
-	defer file.Close()
-	lib.TopLevel.StandardInput = core.NewStream(file, nil, core.CharacterClass)
-	lib.TopLevel.StandardOutput = core.NewStream(nil, os.Stdout, core.CharacterClass)
-	lib.TopLevel.ErrorOutput = core.NewStream(nil, os.Stderr, core.CharacterClass)
-	for {
-		exp, err := lib.Read(lib.TopLevel)
-		if err != nil {
-			if fmt.Sprint(err) != "#<END-OF-STREAM >" {
-				fmt.Println(err)
-			}
-			return
-		}
-		_, err = lib.Eval(lib.TopLevel, exp)
-		if err != nil {
-			fmt.Println(err)
-			return
+	// The output of the tests is kept apart from the results.
+	output := io.Writer(os.Stdout)
+	if *format != "text" {
+		output = os.Stderr
+	}
+	results := []unit.Result{}
+	for _, file := range files {
+		results = append(results, unit.RunFile(file, output)...)
+	}
+	if err := write(os.Stdout, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return unit.Passed(results)
+}
+
+// check reads the files with the static checker, prints the errors found and
+// reports whether there are none.
+func check(paths ...string) bool {
+	c := checker.New()
+	ok := true
+	for _, path := range paths {
+		if err := c.AddFile(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			ok = false
 		}
 	}
+	diagnostics := c.Check()
+	for _, d := range diagnostics {
+		fmt.Println(d)
+	}
+	return ok && len(diagnostics) == 0
 }
 
 func main() {
+	debug := flag.Bool("debug", false, "check the classes declared by the THE forms")
+	debugger := flag.Bool("debugger", false, "open the debugger on the conditions not handled in the REPL")
+	depth := flag.Int("depth", core.DefaultStackDepth, "signal <storage-exhausted> beyond this call depth")
 	flag.Parse()
+	lib.TopLevel.Runtime.Debug = *debug
+	lib.TopLevel.Runtime.StackDepth = *depth
+	if flag.Arg(0) == "run" {
+		if !run(flag.Args()[1:]...) {
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "test" {
+		if !test(flag.Args()[1:]...) {
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "lsp" {
+		if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "dap" {
+		if err := dap.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "check" {
+		if !check(flag.Args()[1:]...) {
+			os.Exit(1)
+		}
+		return
+	}
 	if flag.NArg() > 0 {
-		script(flag.Arg(0))
+		if !script(flag.Args()...) {
+			os.Exit(1)
+		}
 		return
 	}
 	info, err := os.Stdin.Stat()
@@ -77,9 +227,9 @@ func main() {
 		panic(err)
 	}
 	if (info.Mode() & os.ModeNamedPipe) == 0 {
-		repl(false)
+		interact(false, *debugger)
 		return
 	}
-	repl(true)
+	interact(true, *debugger)
 	return
 }
@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package protocol frames the JSON messages of the language server and the
+// debug adapter, which both use the base protocol of LSP: each message is
+// preceded by a header giving its Content-Length.
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// ReadMessage reads the content of a message framed by its Content-Length
+// header.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// WriteMessage writes v in JSON framed by its Content-Length header.
+func WriteMessage(w io.Writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// Client is a client talking to a server over a pair of pipes, as the tests
+// of the servers script it. The messages of the server are decoded into
+// Messages, which is closed when the server closes its output or sends a
+// message which is not a JSON object; Err tells which after it is closed.
+type Client struct {
+	Messages chan map[string]json.RawMessage
+	Done     chan error // receives the error returned by serve
+	Err      error
+	w        io.WriteCloser
+}
+
+// NewClient runs serve with the ends of the pipes of a new client.
+func NewClient(serve func(r io.Reader, w io.Writer) error) *Client {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &Client{Messages: make(chan map[string]json.RawMessage, 100), Done: make(chan error, 1), w: inW}
+	go func() {
+		c.Done <- serve(inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		defer close(c.Messages)
+		r := bufio.NewReader(outR)
+		for {
+			content, err := ReadMessage(r)
+			if err != nil {
+				if err != io.EOF {
+					c.Err = err
+				}
+				return
+			}
+			var m map[string]json.RawMessage
+			if err := json.Unmarshal(content, &m); err != nil {
+				c.Err = fmt.Errorf("the server sent %q: %v", content, err)
+				return
+			}
+			c.Messages <- m
+		}
+	}()
+	return c
+}
+
+// Send sends v to the server.
+func (c *Client) Send(v interface{}) error {
+	return WriteMessage(c.w, v)
+}
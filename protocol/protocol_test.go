@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	buf := new(bytes.Buffer)
+	for _, v := range []interface{}{map[string]int{"id": 1}, "ü"} {
+		if err := WriteMessage(buf, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "Content-Length: 8\r\n\r\n{\"id\":1}Content-Length: 4\r\n\r\n\"ü\""; buf.String() != want {
+		t.Errorf("written = %q, want %q", buf, want)
+	}
+	r := bufio.NewReader(buf)
+	for _, want := range []string{`{"id":1}`, `"ü"`} {
+		content, err := ReadMessage(r)
+		if err != nil || string(content) != want {
+			t.Errorf("ReadMessage() = %q, %v, want %q", content, err, want)
+		}
+	}
+	for _, input := range []string{"Content-Length: x\r\n\r\n", "Content-Length: -1\r\n\r\n", "Content-Length: 5\r\n\r\n{}"} {
+		if content, err := ReadMessage(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("ReadMessage(%q) = %q, want an error", input, content)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	c := NewClient(func(r io.Reader, w io.Writer) error {
+		content, err := ReadMessage(bufio.NewReader(r))
+		if err != nil {
+			return err
+		}
+		w.Write([]byte("Content-Length: 2\r\n\r\n"))
+		w.Write(content)
+		return WriteMessage(w, "not an object")
+	})
+	if err := c.Send(map[string]int{}); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := <-c.Messages; !ok || len(m) != 0 {
+		t.Errorf("message = %v, %v, want {}", m, ok)
+	}
+	if m, ok := <-c.Messages; ok {
+		t.Errorf("message = %v, want the messages closed", m)
+	}
+	if c.Err == nil {
+		t.Errorf("Err = nil, want the string rejected")
+	}
+	if err := <-c.Done; err != nil {
+		t.Errorf("serve() = %v", err)
+	}
+}
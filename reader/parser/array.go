@@ -25,3 +25,10 @@ func list2array(dim int, list core.Instance) (core.Instance, core.Instance) {
 func list2vector(list core.Instance) (core.Instance, core.Instance) {
 	return core.NewGeneralVector(list.(core.List).Slice()), nil
 }
+
+func isProperList(list core.Instance) bool {
+	for core.InstanceOf(core.ConsClass, list) {
+		list = list.(*core.Cons).Cdr
+	}
+	return list == core.Nil
+}
@@ -5,129 +5,294 @@
 package parser
 
 import (
-	"math"
+	"io"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/islisp-dev/iris/reader/tokenizer"
 	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/reader/tokenizer"
 )
 
-var eop = core.NewSymbol("End Of Parentheses")
-var bod = core.NewSymbol("Begin Of Dot")
+var (
+	decimal     = regexp.MustCompile(`^[-+]?[[:digit:]]+$`)
+	binary      = regexp.MustCompile(`^#[bB]([-+]?[01]+)$`)
+	octal       = regexp.MustCompile(`^#[oO]([-+]?[0-7]+)$`)
+	hexadecimal = regexp.MustCompile(`^#[xX]([-+]?[[:xdigit:]]+)$`)
+	float       = regexp.MustCompile(`^[-+]?[[:digit:]]+(?:\.[[:digit:]]+|(?:\.[[:digit:]]+)?[eE][-+]?[[:digit:]]+)$`)
+	array       = regexp.MustCompile(`^#([[:digit:]]*)[aA]$`)
+)
 
 func ParseAtom(e core.Environment, tok *tokenizer.Token) (core.Instance, core.Instance) {
 	str := tok.Str
 	//
 	// integer
 	//
-	if m, _ := regexp.MatchString("^[-+]?[[:digit:]]+$", str); m {
-		n, _ := strconv.ParseInt(str, 10, 64)
-		return core.NewInteger(int(n)), nil
+	if decimal.MatchString(str) {
+		return parseInteger(str, 10), nil
 	}
-	if r := regexp.MustCompile("^#[bB]([-+]?[01]+)$").FindStringSubmatch(str); len(r) >= 2 {
-		n, _ := strconv.ParseInt(r[1], 2, 64)
-		return core.NewInteger(int(n)), nil
+	if r := binary.FindStringSubmatch(str); len(r) >= 2 {
+		return parseInteger(r[1], 2), nil
 	}
-	if r := regexp.MustCompile("^#[oO]([-+]?[0-7]+)$").FindStringSubmatch(str); len(r) >= 2 {
-		n, _ := strconv.ParseInt(r[1], 8, 64)
-		return core.NewInteger(int(n)), nil
+	if r := octal.FindStringSubmatch(str); len(r) >= 2 {
+		return parseInteger(r[1], 8), nil
 	}
-	if r := regexp.MustCompile("^#[xX]([-+]?[[:xdigit:]]+)$").FindStringSubmatch(str); len(r) >= 2 {
-		n, _ := strconv.ParseInt(r[1], 16, 64)
-		return core.NewInteger(int(n)), nil
+	if r := hexadecimal.FindStringSubmatch(str); len(r) >= 2 {
+		return parseInteger(r[1], 16), nil
 	}
 	//
 	// float
 	//
-	if m, _ := regexp.MatchString(`^[-+]?[[:digit:]]+\.[[:digit:]]+$`, str); m {
-		n, _ := strconv.ParseFloat(str, 64)
+	if float.MatchString(str) {
+		n, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return parseError(e, tok, core.FloatClass)
+		}
 		return core.NewFloat(n), nil
 	}
-	if r := regexp.MustCompile(`^([-+]?[[:digit:]]+(?:\.[[:digit:]]+)?)[eE]([-+]?[[:digit:]]+)$`).FindStringSubmatch(str); len(r) >= 3 {
-		n, _ := strconv.ParseFloat(r[1], 64)
-		e, _ := strconv.ParseInt(r[2], 10, 64)
-		return core.NewFloat(n * math.Pow10(int(e))), nil
-	}
 	//
 	// character
 	//
-	if m, _ := regexp.MatchString(`^#\\newline$`, strings.ToLower(str)); m {
-		return core.NewCharacter('\n'), nil
-	}
-	if m, _ := regexp.MatchString(`^#\\space$`, strings.ToLower(str)); m {
-		return core.NewCharacter(' '), nil
-	}
-	if r := regexp.MustCompile(`^#\\([[:graph:]])$`).FindStringSubmatch(str); len(r) >= 2 {
-		return core.NewCharacter(rune(r[1][0])), nil
+	if strings.HasPrefix(str, `#\`) {
+		switch name := str[2:]; {
+		case strings.EqualFold(name, "newline"):
+			return core.NewCharacter('\n'), nil
+		case strings.EqualFold(name, "space"):
+			return core.NewCharacter(' '), nil
+		case utf8.RuneCountInString(name) == 1:
+			r, _ := utf8.DecodeRuneInString(name)
+			return core.NewCharacter(r), nil
+		}
+		return parseError(e, tok, core.CharacterClass)
 	}
 	//
 	// string
 	//
-	if r := regexp.MustCompile(`^"(.*)"$`).FindStringSubmatch(str); len(r) >= 2 {
-		s := strings.Replace(r[1], "\\\\", "\\", -1)
-		return core.NewString([]rune(s)), nil
+	if len(str) >= 2 && str[0] == '"' && str[len(str)-1] == '"' {
+		return core.NewString([]rune(unescape(str[1 : len(str)-1]))), nil
 	}
 	//
 	// symbol
 	//
-	if str == "nil" || str == "NIL" {
+	if len(str) >= 2 && str[0] == '|' && str[len(str)-1] == '|' {
+		return intern(e, tok, "", unescape(str[1:len(str)-1]), false)
+	}
+	if strings.EqualFold(str, "nil") {
 		return core.Nil, nil
 	}
-	re := `^(`
-	re += `[:&](?:[a-zA-Z]|-)+|`
-	re += `\|.*\||`
-	re += `\+|-|1\+|1-|`
-	re += `[a-zA-Z<>/*=?_!$%[\]^{}~][-a-zA-Z0-9+<>/*=?_!$%[\]^{}~]*|`
-	re += `)$`
-	if m, _ := regexp.MatchString(re, str); m {
-		return core.NewSymbol(strings.ToUpper(str), tok.Line, tok.Column), nil
-	}
-	return core.SignalCondition(
-		e,
-		core.NewParseError(
-			e,
-			core.NewString([]rune(str)),
-			core.ObjectClass,
-		),
-		core.Nil,
-	)
+	if isIdentifier(str) {
+		return intern(e, tok, "", strings.ToUpper(str), false)
+	}
+	if module, name, internal, ok := qualified(str); ok {
+		return intern(e, tok, strings.ToUpper(module), strings.ToUpper(name), internal)
+	}
+	return parseError(e, tok, core.ObjectClass)
+}
+
+// qualified splits a symbol qualified by a module, written module:name, or
+// module::name to refer to a name the module does not export.
+func qualified(str string) (module, name string, internal, ok bool) {
+	i := strings.IndexByte(str, ':')
+	if i <= 0 {
+		return "", "", false, false
+	}
+	module, name = str[:i], str[i+1:]
+	if strings.HasPrefix(name, ":") {
+		name, internal = name[1:], true
+	}
+	if !isIdentifier(module) || !isIdentifier(name) || name[0] == ':' || name[0] == '&' {
+		return "", "", false, false
+	}
+	return module, name, internal, true
+}
+
+// intern returns the symbol read in the current module with core.Intern.
+func intern(e core.Environment, tok *tokenizer.Token, module, name string, internal bool) (core.Instance, core.Instance) {
+	symbol, err := core.Intern(e, module, name, internal, location(tok))
+	if err != nil {
+		return nil, core.PushFrame(err, core.NewSymbol("READ"), location(tok))
+	}
+	return symbol, nil
+}
+
+// unescape removes the backslashes escaping the characters of a string or a
+// symbol between bars.
+func unescape(str string) string {
+	if !strings.ContainsRune(str, '\\') {
+		return str
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range str {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isIdentifier reports whether str is the name of a symbol. Letters of any
+// script may be used as well as the ASCII characters of ISLISP.
+func isIdentifier(str string) bool {
+	switch str {
+	case "+", "-", "1+", "1-":
+		return true
+	case "":
+		return false
+	}
+	if str[0] == ':' || str[0] == '&' {
+		str = str[1:]
+		if str == "" {
+			return false
+		}
+	}
+	for i, r := range str {
+		switch {
+		case unicode.IsLetter(r), strings.ContainsRune("<>/*=?_!$%[]^{}~", r):
+		case i > 0 && (unicode.IsDigit(r) || unicode.IsMark(r) || r == '-' || r == '+'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseInteger returns the integer written in str with an optional sign and
+// digits of the base, which have already been matched.
+func parseInteger(str string, base int) core.Instance {
+	if n, err := strconv.ParseInt(str, base, 0); err == nil {
+		return core.NewInteger(int(n))
+	}
+	n, _ := new(big.Int).SetString(str, base)
+	return core.NewBigInteger(n)
+}
+
+// location returns the location of the token, or nil if it is unknown.
+func location(tok *tokenizer.Token) *core.Location {
+	if tok.Line < 0 {
+		return nil
+	}
+	return core.NewLocation(tok.File, tok.Line, tok.Column)
+}
+
+// parseError signals a <parse-error> for the token, whose location is recorded
+// in the stack trace.
+func parseError(e core.Environment, tok *tokenizer.Token, expectedClass core.Class) (core.Instance, core.Instance) {
+	str := core.NewString([]rune(tok.Str))
+	err := core.NewParseError(e, str, expectedClass)
+	return core.SignalCondition(e, core.PushFrame(err, core.NewSymbol("READ"), location(tok)), core.Nil)
+}
+
+// readError signals a <parse-error> for an error of the tokenizer.
+func readError(e core.Environment, t *tokenizer.BufferedTokenReader, err error) (core.Instance, core.Instance) {
+	if err, ok := err.(*tokenizer.Error); ok {
+		var expectedClass core.Class = core.ObjectClass
+		switch {
+		case strings.HasPrefix(err.Str, `"`):
+			expectedClass = core.StringClass
+		case strings.HasPrefix(err.Str, `|`):
+			expectedClass = core.SymbolClass
+		case strings.HasPrefix(err.Str, `#\`):
+			expectedClass = core.CharacterClass
+		}
+		return parseError(e, &tokenizer.Token{Str: err.Str, Line: err.Line, Column: err.Column, File: t.File}, expectedClass)
+	}
+	return core.SignalCondition(e, core.NewStreamError(e, core.Nil), core.Nil)
+}
+
+// Parse builds a internal expression from tokens. At the end of the input it
+// signals an <end-of-stream>, while an object which is cut off by the end of
+// the input or is malformed signals a <parse-error> at its location.
+func Parse(e core.Environment, t *tokenizer.BufferedTokenReader) (core.Instance, core.Instance) {
+	tok, err := next(t)
+	if err == io.EOF {
+		return core.SignalCondition(e, core.NewEndOfStream(e), core.Nil)
+	}
+	if err != nil {
+		return readError(e, t, err)
+	}
+	return parse(e, t, tok)
+}
+
+// next returns the next token which is not a comment.
+func next(t *tokenizer.BufferedTokenReader) (*tokenizer.Token, error) {
+	for {
+		tok, err := t.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(tok.Str, ";") && !strings.HasPrefix(tok.Str, "#|") {
+			return tok, nil
+		}
+	}
+}
+
+// operand returns the next token of the object started by the token open.
+func operand(e core.Environment, t *tokenizer.BufferedTokenReader, open *tokenizer.Token) (*tokenizer.Token, core.Instance) {
+	tok, err := next(t)
+	if err == io.EOF {
+		_, err := parseError(e, open, core.ObjectClass)
+		return nil, err
+	}
+	if err != nil {
+		_, err := readError(e, t, err)
+		return nil, err
+	}
+	return tok, nil
+}
+
+func parse(e core.Environment, t *tokenizer.BufferedTokenReader, tok *tokenizer.Token) (core.Instance, core.Instance) {
+	switch str := tok.Str; {
+	case str == "(":
+		return parseCons(e, t, tok)
+	case str == ")" || str == ".":
+		return parseError(e, tok, core.ObjectClass)
+	case str == "#'" || str == "'" || str == "`" || str == "," || str == ",@" || str == "#" || array.MatchString(str):
+		return parseMacro(e, tok, t)
+	}
+	return ParseAtom(e, tok)
 }
 
 func parseMacro(e core.Environment, tok *tokenizer.Token, t *tokenizer.BufferedTokenReader) (core.Instance, core.Instance) {
 	str := tok.Str
-	cdr, err := Parse(e, t)
+	next, err := operand(e, t, tok)
 	if err != nil {
 		return nil, err
 	}
-	n := str
-	if m, _ := regexp.MatchString("#[[:digit:]]+[aA]", str); m {
-		i := strings.IndexRune(strings.ToLower(str), 'a')
-		var v int64 = 1
-		if i != 1 {
+	cdr, err := parse(e, t, next)
+	if err != nil {
+		return nil, err
+	}
+	if r := array.FindStringSubmatch(str); len(r) >= 2 {
+		v := 1
+		if r[1] != "" {
 			var err error
-			v, err = strconv.ParseInt(str[1:i], 10, 64)
+			v, err = strconv.Atoi(r[1])
 			if err != nil {
-				return core.SignalCondition(
-					e,
-					core.NewParseError(
-						e,
-						core.NewString([]rune(str)),
-						core.IntegerClass,
-					),
-					core.Nil,
-				)
+				return parseError(e, tok, core.IntegerClass)
 			}
 		}
-		if int(v) == 1 {
+		if !isProperList(cdr) {
+			return parseError(e, next, core.ListClass)
+		}
+		if v == 1 {
 			return list2vector(cdr)
 		}
-		return list2array(int(v), cdr)
+		return list2array(v, cdr)
 	}
 	if str == "#" {
+		if !isProperList(cdr) {
+			return parseError(e, next, core.ListClass)
+		}
 		return list2vector(cdr)
 	}
+	n := str
 	switch str {
 	case "#'":
 		n = "FUNCTION"
@@ -140,71 +305,53 @@ func parseMacro(e core.Environment, tok *tokenizer.Token, t *tokenizer.BufferedT
 	case "`":
 		n = "QUASIQUOTE"
 	}
-	m := core.NewSymbol(n, tok.Line, tok.Line)
-	return core.NewCons(m, core.NewCons(cdr, core.Nil)), nil
+	form := core.NewCons(core.NewSymbolAt(n, location(tok)), core.NewCons(cdr, core.Nil))
+	form.(*core.Cons).SetLocation(location(tok))
+	return form, nil
 }
-func parseCons(e core.Environment, t *tokenizer.BufferedTokenReader) (core.Instance, core.Instance) {
-	car, err := Parse(e, t)
-	if err == eop {
-		return core.Nil, nil
-	}
-	if err == bod {
-		cdr, err := Parse(e, t)
+
+func parseCons(e core.Environment, t *tokenizer.BufferedTokenReader, open *tokenizer.Token) (core.Instance, core.Instance) {
+	elements := []core.Instance{}
+	cdr := core.Nil
+	for {
+		tok, err := operand(e, t, open)
 		if err != nil {
 			return nil, err
 		}
-		if _, err := Parse(e, t); err != eop {
-			return nil, err
+		if tok.Str == ")" {
+			break
 		}
-		return cdr, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	cdr, err := parseCons(e, t)
-	if err != nil {
-		return nil, err
-	}
-	return core.NewCons(car, cdr), nil
-}
-
-// Parse builds a internal expression from tokens
-func Parse(e core.Environment, t *tokenizer.BufferedTokenReader) (core.Instance, core.Instance) {
-	tok, err := t.ReadToken()
-	if err != nil {
-		return core.SignalCondition(e, core.NewEndOfStream(e), core.Nil)
-	}
-	str := tok.Str
-	for (len(str) > 2 && str[:2] == "#|") || str[:1] == ";" {
-		tok, err = t.ReadToken()
-		if err != nil {
-			return core.SignalCondition(e, core.NewEndOfStream(e), core.Nil)
+		if tok.Str == "." && len(elements) > 0 {
+			tok, err := operand(e, t, open)
+			if err != nil {
+				return nil, err
+			}
+			if tok.Str == ")" {
+				return parseError(e, tok, core.ObjectClass)
+			}
+			if cdr, err = parse(e, t, tok); err != nil {
+				return nil, err
+			}
+			tok, err = operand(e, t, open)
+			if err != nil {
+				return nil, err
+			}
+			if tok.Str != ")" {
+				return parseError(e, tok, core.ObjectClass)
+			}
+			break
 		}
-		str = tok.Str
-	}
-	if str == "(" {
-		cons, err := parseCons(e, t)
+		car, err := parse(e, t, tok)
 		if err != nil {
 			return nil, err
 		}
-		return cons, err
-	}
-	if str == ")" {
-		return nil, eop
-	}
-	if str == "." {
-		return nil, bod
+		elements = append(elements, car)
 	}
-	if mat, _ := regexp.MatchString("^(?:#'|,@?|'|`|#[[:digit:]]*[aA]|#)$", str); mat {
-		m, err := parseMacro(e, tok, t)
-		if err != nil {
-			return nil, err
-		}
-		return m, nil
+	for i := len(elements) - 1; i >= 0; i-- {
+		cdr = core.NewCons(elements[i], cdr)
 	}
-	atom, err1 := ParseAtom(e, tok)
-	if err1 != nil {
-		return nil, err1
+	if cons, ok := cdr.(*core.Cons); ok {
+		cons.SetLocation(location(open))
 	}
-	return atom, nil
+	return cdr, nil
 }
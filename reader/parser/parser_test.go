@@ -5,13 +5,23 @@
 package parser
 
 import (
+	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/islisp-dev/iris/reader/tokenizer"
 	"github.com/islisp-dev/iris/core"
 )
 
+const maxInt = int(^uint(0) >> 1)
+
+func bigInteger(s string, base int) core.Instance {
+	i, _ := new(big.Int).SetString(s, base)
+	return core.NewBigInteger(i)
+}
+
 func Test_parseAtom(t *testing.T) {
 	type arguments struct {
 		tok string
@@ -118,6 +128,24 @@ func Test_parseAtom(t *testing.T) {
 			want:      core.NewInteger(-257),
 			wantErr:   false,
 		},
+		{
+			name:      "bignum",
+			arguments: arguments{"-123456789012345678901234567890"},
+			want:      bigInteger("-123456789012345678901234567890", 10),
+			wantErr:   false,
+		},
+		{
+			name:      "hexadecimal bignum",
+			arguments: arguments{"#x10000000000000000"},
+			want:      bigInteger("10000000000000000", 16),
+			wantErr:   false,
+		},
+		{
+			name:      "most positive fixnum",
+			arguments: arguments{strconv.Itoa(maxInt)},
+			want:      core.NewInteger(maxInt),
+			wantErr:   false,
+		},
 		{
 			name:      "invalid binary",
 			arguments: arguments{"-#x00101"},
@@ -167,3 +195,94 @@ func Test_parseAtom(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    core.Instance
+		wantErr bool
+	}{
+		{`"a\"b\\c"`, core.NewString([]rune(`a"b\c`)), false},
+		{`|Foo Bar|`, core.NewSymbol("Foo Bar"), false},
+		{`|a\|b|`, core.NewSymbol("a|b"), false},
+		{`résumé`, core.NewSymbol("RÉSUMÉ"), false},
+		{`#\λ`, core.NewCharacter('λ'), false},
+		{`user:car`, core.NewSymbol("CAR"), false},
+		{`#| #| nested |# |# -2.5`, core.NewFloat(-2.5), false},
+		{`(1 . 2)`, core.NewCons(core.NewInteger(1), core.NewInteger(2)), false},
+		{`#(1 2)`, core.NewGeneralVector([]core.Instance{core.NewInteger(1), core.NewInteger(2)}), false},
+		{`(1 2`, nil, true},
+		{`)`, nil, true},
+		{`(1 . 2 3)`, nil, true},
+		{`(. 2)`, nil, true},
+		{`'`, nil, true},
+		{`#ab`, nil, true},
+		{`#3 (1)`, nil, true},
+		{`#(1 . 2)`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			env := core.NewEnvironment(nil, nil, nil, core.DefaultHandler)
+			got, err := Parse(env, tokenizer.NewBufferedTokenReader(strings.NewReader(tt.src)))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !core.InstanceOf(core.ParseErrorClass, err) {
+				t.Errorf("Parse() error = %v, want <parse-error>", err)
+			}
+			if !core.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorLocation(t *testing.T) {
+	env := core.NewEnvironment(nil, nil, nil, core.DefaultHandler)
+	_, err := Parse(env, tokenizer.NewBufferedTokenReader(strings.NewReader("\n  (a\n (b #ab))")))
+	frames := core.Stacktrace(err)
+	if len(frames) != 1 || frames[0].Location.String() != "3:5" {
+		t.Errorf("Parse() stack trace = %v, want a frame at 3:5", frames)
+	}
+	_, err = Parse(env, tokenizer.NewBufferedTokenReader(strings.NewReader(" ")))
+	if !core.InstanceOf(core.EndOfStreamClass, err) {
+		t.Errorf("Parse() error = %v, want <end-of-stream>", err)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	src := "(" + strings.Repeat("(defun foo (x) \"doc\" (+ x 1.5)) ", 10000) + ")"
+	env := core.NewEnvironment(nil, nil, nil, core.DefaultHandler)
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(env, tokenizer.NewBufferedTokenReader(strings.NewReader(src))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	env := core.NewEnvironment(nil, nil, nil, core.DefaultHandler)
+	reader := tokenizer.NewBufferedTokenReader(strings.NewReader("(a\n  'b (c))"))
+	reader.File = "test.lsp"
+	form, err := Parse(env, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := form.(*core.Cons).Slice()
+	tests := []struct {
+		form core.Instance
+		want string
+	}{
+		{form, "test.lsp:1:1"},
+		{list[0], "test.lsp:1:2"},
+		{list[1], "test.lsp:2:3"},
+		{list[1].(*core.Cons).Nth(1), "test.lsp:2:4"},
+		{list[2], "test.lsp:2:6"},
+	}
+	for _, tt := range tests {
+		if got := core.LocationOf(tt.form).String(); got != tt.want {
+			t.Errorf("location of %v = %v, want %v", tt.form, got, tt.want)
+		}
+	}
+}
@@ -2,11 +2,10 @@ package tokenizer
 
 import (
 	"bufio"
+	"fmt"
 	"io"
-	"regexp"
 	"strings"
-
-	"github.com/dlclark/regexp2"
+	"unicode"
 )
 
 // BufferedTokenReader interface type is the interface
@@ -15,17 +14,26 @@ import (
 // which returns a rune without advancing pointer
 type BufferedTokenReader struct {
 	line, column int
+	File         string // the name of the file read, or empty
 	Raw          io.Reader
 	*bufio.Reader
 }
 
-// NewBufferedTokenReader creates interal reader from io.RuneReader
+// NewBufferedTokenReader creates interal reader from io.RuneReader. The file
+// name is taken from r if it has a Name method like *os.File.
 func NewBufferedTokenReader(r io.Reader) *BufferedTokenReader {
-	return &BufferedTokenReader{1, 0, r, bufio.NewReader(r)}
+	file := ""
+	if f, ok := r.(interface{ Name() string }); ok {
+		file = f.Name()
+	}
+	return &BufferedTokenReader{1, 0, file, r, bufio.NewReader(r)}
 }
 
 func (t *BufferedTokenReader) ReadRune() (r rune, size int, err error) {
 	r, size, err = t.Reader.ReadRune()
+	if err != nil {
+		return
+	}
 	if r == '\n' {
 		t.line++
 		t.column = 0
@@ -35,95 +43,222 @@ func (t *BufferedTokenReader) ReadRune() (r rune, size int, err error) {
 	return
 }
 
-var str = `^1\+$|^1-$|` +
-	`^[-+]?[[:digit:]]+\.[[:digit:]]+$|` +
-	`^[-+]?[[:digit:]]+(?:\.[[:digit:]]+)?[eE][-+]?[[:digit:]]+$|` +
-	`^[-+]?[[:digit:]]+$|` +
-	`^#[bB][-+]?[01]*$|` +
-	`^#[oO][-+]?[0-7]*$|` +
-	`^#[xX][-+]?[[:xdigit:]]*$|` +
-	`^#\\[[:alpha:]]*$|` +
-	`^#\\[[:graph:]]?$|` +
-	`^"(?:\\\\|\\.|[^\\"])*"$|` +
-	`^[:&](?:[a-zA-Z]|-)+$|` +
-	`^\+$|^-$|^[a-zA-Z<>/*=?_!$%[\]^{}~][-a-zA-Z0-9+<>/*=?_!$%[\]^{}~]*$|` +
-	`^\|(?:\\\\|\\.|[^\\|])*\|$|` +
-	`^[.()]$|` +
-	"^;[^\n]*$|" +
-	`^#\|((?<!\|#)[\s\S])*$|` +
-	"^#'$|^,@?$|^'$|^`$|^#[[:digit:]]*[aA]$|^#$" // TODO: hangs at #ab or #3
-var re = regexp2.MustCompile(str, regexp2.RE2)
+// PeekRune returns the next rune without advancing the reader.
+func (t *BufferedTokenReader) PeekRune() (rune, error) {
+	r, _, err := t.Reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	t.Reader.UnreadRune()
+	return r, nil
+}
+
+// Position returns the line and the column of the next rune. Both start at 1.
+func (t *BufferedTokenReader) Position() (line, column int) {
+	return t.line, t.column + 1
+}
+
+// SetLine sets the line of the next rune, for a source continuing another one
+// which ended on the previous line.
+func (t *BufferedTokenReader) SetLine(line int) {
+	t.line = line
+	t.column = 0
+}
 
 type Token struct {
 	Str          string
 	Line, Column int
+	File         string
 }
 
 func NewToken(Str string, Line, Column int) *Token {
-	return &Token{Str, Line, Column}
+	return &Token{Str, Line, Column, ""}
+}
+
+// Error is a malformed token. Str is the text read so far, which starts at
+// Line and Column.
+type Error struct {
+	Str          string
+	Line, Column int
+	Message      string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s: %q", err.Line, err.Column, err.Message, err.Str)
+}
+
+// isDelimiter reports whether r ends an atom.
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("()\"';`,", r)
 }
 
-// ReadToken returns error or string as token
-func (r *BufferedTokenReader) ReadToken() (*Token, error) {
+// ReadToken returns the next token. Comments are returned as tokens starting
+// with ";" or "#|". At the end of the input it returns io.EOF, and for a
+// malformed token an *Error.
+func (t *BufferedTokenReader) ReadToken() (*Token, error) {
 	for {
-		bytes, err := r.Peek(1)
-		if err != nil {
-			return NewToken("", r.line, r.column), io.EOF
-		}
-		ru := rune(bytes[0])
-		if ru == 0 {
-			return NewToken("", r.line, r.column), io.EOF
+		r, err := t.PeekRune()
+		if err != nil || r == 0 {
+			return NewToken("", t.line, t.column), io.EOF
 		}
-		if !strings.ContainsRune(" \t\n\r", ru) {
+		if !unicode.IsSpace(r) {
 			break
 		}
-		r.ReadRune()
+		t.ReadRune()
+	}
+	line, column := t.Position()
+	s := &scanner{t, line, column, strings.Builder{}}
+	return s.scan()
+}
+
+// scanner reads one token into buf.
+type scanner struct {
+	*BufferedTokenReader
+	line, column int
+	buf          strings.Builder
+}
+
+func (s *scanner) token() (*Token, error) {
+	return &Token{s.buf.String(), s.line, s.column, s.File}, nil
+}
+
+func (s *scanner) error(message string) (*Token, error) {
+	return nil, &Error{s.buf.String(), s.line, s.column, message}
+}
+
+// next appends the next rune to the token. It returns false at the end of the
+// input.
+func (s *scanner) next() (rune, bool) {
+	r, _, err := s.ReadRune()
+	if err != nil {
+		return 0, false
 	}
-	buf := ""
-	mat := false
-	num := false
-	shp := false
+	s.buf.WriteRune(r)
+	return r, true
+}
+
+// peek returns the next rune, or 0 at the end of the input.
+func (s *scanner) peek() rune {
+	r, err := s.PeekRune()
+	if err != nil {
+		return 0
+	}
+	return r
+}
+
+// atom appends the runes up to the next delimiter.
+func (s *scanner) atom() {
+	for r := s.peek(); r != 0 && !isDelimiter(r); r = s.peek() {
+		s.next()
+	}
+}
+
+func (s *scanner) scan() (*Token, error) {
+	r, _ := s.next()
+	switch r {
+	case '(', ')', '\'', '`':
+		return s.token()
+	case ',':
+		if s.peek() == '@' {
+			s.next()
+		}
+		return s.token()
+	case ';':
+		for r := s.peek(); r != 0 && r != '\n'; r = s.peek() {
+			s.next()
+		}
+		return s.token()
+	case '"':
+		return s.quoted('"', "unterminated string")
+	case '|':
+		return s.quoted('|', "unterminated symbol")
+	case '#':
+		return s.dispatch()
+	}
+	s.atom()
+	return s.token()
+}
+
+// quoted reads up to the closing delimiter, skipping the runes escaped by a
+// backslash.
+func (s *scanner) quoted(delimiter rune, message string) (*Token, error) {
 	for {
-		bytes, err := r.Peek(1)
-		if err != nil {
-			if mat {
-				return NewToken(buf, r.line, r.column-len(buf)+1), nil
-			}
-			return NewToken("", r.line, r.column), nil
+		r, ok := s.next()
+		if !ok {
+			return s.error(message)
 		}
-		ru := rune(bytes[0])
-		if ru == 0 {
-			if mat {
-				return NewToken(buf, r.line, r.column-len(buf)+1), nil
+		switch r {
+		case '\\':
+			if _, ok := s.next(); !ok {
+				return s.error(message)
 			}
-			return NewToken("", r.line, r.column), nil
+		case delimiter:
+			return s.token()
 		}
-		if buf == "" && strings.ContainsRune("1234567890", ru) {
-			num = true
+	}
+}
+
+// dispatch reads a token starting with "#".
+func (s *scanner) dispatch() (*Token, error) {
+	r := s.peek()
+	switch {
+	case r == '|':
+		s.next()
+		return s.comment()
+	case r == '\'' || r == '(':
+		if r == '\'' {
+			s.next()
 		}
-		if buf == "" && ru == '#' {
-			shp = true
+		return s.token()
+	case r == '\\':
+		s.next()
+		r, ok := s.next()
+		if !ok {
+			return s.error("missing character")
 		}
-		if m, _ := re.MatchString(buf + string(ru)); !m && mat {
-			if num && strings.ContainsRune(".Ee", ru) {
-				buf += string(ru)
-				r.ReadRune()
-				continue
-			}
-			if shp {
-				if matched, _ := regexp.MatchString(`^#[0123456789]*$`, buf+string(ru)); matched {
-					buf += string(ru)
-					r.ReadRune()
-					continue
-				}
-			}
-			break
+		if !isDelimiter(r) {
+			s.atom()
+		}
+		return s.token()
+	case strings.ContainsRune("bBoOxX", r):
+		s.atom()
+		return s.token()
+	case '0' <= r && r <= '9' || r == 'a' || r == 'A':
+		for '0' <= r && r <= '9' {
+			s.next()
+			r = s.peek()
+		}
+		if r != 'a' && r != 'A' {
+			s.atom()
+			return s.error("malformed array syntax")
+		}
+		s.next()
+		if r := s.peek(); r != 0 && !isDelimiter(r) {
+			s.atom()
+			return s.error("malformed array syntax")
+		}
+		return s.token()
+	}
+	s.atom()
+	return s.error("undefined dispatch character")
+}
+
+// comment reads a block comment, which may be nested.
+func (s *scanner) comment() (*Token, error) {
+	depth := 1
+	for depth > 0 {
+		r, ok := s.next()
+		if !ok {
+			return s.error("unterminated comment")
 		}
-		buf += string(ru)
-		if m, _ := re.MatchString(buf); m {
-			mat = true
+		switch {
+		case r == '|' && s.peek() == '#':
+			s.next()
+			depth--
+		case r == '#' && s.peek() == '|':
+			s.next()
+			depth++
 		}
-		r.ReadRune()
 	}
-	return NewToken(buf, r.line, r.column-len(buf)+1), nil
+	return s.token()
 }
@@ -5,6 +5,7 @@
 package tokenizer
 
 import (
+	"io"
 	"strings"
 	"testing"
 )
@@ -62,3 +63,64 @@ func TestTokenizer_Next(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenizer_Position(t *testing.T) {
+	tokenizer := NewBufferedTokenReader(strings.NewReader("(défun λ\n  #|a #|b|# c|# \"x\\\"y\" |a\\|b|)\n#\\λ #2a"))
+	tests := []struct {
+		want         string
+		line, column int
+	}{
+		{"(", 1, 1},
+		{"défun", 1, 2},
+		{"λ", 1, 8},
+		{"#|a #|b|# c|#", 2, 3},
+		{`"x\"y"`, 2, 17},
+		{`|a\|b|`, 2, 24},
+		{")", 2, 30},
+		{`#\λ`, 3, 1},
+		{"#2a", 3, 5},
+	}
+	for _, tt := range tests {
+		got, err := tokenizer.ReadToken()
+		if err != nil {
+			t.Fatalf("Tokenizer.ReadToken() err = %v, want %v", err, tt.want)
+		}
+		if got.Str != tt.want || got.Line != tt.line || got.Column != tt.column {
+			t.Errorf("Tokenizer.ReadToken() got = %v at %v:%v, want %v at %v:%v", got.Str, got.Line, got.Column, tt.want, tt.line, tt.column)
+		}
+	}
+	if _, err := tokenizer.ReadToken(); err != io.EOF {
+		t.Errorf("Tokenizer.ReadToken() err = %v, want EOF", err)
+	}
+}
+
+func TestTokenizer_Error(t *testing.T) {
+	tests := []struct {
+		src          string
+		want         string
+		line, column int
+	}{
+		{"#ab", "#ab", 1, 1},
+		{"(#3 1)", "#3", 1, 2},
+		{"  \"abc", `"abc`, 1, 3},
+		{"\n|abc\\|", `|abc\|`, 2, 1},
+		{"#| #| |#", "#| #| |#", 1, 1},
+		{"#\\", `#\`, 1, 1},
+		{"#?", "#?", 1, 1},
+	}
+	for _, tt := range tests {
+		tokenizer := NewBufferedTokenReader(strings.NewReader(tt.src))
+		var err error
+		for err == nil {
+			_, err = tokenizer.ReadToken()
+		}
+		got, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Tokenizer.ReadToken(%q) err = %v, want *Error", tt.src, err)
+			continue
+		}
+		if got.Str != tt.want || got.Line != tt.line || got.Column != tt.column {
+			t.Errorf("Tokenizer.ReadToken(%q) got = %v at %v:%v, want %v at %v:%v", tt.src, got.Str, got.Line, got.Column, tt.want, tt.line, tt.column)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+)
+
+// debugger is the session of the debugger on a condition not handled. Its
+// lines are read from the input of the REPL. The forms entered are evaluated
+// in the environment of the selected frame, the innermost at first.
+type debugger struct {
+	repl      *REPL
+	env       core.Environment // where the condition was signaled
+	condition core.Instance
+	calls     []*core.Call // the innermost first
+	frame     int          // the index of the selected call
+}
+
+// debug runs the debugger on the condition signaled in e. It returns a
+// <continue> for ,continue, and the condition for ,abort or at the end of the
+// input.
+func (r *REPL) debug(e core.Environment, condition core.Instance) (core.Instance, core.Instance) {
+	d := &debugger{repl: r, env: e, condition: condition, calls: e.Runtime.Calls()}
+	fmt.Fprintf(r.out, "%v: %s\n", condition.Class(), lib.ReportString(e, condition))
+	fmt.Fprintln(r.out, "Entering the debugger; type ,help for the commands.")
+	d.backtrace()
+	input := []string{}
+	for {
+		prompt := fmt.Sprintf("debug[%d]> ", d.frame)
+		if len(input) > 0 {
+			prompt = "... "
+		}
+		line, err := r.read(prompt)
+		if err == errInterrupted {
+			input = input[:0]
+			continue
+		}
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, condition
+		}
+		if len(input) == 0 && strings.HasPrefix(strings.TrimSpace(line), ",") {
+			if ret, err, done := d.command(strings.TrimSpace(line)[1:]); done {
+				return ret, err
+			}
+			continue
+		}
+		input = append(input, line)
+		text := strings.Join(input, "\n")
+		if strings.TrimSpace(text) == "" {
+			input = input[:0]
+			continue
+		}
+		if complete(text) {
+			d.eval(text)
+			input = input[:0]
+		}
+	}
+}
+
+// environment returns the environment of the selected frame, with no handler
+// established.
+func (d *debugger) environment() core.Environment {
+	e := d.env
+	if d.frame < len(d.calls) && d.calls[d.frame].Environment != nil {
+		e = *d.calls[d.frame].Environment
+	}
+	return e.NewHandler(core.DefaultHandler)
+}
+
+// command runs a command of the debugger. It reports whether the debugger
+// returns, with the values to return.
+func (d *debugger) command(line string) (core.Instance, core.Instance, bool) {
+	out := d.repl.out
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		fields = []string{"help"}
+	}
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	switch name {
+	case "abort", "a", "quit", "q":
+		return nil, d.condition, true
+	case "continue", "c":
+		if continuable, _ := lib.ConditionContinuable(d.env, d.condition); core.DeepEqual(continuable, core.Nil) {
+			fmt.Fprintln(out, "the condition is not continuable")
+			break
+		}
+		value := core.Instance(core.Nil)
+		if text := strings.TrimSpace(line[len(fields[0]):]); text != "" {
+			var ok bool
+			if value, ok = d.evalForm(text); !ok {
+				break
+			}
+		}
+		ret, err := lib.ContinueCondition(d.env, d.condition, value)
+		return ret, err, true
+	case "backtrace", "bt":
+		d.backtrace()
+	case "frame", "f":
+		n, err := strconv.Atoi(strings.Join(args, ""))
+		if len(args) != 1 || err != nil || n < 0 || n >= len(d.calls) {
+			fmt.Fprintf(out, "usage: ,frame N, where N is below %d\n", len(d.calls))
+			break
+		}
+		d.frame = n
+		d.printFrame(n)
+	case "locals", "l":
+		d.locals()
+	case "help", "h", "?":
+		fmt.Fprintln(out, ",backtrace       print the function applications in progress")
+		fmt.Fprintln(out, ",frame N         select the application N")
+		fmt.Fprintln(out, ",locals          print the local variables of the application selected")
+		fmt.Fprintln(out, ",continue [FORM] return the value of FORM from the continuable condition")
+		fmt.Fprintln(out, ",abort           return to the REPL")
+		fmt.Fprintln(out, "Other forms are evaluated in the application selected.")
+	default:
+		fmt.Fprintf(out, "unknown command ,%s; type ,help for the commands\n", name)
+	}
+	return nil, nil, false
+}
+
+// backtrace prints the function applications in progress.
+func (d *debugger) backtrace() {
+	for i := range d.calls {
+		d.printFrame(i)
+	}
+}
+
+// printFrame prints the application n with its arguments, the location of
+// the form it was evaluating, and whether it has ended by a tail call.
+func (d *debugger) printFrame(n int) {
+	call := d.calls[n]
+	marker := " "
+	if n == d.frame {
+		marker = ">"
+	}
+	text := fmt.Sprint(call.Name)
+	for _, argument := range call.Arguments {
+		text += " " + fmt.Sprint(argument)
+	}
+	text = "(" + text + ")"
+	if location := core.LocationOf(call.Form); location != nil {
+		text += fmt.Sprintf(" at %v", location)
+	}
+	if call.Tail {
+		text += " [tail call]"
+	}
+	fmt.Fprintf(d.repl.out, "%s%3d: %s\n", marker, n, text)
+}
+
+// locals prints the variables bound in the selected frame, other than the
+// global ones.
+func (d *debugger) locals() {
+	e := d.environment()
+	seen := map[string]bool{}
+	found := false
+	for _, name := range e.Variable[1:].Keys() {
+		if seen[name.String()] {
+			continue
+		}
+		seen[name.String()] = true
+		value, _ := e.Variable.Get(name)
+		fmt.Fprintf(d.repl.out, "  %v = %v\n", name, value)
+		found = true
+	}
+	if !found {
+		fmt.Fprintln(d.repl.out, "no local variables")
+	}
+}
+
+// eval evaluates the forms of text in the selected frame and prints their
+// values.
+func (d *debugger) eval(text string) {
+	e := d.environment()
+	stream := d.repl.input(text)
+	for {
+		form, err := lib.Read(e, stream)
+		if err != nil {
+			if !core.InstanceOf(core.EndOfStreamClass, err) {
+				d.repl.printError(e, err)
+			}
+			return
+		}
+		ret, err := lib.Eval(e, form)
+		if err != nil {
+			d.repl.printError(e, err)
+			continue
+		}
+		fmt.Fprintln(d.repl.out, ret)
+	}
+}
+
+// evalForm evaluates the form of text in the selected frame, and reports
+// whether it did without error.
+func (d *debugger) evalForm(text string) (core.Instance, bool) {
+	e := d.environment()
+	stream := d.repl.input(text)
+	form, err := lib.Read(e, stream)
+	if err == nil {
+		var ret core.Instance
+		if ret, err = lib.Eval(e, form); err == nil {
+			return ret, true
+		}
+	}
+	d.repl.printError(e, err)
+	return nil, false
+}
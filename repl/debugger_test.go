@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/lib"
+)
+
+func debugRun(t *testing.T, input string) string {
+	t.Helper()
+	out := new(bytes.Buffer)
+	r := New(lib.NewRuntime(), strings.NewReader(input), out)
+	r.Quiet = true
+	r.Debug = true
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run() err = %v", err)
+	}
+	return out.String()
+}
+
+func TestDebugger(t *testing.T) {
+	got := debugRun(t, `(defun debug-f (x) (let ((y (* x 2))) (+ 1 (debug-g y))))
+(defun debug-g (z) (+ (car z) 1))
+(debug-f 3)
+,frame 2
+,locals
+(+ x y)
+,frame 1
+,locals
+,continue 1
+,abort
+(+ 1 2)
+`)
+	for _, want := range []string{
+		"<DOMAIN-ERROR>: 6 is not an instance of <CONS>\nEntering the debugger",
+		">  0: (CAR 6)\n   1: (DEBUG-G 6) at 2:23\n   2: (DEBUG-F 3) at 1:44\n",
+		">  2: (DEBUG-F 3) at 1:44\n  X = 3\n  Y = 6\n9\n",
+		">  1: (DEBUG-G 6) at 2:23\n  Z = 6\n",
+		"the condition is not continuable\n",
+		"  at DEBUG-F (3:1)\n3\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q in it", got, want)
+		}
+	}
+}
+
+func TestDebuggerContinue(t *testing.T) {
+	got := debugRun(t, `(+ 1 (cerror "use a value" "bad ~A" 1))
+,continue (+ 1 2)
+(ignore-errors (car 1))
+(car 1)
+`)
+	want := "<SIMPLE-ERROR>: bad 1\n" +
+		"Entering the debugger; type ,help for the commands.\n" +
+		">  0: (CERROR \"use a value\" \"bad ~A\" 1)\n" +
+		"4\n" +
+		"NIL\n" +
+		"<DOMAIN-ERROR>: 1 is not an instance of <CONS>\n" +
+		"Entering the debugger; type ,help for the commands.\n" +
+		">  0: (CAR 1)\n"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("output = %q, want %q first", got, want)
+	}
+}
+
+func TestDebuggerTailCall(t *testing.T) {
+	got := debugRun(t, `(defun tail-f (a b) (cerror "use a value" "bad ~A" a))
+(defun tail-g (n) (if (= n 0) (tail-f n 2) (tail-g (- n 1))))
+(tail-g 3)
+,frame 1
+,locals
+a
+,continue 5
+`)
+	for _, want := range []string{
+		"<SIMPLE-ERROR>: bad 0\nEntering the debugger",
+		">  0: (CERROR \"use a value\" \"bad ~A\" 0)\n   1: (TAIL-F 0 2) at 1:21 [tail call]\n",
+		">  1: (TAIL-F 0 2) at 1:21 [tail call]\n  ",
+		"  A = 0\n",
+		"  B = 2\n",
+		"\n0\n5\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q in it", got, want)
+		}
+	}
+}
+
+func TestDebuggerErrorSource(t *testing.T) {
+	got := debugRun(t, `(defun src-f (y) (cerror "go on" "bad ~A" y))
+(src-f 1)
+,frame 1
+(car y)
+,continue (cdr y)
+`)
+	for _, want := range []string{
+		"<DOMAIN-ERROR>: 1 is not an instance of <CONS>\n3:1:\n    3 | (car y)\n",
+		"<DOMAIN-ERROR>: 1 is not an instance of <CONS>\n4:1:\n    4 | (cdr y)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q in it", got, want)
+		}
+	}
+}
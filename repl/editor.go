@@ -0,0 +1,281 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// errInterrupted is returned by readLine when the line is cancelled by C-c.
+var errInterrupted = errors.New("interrupted")
+
+// editor reads lines from a terminal in raw mode with Emacs-like key
+// bindings, a history browsed by the arrow keys and completion by TAB.
+type editor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history []string
+	// complete returns the position where the word before pos starts and
+	// the words it may be completed to.
+	complete func(line []rune, pos int) (int, []string)
+
+	prompt string
+	line   []rune
+	pos    int
+	index  int    // the entry of the history shown, len(history) for the new line
+	saved  []rune // the new line while the history is shown
+}
+
+func newEditor(in io.Reader, out io.Writer) *editor {
+	return &editor{in: bufio.NewReader(in), out: out}
+}
+
+func ctrl(r rune) rune {
+	return r & 0x1f
+}
+
+// addHistory appends a line to the history unless it is blank or repeats the
+// last one.
+func (ed *editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(ed.history); n > 0 && ed.history[n-1] == line {
+		return
+	}
+	ed.history = append(ed.history, line)
+}
+
+// readLine shows the prompt and returns the line edited. It returns io.EOF
+// for C-d on an empty line and errInterrupted for C-c.
+func (ed *editor) readLine(prompt string) (string, error) {
+	ed.prompt, ed.line, ed.pos = prompt, []rune{}, 0
+	ed.index, ed.saved = len(ed.history), nil
+	ed.refresh()
+	for {
+		r, _, err := ed.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(ed.out, "\r\n")
+			return string(ed.line), nil
+		case ctrl('C'):
+			fmt.Fprint(ed.out, "^C\r\n")
+			return "", errInterrupted
+		case ctrl('D'):
+			if len(ed.line) == 0 {
+				fmt.Fprint(ed.out, "\r\n")
+				return "", io.EOF
+			}
+			ed.delete()
+		case ctrl('A'):
+			ed.pos = 0
+		case ctrl('E'):
+			ed.pos = len(ed.line)
+		case ctrl('B'):
+			ed.left()
+		case ctrl('F'):
+			ed.right()
+		case ctrl('H'), 127:
+			if ed.pos > 0 {
+				ed.pos--
+				ed.delete()
+			}
+		case ctrl('K'):
+			ed.line = ed.line[:ed.pos]
+		case ctrl('U'):
+			ed.line = append([]rune{}, ed.line[ed.pos:]...)
+			ed.pos = 0
+		case ctrl('W'):
+			end := ed.pos
+			ed.wordLeft()
+			ed.line = append(ed.line[:ed.pos], ed.line[end:]...)
+		case ctrl('L'):
+			fmt.Fprint(ed.out, "\x1b[H\x1b[2J")
+		case ctrl('P'):
+			ed.browse(-1)
+		case ctrl('N'):
+			ed.browse(1)
+		case '\t':
+			ed.completion()
+		case 27:
+			ed.escape()
+		default:
+			if unicode.IsPrint(r) {
+				ed.insert(r)
+			}
+		}
+		ed.refresh()
+	}
+}
+
+// escape handles the escape sequences sent by the arrow and editing keys,
+// and the Meta bindings.
+func (ed *editor) escape() {
+	r, _, err := ed.in.ReadRune()
+	if err != nil {
+		return
+	}
+	switch r {
+	case 'b':
+		ed.wordLeft()
+		return
+	case 'f':
+		ed.wordRight()
+		return
+	case '[', 'O':
+	default:
+		return
+	}
+	params := ""
+	for {
+		r, _, err = ed.in.ReadRune()
+		if err != nil {
+			return
+		}
+		if 0x40 <= r && r <= 0x7e {
+			break
+		}
+		params += string(r)
+	}
+	switch {
+	case r == 'A':
+		ed.browse(-1)
+	case r == 'B':
+		ed.browse(1)
+	case r == 'C':
+		ed.right()
+	case r == 'D':
+		ed.left()
+	case r == 'H' || r == '~' && (params == "1" || params == "7"):
+		ed.pos = 0
+	case r == 'F' || r == '~' && (params == "4" || params == "8"):
+		ed.pos = len(ed.line)
+	case r == '~' && params == "3":
+		ed.delete()
+	}
+}
+
+func (ed *editor) insert(rs ...rune) {
+	line := make([]rune, 0, len(ed.line)+len(rs))
+	line = append(line, ed.line[:ed.pos]...)
+	line = append(line, rs...)
+	ed.line = append(line, ed.line[ed.pos:]...)
+	ed.pos += len(rs)
+}
+
+// delete deletes the rune under the cursor.
+func (ed *editor) delete() {
+	if ed.pos < len(ed.line) {
+		ed.line = append(ed.line[:ed.pos], ed.line[ed.pos+1:]...)
+	}
+}
+
+func (ed *editor) left() {
+	if ed.pos > 0 {
+		ed.pos--
+	}
+}
+
+func (ed *editor) right() {
+	if ed.pos < len(ed.line) {
+		ed.pos++
+	}
+}
+
+func isWord(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune("()\"';`,", r)
+}
+
+func (ed *editor) wordLeft() {
+	for ed.pos > 0 && !isWord(ed.line[ed.pos-1]) {
+		ed.pos--
+	}
+	for ed.pos > 0 && isWord(ed.line[ed.pos-1]) {
+		ed.pos--
+	}
+}
+
+func (ed *editor) wordRight() {
+	for ed.pos < len(ed.line) && !isWord(ed.line[ed.pos]) {
+		ed.pos++
+	}
+	for ed.pos < len(ed.line) && isWord(ed.line[ed.pos]) {
+		ed.pos++
+	}
+}
+
+// browse shows the entry of the history delta lines before or after the one
+// shown.
+func (ed *editor) browse(delta int) {
+	index := ed.index + delta
+	if index < 0 || len(ed.history) < index {
+		return
+	}
+	if ed.index == len(ed.history) {
+		ed.saved = ed.line
+	}
+	ed.index = index
+	if index == len(ed.history) {
+		ed.line = ed.saved
+	} else {
+		ed.line = []rune(ed.history[index])
+	}
+	ed.pos = len(ed.line)
+}
+
+// completion completes the word before the cursor to the longest prefix
+// shared by its candidates, and lists them if there is no such prefix.
+func (ed *editor) completion() {
+	if ed.complete == nil {
+		return
+	}
+	start, candidates := ed.complete(ed.line, ed.pos)
+	if len(candidates) == 0 {
+		fmt.Fprint(ed.out, "\a")
+		return
+	}
+	word := string(ed.line[start:ed.pos])
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		prefix = commonPrefix(prefix, candidate)
+	}
+	if word == strings.ToLower(word) {
+		prefix = strings.ToLower(prefix)
+	}
+	if len([]rune(prefix)) > len([]rune(word)) {
+		ed.line = append(ed.line[:start], ed.line[ed.pos:]...)
+		ed.pos = start
+		ed.insert([]rune(prefix)...)
+		return
+	}
+	if len(candidates) > 1 {
+		fmt.Fprintf(ed.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+func commonPrefix(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	i := 0
+	for i < len(ra) && i < len(rb) && unicode.ToUpper(ra[i]) == unicode.ToUpper(rb[i]) {
+		i++
+	}
+	return string(ra[:i])
+}
+
+// refresh redraws the line and moves the cursor to its position.
+func (ed *editor) refresh() {
+	fmt.Fprintf(ed.out, "\r%s%s\x1b[K", ed.prompt, string(ed.line))
+	if n := len(ed.line) - ed.pos; n > 0 {
+		fmt.Fprintf(ed.out, "\x1b[%dD", n)
+	}
+}
@@ -0,0 +1,83 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package repl
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestEditor(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		history []string
+		want    string
+		wantErr error
+	}{
+		{"insert", "(car x)\r", nil, "(car x)", nil},
+		{"backspace", "(cdr\x7f\x7far x)\r", nil, "(car x)", nil},
+		{"home and end", "car x\x01(\x05)\r", nil, "(car x)", nil},
+		{"arrows", "(car )\x1b[Dx\x1b[D\x1b[D\x1b[C\x1b[C\x1b[C!\r", nil, "(car x)!", nil},
+		{"delete key", "(caar x)\x1b[H\x1b[C\x1b[C\x1b[3~\r", nil, "(car x)", nil},
+		{"kill", "(car x) junk\x02\x02\x02\x02\x02\x0b\r", nil, "(car x)", nil},
+		{"kill word", "(car junk\x17x)\r", nil, "(car x)", nil},
+		{"kill line", "junk\x15(car x)\r", nil, "(car x)", nil},
+		{"meta word", "car x\x1bb\x1bb(\x1bf\x1bf)\r", nil, "(car x)", nil},
+		{"history", "\x1b[A\x1b[A\r", []string{"(car x)", "(cdr x)"}, "(car x)", nil},
+		{"history back", "(a\x1b[A\x1b[B\x10\x0e)\r", []string{"(car x)"}, "(a)", nil},
+		{"interrupt", "(car\x03", nil, "", errInterrupted},
+		{"eof", "\x04", nil, "", io.EOF},
+		{"delete char", "(car x)\x01\x04(\r", nil, "(car x)", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ed := newEditor(strings.NewReader(tt.keys), ioutil.Discard)
+			for _, line := range tt.history {
+				ed.addHistory(line)
+			}
+			got, err := ed.readLine(">>> ")
+			if got != tt.want || err != tt.wantErr {
+				t.Errorf("readLine() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEditorCompletion(t *testing.T) {
+	complete := func(line []rune, pos int) (int, []string) {
+		start := pos
+		for start > 0 && isWord(line[start-1]) {
+			start--
+		}
+		candidates := []string{}
+		for _, name := range []string{"CAR", "CDR", "CREATE", "CREATE-ARRAY"} {
+			if strings.HasPrefix(name, strings.ToUpper(string(line[start:pos]))) {
+				candidates = append(candidates, name)
+			}
+		}
+		return start, candidates
+	}
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"(ca\t x)\r", "(car x)"},
+		{"(CA\t x)\r", "(CAR x)"},
+		{"(cre\t\r", "(create"},
+		{"(create-\t\r", "(create-array"},
+		{"(c\t\r", "(c"},
+		{"(z\t\r", "(z"},
+	}
+	for _, tt := range tests {
+		ed := newEditor(strings.NewReader(tt.keys), ioutil.Discard)
+		ed.complete = complete
+		if got, _ := ed.readLine(">>> "); got != tt.want {
+			t.Errorf("readLine(%q) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
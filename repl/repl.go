@@ -0,0 +1,356 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package repl implements the read-eval-print loop of the command line
+// interpreter. On a terminal, lines are read with a line editor offering a
+// persistent history and the completion of the names defined.
+package repl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+	"github.com/islisp-dev/iris/reader/parser"
+	"github.com/islisp-dev/iris/reader/tokenizer"
+)
+
+// maxHistory is the number of lines of the history file loaded.
+const maxHistory = 1000
+
+// REPL reads forms, evaluates them in an environment and prints their
+// values. A form may span several lines; the lines continuing it are
+// prompted by "... ". The variables *, ** and *** hold the last three values.
+// Lines starting with a comma are commands, listed by ,help.
+type REPL struct {
+	// HistoryFile is the file the lines entered on a terminal are appended
+	// to and loaded from, or empty to keep the history in memory.
+	HistoryFile string
+	// Quiet disables the prompts when the input is not a terminal.
+	Quiet bool
+	// Debug opens the debugger on the conditions not handled, at the point
+	// they are signaled, instead of returning to the prompt.
+	Debug bool
+
+	env     core.Environment
+	in      io.Reader
+	out     io.Writer
+	line    int                          // the line of the next input
+	entered []string                     // the lines entered, shown in errors
+	read    func(string) (string, error) // reads a line after a prompt
+}
+
+// New returns a REPL evaluating in e the forms read from in and printing to
+// out.
+func New(e core.Environment, in io.Reader, out io.Writer) *REPL {
+	for _, name := range []string{"*", "**", "***"} {
+		e.Variable.Define(core.NewSymbol(name), core.Nil)
+	}
+	return &REPL{env: e, in: in, out: out, line: 1}
+}
+
+// Run runs the loop until the end of the input or the command ,quit.
+func (r *REPL) Run() error {
+	read, done := r.reader()
+	defer done()
+	r.read = read
+	if r.Debug {
+		r.env.Runtime.SetDebugger(r.debug)
+		defer r.env.Runtime.SetDebugger(nil)
+	}
+	input := []string{}
+	for {
+		prompt := ">>> "
+		if len(input) > 0 {
+			prompt = "... "
+		}
+		line, err := read(prompt)
+		if err == errInterrupted {
+			input = input[:0]
+			continue
+		}
+		if err != nil && (err != io.EOF || line == "") {
+			if len(input) > 0 {
+				r.eval(strings.Join(input, "\n"))
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if len(input) == 0 && strings.HasPrefix(strings.TrimSpace(line), ",") {
+			if !r.command(strings.TrimSpace(line)[1:]) {
+				return nil
+			}
+			continue
+		}
+		input = append(input, line)
+		text := strings.Join(input, "\n")
+		if strings.TrimSpace(text) == "" {
+			input = input[:0]
+			continue
+		}
+		if complete(text) {
+			r.eval(text)
+			input = input[:0]
+		}
+	}
+}
+
+// reader returns a function reading a line after a prompt, and a function
+// called once the loop ends. The standard input of the environment is set to
+// read from the input of the REPL. Unless on a terminal, it shares the buffer
+// the lines are read through, so that a form reading the standard input reads
+// the lines following it.
+func (r *REPL) reader() (func(string) (string, error), func()) {
+	if f, ok := r.in.(*os.File); ok {
+		if restore, err := makeRaw(f); err == nil {
+			restore()
+			r.env.StandardInput = core.NewStream(f, nil, core.CharacterClass)
+			return r.editor(f)
+		}
+	}
+	stream := core.NewStream(r.in, nil, core.CharacterClass)
+	r.env.StandardInput = stream
+	in := stream.(core.Stream).BufferedTokenReader
+	return func(prompt string) (string, error) {
+		if !r.Quiet {
+			fmt.Fprint(r.out, prompt)
+		}
+		line, err := in.ReadString('\n')
+		return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), err
+	}, func() {}
+}
+
+// editor returns the functions of reader for a terminal.
+func (r *REPL) editor(f *os.File) (func(string) (string, error), func()) {
+	ed := newEditor(f, r.out)
+	ed.complete = r.complete
+	var history *os.File
+	if r.HistoryFile != "" {
+		if b, err := ioutil.ReadFile(r.HistoryFile); err == nil {
+			lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+			if len(lines) > maxHistory {
+				lines = lines[len(lines)-maxHistory:]
+			}
+			for _, line := range lines {
+				ed.addHistory(line)
+			}
+		}
+		history, _ = os.OpenFile(r.HistoryFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	}
+	read := func(prompt string) (string, error) {
+		restore, err := makeRaw(f)
+		if err != nil {
+			return "", err
+		}
+		line, err := ed.readLine(prompt)
+		restore()
+		if err == nil {
+			n := len(ed.history)
+			ed.addHistory(line)
+			if history != nil && len(ed.history) > n {
+				fmt.Fprintln(history, line)
+			}
+		}
+		return line, err
+	}
+	return read, func() {
+		if history != nil {
+			history.Close()
+		}
+	}
+}
+
+var array = regexp.MustCompile(`^#[0-9]*[aA]$`)
+
+// complete reports whether text ends after a whole form, so that it may be
+// read without waiting for more lines. Malformed text is complete, to be
+// reported by the reader.
+func complete(text string) bool {
+	t := tokenizer.NewBufferedTokenReader(strings.NewReader(text))
+	depth, operand := 0, false
+	for {
+		tok, err := t.ReadToken()
+		if err == io.EOF {
+			return depth <= 0 && !operand
+		}
+		if err != nil {
+			message := err.(*tokenizer.Error).Message
+			return !strings.HasPrefix(message, "unterminated") && message != "missing character"
+		}
+		switch {
+		case strings.HasPrefix(tok.Str, ";") || strings.HasPrefix(tok.Str, "#|"):
+		case tok.Str == "(":
+			depth++
+			operand = false
+		case tok.Str == ")":
+			depth--
+			operand = false
+		case tok.Str == "'" || tok.Str == "`" || tok.Str == "," || tok.Str == ",@" || tok.Str == "#'" || tok.Str == "#":
+			operand = true
+		case array.MatchString(tok.Str):
+			operand = true
+		default:
+			operand = false
+		}
+	}
+}
+
+// input returns a stream of text, which continues the input read so far, so
+// that errors in its forms show the lines entered.
+func (r *REPL) input(text string) core.Instance {
+	lines := strings.Split(text, "\n")
+	r.entered = append(r.entered, lines...)
+	stream := core.NewStream(strings.NewReader(text), nil, core.CharacterClass)
+	stream.(core.Stream).SetLine(r.line)
+	r.line += len(lines)
+	return stream
+}
+
+// eval reads and evaluates the forms of text, which continues the input read
+// so far. A read error discards the rest of text.
+func (r *REPL) eval(text string) {
+	stream := r.input(text)
+	for {
+		form, err := lib.Read(r.env.NewHandler(core.SilentHandler), stream)
+		if err != nil {
+			if !core.InstanceOf(core.EndOfStreamClass, err) {
+				r.printError(r.env, err)
+			}
+			return
+		}
+		ret, err := lib.Eval(r.env, form)
+		if err != nil {
+			r.printError(r.env, err)
+			continue
+		}
+		fmt.Fprintln(r.out, ret)
+		for _, name := range []string{"***", "**", "*"} {
+			var value core.Instance = ret
+			if name != "*" {
+				value, _ = r.env.Variable.Get(core.NewSymbol(name[1:]))
+			}
+			r.env.Variable.Define(core.NewSymbol(name), value)
+		}
+	}
+}
+
+// complete returns the start of the name before pos in line and the names of
+// the functions, macros, special operators and variables it is a prefix of.
+func (r *REPL) complete(line []rune, pos int) (int, []string) {
+	start := pos
+	for start > 0 && isWord(line[start-1]) {
+		start--
+	}
+	prefix := strings.ToUpper(string(line[start:pos]))
+	if prefix == "" {
+		return start, nil
+	}
+	names := map[string]bool{}
+	for _, table := range [][]core.Instance{r.env.Function.Keys(), r.env.Macro.Keys(), r.env.Special.Keys(), r.env.Variable.Keys()} {
+		for _, key := range table {
+			if name := key.String(); strings.HasPrefix(name, prefix) {
+				names[name] = true
+			}
+		}
+	}
+	candidates := []string{}
+	for name := range names {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+	return start, candidates
+}
+
+// command runs a command, the text of a line after the leading comma. It
+// returns false for ,quit.
+func (r *REPL) command(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		fields = []string{"help"}
+	}
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	switch name {
+	case "quit", "q", "exit":
+		return false
+	case "help", "h", "?":
+		fmt.Fprintln(r.out, ",load FILE     load the forms of FILE")
+		fmt.Fprintln(r.out, ",describe NAME describe what NAME is bound to")
+		fmt.Fprintln(r.out, ",quit          leave the REPL")
+		fmt.Fprintln(r.out, ",help          print this list")
+	case "load", "l":
+		if len(args) != 1 {
+			fmt.Fprintln(r.out, "usage: ,load FILE")
+			break
+		}
+		Load(r.env, r.out, args[0])
+	case "describe", "d":
+		if len(args) != 1 {
+			fmt.Fprintln(r.out, "usage: ,describe NAME")
+			break
+		}
+		r.describe(args[0])
+	default:
+		fmt.Fprintf(r.out, "unknown command ,%s; type ,help for the commands\n", name)
+	}
+	return true
+}
+
+// describe prints what the name is bound to in each namespace.
+func (r *REPL) describe(name string) {
+	symbol, err := parser.ParseAtom(r.env.NewHandler(core.SilentHandler), tokenizer.NewToken(name, -1, -1))
+	if err != nil || !core.InstanceOf(core.SymbolClass, symbol) {
+		fmt.Fprintf(r.out, "%s is not a symbol\n", name)
+		return
+	}
+	found := false
+	if _, ok := r.env.Special.Get(symbol); ok {
+		fmt.Fprintf(r.out, "%v names a special operator\n", symbol)
+		found = true
+	}
+	if _, ok := r.env.Macro.Get(symbol); ok {
+		fmt.Fprintf(r.out, "%v names a macro\n", symbol)
+		found = true
+	}
+	if fun, ok := r.env.Function.Get(symbol); ok {
+		if core.InstanceOf(core.GenericFunctionClass, fun) {
+			fmt.Fprintf(r.out, "%v names a generic function\n", symbol)
+		} else {
+			fmt.Fprintf(r.out, "%v names a function\n", symbol)
+		}
+		found = true
+	}
+	if value, ok := r.env.Constant.Get(symbol); ok {
+		fmt.Fprintf(r.out, "%v names a constant whose value is %v\n", symbol, value)
+		found = true
+	}
+	if value, ok := r.env.Variable.Get(symbol); ok {
+		fmt.Fprintf(r.out, "%v names a global variable whose value is %v\n", symbol, value)
+		found = true
+	}
+	if value, ok := r.env.DynamicVariable.Get(symbol); ok {
+		fmt.Fprintf(r.out, "%v names a dynamic variable whose value is %v\n", symbol, value)
+		found = true
+	}
+	if class, ok := r.env.Class.Get(symbol); ok {
+		fmt.Fprintf(r.out, "%v names the class %v\n", symbol, class)
+		found = true
+	}
+	if !found {
+		fmt.Fprintf(r.out, "%v is not defined\n", symbol)
+	}
+}
+
+// printError prints an error signaled in e, showing the lines entered in the
+// REPL for the locations in no file.
+func (r *REPL) printError(e core.Environment, err core.Instance) {
+	printError(r.out, e, err, r.entered)
+}
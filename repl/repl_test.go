@@ -0,0 +1,168 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/lib"
+)
+
+func run(t *testing.T, input string) string {
+	t.Helper()
+	out := new(bytes.Buffer)
+	r := New(lib.NewRuntime(), strings.NewReader(input), out)
+	r.Quiet = true
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run() err = %v", err)
+	}
+	return out.String()
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"(car x)", true},
+		{"(car", false},
+		{"(car (cdr x)", false},
+		{"(car x))", true},
+		{"'", false},
+		{"'x", true},
+		{"#'", false},
+		{"#2a", false},
+		{"#\\a", true},
+		{"\"abc", false},
+		{"#| comment", false},
+		{"(car ; )\n", false},
+		{"x ; comment", true},
+	}
+	for _, tt := range tests {
+		if got := complete(tt.text); got != tt.want {
+			t.Errorf("complete(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestREPL(t *testing.T) {
+	got := run(t, "(+ 1\n 2)\n(list 1 2) 'a\n(list * ** ***)\n")
+	want := "3\n(1 2)\nA\n(A (1 2) 3)\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestREPLStandardInput(t *testing.T) {
+	got := run(t, "(read-line)\nfirst line\n(list (read) (read-line))\nsecond line\n(+ 1 2)\n")
+	want := "\"first line\"\n(SECOND \" line\")\n3\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestREPLRecovery(t *testing.T) {
+	got := run(t, "(car 1) 2\n(+ 1)) 3\n(list 4\n")
+	for _, want := range []string{
+		"<DOMAIN-ERROR>: 1 is not an instance of <CONS>\n",
+		"\n2\n1\n<PARSE-ERROR>",
+		"\n    2 | (+ 1)) 3\n",
+		"<PARSE-ERROR>: Cannot parse \"(\" as",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q in it", got, want)
+		}
+	}
+	if strings.Contains(got, "\n3\n") {
+		t.Errorf("output = %q, want the rest of line 2 discarded", got)
+	}
+}
+
+func TestREPLStackDepth(t *testing.T) {
+	got := run(t, "(defun f (n) (+ 1 (f n)))\n(f 0)\n(+ 1 2)\n")
+	for _, want := range []string{
+		"<STORAGE-EXHAUSTED>",
+		"  at F (2:1)\n",
+		" more frames\n",
+		"\n3\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q in it", got, want)
+		}
+	}
+}
+
+func TestREPLCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "a.lsp")
+	if err := ioutil.WriteFile(file, []byte("(defglobal repl-a 1)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := run(t, ",load "+file+"\nrepl-a\n,describe car\n,describe repl-a\n,describe let\n,bogus\n,quit\n(never)\n")
+	want := "1\n" +
+		"CAR names a function\n" +
+		"REPL-A names a global variable whose value is 1\n" +
+		"LET names a special operator\n" +
+		"unknown command ,bogus; type ,help for the commands\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintErrorSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "a.lsp")
+	for _, text := range []string{"(car 1)\n", "(cdr 2)\n"} {
+		if err := ioutil.WriteFile(file, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		if Load(lib.NewRuntime(), out, file) {
+			t.Fatalf("Load(%q) = true, want false", text)
+		}
+		if want := "    1 | " + text; !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, want %q in it", out, want)
+		}
+	}
+	done := make(chan string)
+	for _, input := range []string{"(car 1)\n", "1\n(cdr 2)\n"} {
+		go func(input string) {
+			out := new(bytes.Buffer)
+			r := New(lib.NewRuntime(), strings.NewReader(input), out)
+			r.Quiet = true
+			r.Run()
+			done <- out.String()
+		}(input)
+	}
+	got := []string{<-done, <-done}
+	if !strings.Contains(got[0]+got[1], "    1 | (car 1)\n") || !strings.Contains(got[0]+got[1], "    2 | (cdr 2)\n") {
+		t.Errorf("outputs = %q, want the lines entered in each REPL", got)
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+	r := New(lib.NewRuntime(), nil, ioutil.Discard)
+	start, got := r.complete([]rune("(funca"), 6)
+	if start != 1 || len(got) != 1 || got[0] != "FUNCALL" {
+		t.Errorf("complete() = %v, %v, want 1, [FUNCALL]", start, got)
+	}
+	start, got = r.complete([]rune("(list *mo"), 9)
+	if start != 6 || len(got) != 2 || got[0] != "*MOST-NEGATIVE-FLOAT*" {
+		t.Errorf("complete() = %v, %v, want 6, [*MOST-NEGATIVE-FLOAT* *MOST-POSITIVE-FLOAT*]", start, got)
+	}
+}
@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package repl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+)
+
+// sourceLine returns the line of source at loc. A location in no file is in
+// the lines entered. A file is read again for each error, so that its edits
+// are seen.
+func sourceLine(entered []string, loc *core.Location) (string, bool) {
+	lines := entered
+	if loc.File != "" {
+		b, err := ioutil.ReadFile(loc.File)
+		if err != nil {
+			return "", false
+		}
+		lines = strings.Split(string(b), "\n")
+	}
+	if loc.Line < 1 || len(lines) < loc.Line {
+		return "", false
+	}
+	return lines[loc.Line-1], true
+}
+
+// shownFrames is the number of frames shown at each end of a long stack trace,
+// such as the one of a <storage-exhausted>.
+const shownFrames = 10
+
+// PrintError prints the report of a condition followed by the line of source
+// where it was signaled, and by its stack trace.
+func PrintError(w io.Writer, e core.Environment, err core.Instance) {
+	printError(w, e, err, nil)
+}
+
+// printError is PrintError showing the lines entered for the locations in no
+// file.
+func printError(w io.Writer, e core.Environment, err core.Instance, entered []string) {
+	fmt.Fprintf(w, "%v: %s\n", err.Class(), lib.ReportString(e, err))
+	frames := core.Stacktrace(err)
+	for _, frame := range frames {
+		if frame.Location == nil {
+			continue
+		}
+		if line, ok := sourceLine(entered, frame.Location); ok {
+			prefix := fmt.Sprintf("%5d | ", frame.Location.Line)
+			fmt.Fprintf(w, "%v:\n", frame.Location)
+			fmt.Fprintf(w, "%s%s\n", prefix, line)
+			fmt.Fprintf(w, "%s^\n", strings.Repeat(" ", len(prefix)+frame.Location.Column-1))
+		}
+		break
+	}
+	for i, frame := range frames {
+		if i == shownFrames && len(frames) > 2*shownFrames {
+			fmt.Fprintf(w, "  ... %d more frames\n", len(frames)-2*shownFrames)
+		}
+		if i >= shownFrames && i < len(frames)-shownFrames {
+			continue
+		}
+		fmt.Fprintf(w, "  at %v\n", frame)
+	}
+}
+
+// Load evaluates the forms of the file at path in order with lib.LoadFile.
+// It stops at the first error, prints it to w and returns false.
+func Load(e core.Environment, w io.Writer, path string) bool {
+	if _, err := lib.LoadFile(e, path); err != nil {
+		PrintError(w, e, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+;; Examples of the section 15, Condition system, of ISO/IEC 13816:2007.
+(error "bad ~A" 1)
+;!! <simple-error>
+(with-handler (lambda (condition) (continue-condition condition 10))
+  (+ 1 (cerror "use a value" "bad")))
+;=> 11
+(catch 'handled
+  (with-handler (lambda (condition) (throw 'handled (instancep condition (class <domain-error>))))
+    (car 1)))
+;=> t
+(ignore-errors (car 1))
+;=> nil
+(unwind-protect
+    (catch 'x (throw 'x 1))
+  (format (standard-output) "cleanup~%"))
+;=> 1
+;>> cleanup
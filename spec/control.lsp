@@ -0,0 +1,34 @@
+;; Examples of the sections 9 and 11, Control structure and Declarations, of
+;; ISO/IEC 13816:2007.
+(defglobal x 5)
+(let ((x 2) (y x)) (list x y))
+;=> (2 5)
+(let* ((x 2) (y x)) (list x y))
+;=> (2 2)
+(if (> 3 2) 'yes 'no)
+;=> yes
+(cond ((> 3 3) 'greater) ((< 3 3) 'less) (t 'equal))
+;=> equal
+(case (* 2 3) ((2 3 5 7) 'prime) ((4 6 8 9) 'composite))
+;=> composite
+(block x (+ 10 (return-from x 6) 22))
+;=> 6
+(catch 'done (throw 'done 42) 'never)
+;=> 42
+(flet ((f (x) (+ x 3))) (f 4))
+;=> 7
+(labels ((evenp (n) (if (= n 0) t (oddp (- n 1))))
+         (oddp (n) (if (= n 0) nil (evenp (- n 1)))))
+  (evenp 88))
+;=> t
+(progn
+  (for ((i 0 (+ i 1))) ((= i 3)) (format (standard-output) "~A~%" i))
+  'done)
+;=> done
+;>> 0
+;>> 1
+;>> 2
+(assure <integer> "a")
+;!! <domain-error>
+(undefined-function-in-spec)
+;!! <undefined-function>
@@ -0,0 +1,25 @@
+;; Examples of the section 14, List class, of ISO/IEC 13816:2007.
+(cons 'a '())
+;=> (a)
+(cons '(a) '(b c d))
+;=> ((a) b c d)
+(car '(a b c))
+;=> a
+(cdr '(a b c))
+;=> (b c)
+(car 'a)
+;!! <domain-error>
+(list 'a (+ 3 4) 'c)
+;=> (a 7 c)
+(reverse '(a (b c) d (e (f))))
+;=> ((e (f)) d (b c) a)
+(append '(a b c) '(d e f))
+;=> (a b c d e f)
+(member 'c '(a b c d e f))
+;=> (c d e f)
+(mapcar #'car '((1 a) (2 b) (3 c)))
+;=> (1 2 3)
+(assoc 'b '((a . 1) (b . 2)))
+;=> (b . 2)
+(length '(a b c))
+;=> 3
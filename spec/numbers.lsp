@@ -0,0 +1,31 @@
+;; Examples of the section 12, Numbers, of ISO/IEC 13816:2007.
+(+ 12 3)
+;=> 15
+(- 4)
+;=> -4
+(* 12 3)
+;=> 36
+(= 3 4)
+;=> nil
+(< 3 4)
+;=> t
+(div 12 3)
+;=> 4
+(div -12 5)
+;=> -3
+(mod 12 5)
+;=> 2
+(mod -12 5)
+;=> 3
+(max 2 6 4)
+;=> 6
+(abs -3)
+;=> 3
+(gcd 12 5)
+;=> 1
+(lcm 14 35)
+;=> 70
+(div 1 0)
+;!! <division-by-zero>
+(+ 1 'a)
+;!! <domain-error>
@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package spec is the suite of the conformance of the interpreter to ISLisp,
+// as examples annotated with their values in the files of this directory.
+package spec
+
+import (
+	"testing"
+
+	"github.com/islisp-dev/iris/lisptest"
+)
+
+func TestSpec(t *testing.T) {
+	lisptest.Run(t, ".")
+}
@@ -0,0 +1,166 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package unit
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Passed reports whether all the tests passed.
+func Passed(results []Result) bool {
+	for _, r := range results {
+		if r.Status != Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// count returns the numbers of the results which failed and which signaled
+// errors.
+func count(results []Result) (failures, errors int) {
+	for _, r := range results {
+		switch r.Status {
+		case Fail:
+			failures++
+		case Error:
+			errors++
+		}
+	}
+	return failures, errors
+}
+
+// WriteText writes a line for each result, followed by the location and the
+// message of each failure, and a summary.
+func WriteText(w io.Writer, results []Result) error {
+	b := bufio.NewWriter(w)
+	for _, r := range results {
+		fmt.Fprintf(b, "--- %s: %s %s (%.3fs)\n", r.Status, r.File, r.FullName(), r.Duration.Seconds())
+		if r.Status != Pass {
+			fmt.Fprintf(b, "    %v: %s: %s\n", r.Location, r.Class, r.Message)
+		}
+	}
+	failures, errors := count(results)
+	fmt.Fprintf(b, "%d passed, %d failed, %d errors\n", len(results)-failures-errors, failures, errors)
+	return b.Flush()
+}
+
+// WriteTAP writes the results in the Test Anything Protocol, version 13. The
+// failures have a YAML block with their messages and locations.
+func WriteTAP(w io.Writer, results []Result) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "TAP version 13")
+	fmt.Fprintf(b, "1..%d\n", len(results))
+	for i, r := range results {
+		if r.Status == Pass {
+			fmt.Fprintf(b, "ok %d - %s %s\n", i+1, r.File, r.FullName())
+			continue
+		}
+		fmt.Fprintf(b, "not ok %d - %s %s\n", i+1, r.File, r.FullName())
+		fmt.Fprintln(b, "  ---")
+		fmt.Fprintf(b, "  message: %s\n", strconv.Quote(r.Message))
+		fmt.Fprintf(b, "  severity: %s\n", map[Status]string{Fail: "fail", Error: "error"}[r.Status])
+		fmt.Fprintf(b, "  class: %s\n", strconv.Quote(r.Class))
+		fmt.Fprintf(b, "  at: %s\n", strconv.Quote(r.Location.String()))
+		fmt.Fprintln(b, "  ...")
+	}
+	return b.Flush()
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the results as JUnit XML, with a test suite for each
+// file. The class name of a test is its suite, or its file outside of the
+// suites.
+func WriteJUnit(w io.Writer, results []Result) error {
+	report := junitSuites{Tests: len(results)}
+	report.Failures, report.Errors = count(results)
+	var total time.Duration
+	durations := []time.Duration{}
+	for _, r := range results {
+		n := len(report.Suites)
+		if n == 0 || report.Suites[n-1].Name != r.File {
+			report.Suites = append(report.Suites, junitSuite{Name: r.File})
+			durations = append(durations, 0)
+			n++
+		}
+		suite := &report.Suites[n-1]
+		c := junitCase{Name: r.Name, ClassName: r.Suite, File: r.File, Time: seconds(r.Duration)}
+		if c.ClassName == "" {
+			c.ClassName = r.File
+		}
+		if r.Location != nil {
+			c.Line = r.Location.Line
+		}
+		failure := &junitFailure{Message: r.Message, Type: r.Class, Text: fmt.Sprintf("%v: %s", r.Location, r.Message)}
+		switch r.Status {
+		case Fail:
+			c.Failure = failure
+			suite.Failures++
+		case Error:
+			c.Error = failure
+			suite.Errors++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+		durations[n-1] += r.Duration
+		total += r.Duration
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = seconds(durations[i])
+	}
+	report.Time = seconds(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,2 @@
+(deftest never-run t)
+(undefined-function-in-test-file)
@@ -0,0 +1,2 @@
+;; Not a file of tests, as its name does not end in -test.lsp.
+(deftest never-found (assert-true nil))
@@ -0,0 +1,20 @@
+(defglobal counter 0)
+
+(defsuite arithmetic
+  (:setup (setq counter (+ counter 1)))
+  (:teardown (setq counter (- counter 1))))
+
+(deftest addition
+  (assert-equal 1 counter)
+  (assert-equal 4 (+ 2 2)))
+
+(deftest subtraction
+  (assert-equal 3 (- 3 1)))
+
+(deftest division
+  (assert-error <division-by-zero> (div 1 0)))
+
+(in-suite nil)
+
+(deftest car-of-number
+  (car 1))
@@ -0,0 +1,2 @@
+(deftest reverse-list
+  (assert-equal '(3 2 1) (reverse '(1 2 3))))
@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package unit runs the tests defined by DEFTEST in ISLisp files, and reports
+// their results as text, TAP or JUnit XML.
+package unit
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/lib"
+)
+
+// Status is the outcome of a test.
+type Status int
+
+const (
+	Pass  Status = iota
+	Fail         // an assertion did not hold
+	Error        // another condition was signaled
+)
+
+func (s Status) String() string {
+	return [...]string{"PASS", "FAIL", "ERROR"}[s]
+}
+
+// Result is the result of a test, or of loading a file of tests.
+type Result struct {
+	File   string
+	Suite  string // empty outside of the suites
+	Name   string // LOAD for the loading of the file
+	Status Status
+	// Class and Message are the class and the report of the condition
+	// signaled, if the test did not pass.
+	Class    string
+	Message  string
+	Location *core.Location // where the condition was signaled, or the test
+	Duration time.Duration
+}
+
+// FullName returns the name of the test qualified by its suite.
+func (r Result) FullName() string {
+	if r.Suite == "" {
+		return r.Name
+	}
+	return r.Suite + "/" + r.Name
+}
+
+// Suffix is the end of the names of the files of tests.
+const Suffix = "-test.lsp"
+
+// Find returns the files of tests in paths, in order. A directory is searched
+// recursively for the files whose names end in Suffix; a file is taken as is.
+func Find(paths ...string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		found := []string{}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(info.Name(), Suffix) {
+				found = append(found, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// RunFile loads the file in a fresh runtime, whose output goes to w, and runs
+// the tests it defines. If the file fails to load, the result of the loading
+// is the only one.
+func RunFile(path string, w io.Writer) []Result {
+	e := lib.NewRuntime()
+	e.StandardOutput = core.NewStream(nil, w, core.CharacterClass)
+	e.ErrorOutput = core.NewStream(nil, w, core.CharacterClass)
+	start := time.Now()
+	if _, err := lib.LoadFile(e, path); err != nil {
+		result := Result{File: path, Name: "LOAD", Duration: time.Since(start)}
+		result.failed(e, err)
+		return []Result{result}
+	}
+	return Run(e, path)
+}
+
+// Run runs the tests defined in the runtime of e, in order, and returns their
+// results as those of the file.
+func Run(e core.Environment, file string) []Result {
+	results := []Result{}
+	for _, test := range e.Runtime.Tests() {
+		result := Result{File: file, Name: test.Name.String(), Location: test.Location}
+		if test.Suite != nil {
+			result.Suite = test.Suite.Name.String()
+		}
+		start := time.Now()
+		err := lib.RunTest(e, test)
+		result.Duration = time.Since(start)
+		if err != nil {
+			result.failed(e, err)
+		}
+		results = append(results, result)
+		flush(e)
+	}
+	return results
+}
+
+// failed records the condition err the test failed with.
+func (r *Result) failed(e core.Environment, err core.Instance) {
+	r.Status = Error
+	if core.InstanceOf(core.AssertionFailureClass, err) {
+		r.Status = Fail
+	}
+	r.Class = err.Class().String()
+	r.Message = lib.ReportString(e, err)
+	for _, frame := range core.Stacktrace(err) {
+		if frame.Location != nil {
+			r.Location = frame.Location
+			break
+		}
+	}
+	flush(e)
+}
+
+func flush(e core.Environment) {
+	for _, s := range []core.Instance{e.StandardOutput, e.ErrorOutput} {
+		if stream, ok := s.(core.Stream); ok && stream.BufferedWriter != nil && stream.BufferedWriter.Raw != nil {
+			stream.Flush()
+		}
+	}
+}
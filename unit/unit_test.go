@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public License,
+// v. 2.0. If a copy of the MPL was not distributed with this file, You can
+// obtain one at http://mozilla.org/MPL/2.0/.
+
+package unit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func run(t *testing.T) []Result {
+	t.Helper()
+	files, err := Find("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"testdata/broken-test.lsp", "testdata/math-test.lsp", "testdata/nested/list-test.lsp"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("Find() = %v, want %v", files, want)
+	}
+	results := []Result{}
+	for _, file := range files {
+		results = append(results, RunFile(file, ioutil.Discard)...)
+	}
+	return results
+}
+
+func TestRunFile(t *testing.T) {
+	results := run(t)
+	want := []struct {
+		name     string
+		status   Status
+		location string
+		message  string
+	}{
+		{"LOAD", Error, "testdata/broken-test.lsp:2:2", "The function UNDEFINED-FUNCTION-IN-TEST-FILE is undefined"},
+		{"ARITHMETIC/ADDITION", Pass, "testdata/math-test.lsp:7:10", ""},
+		{"ARITHMETIC/SUBTRACTION", Fail, "testdata/math-test.lsp:12:3", "(- 3 1) returned 2, expected 3"},
+		{"ARITHMETIC/DIVISION", Pass, "testdata/math-test.lsp:14:10", ""},
+		{"CAR-OF-NUMBER", Error, "testdata/math-test.lsp:20:3", "1 is not an instance of <CONS>"},
+		{"REVERSE-LIST", Pass, "testdata/nested/list-test.lsp:1:10", ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.FullName() != w.name || r.Status != w.status || r.Location.String() != w.location || r.Message != w.message {
+			t.Errorf("result %d = %s %v at %v: %q, want %s %v at %v: %q", i, r.FullName(), r.Status, r.Location, r.Message, w.name, w.status, w.location, w.message)
+		}
+	}
+	if Passed(results) {
+		t.Errorf("Passed() = true, want false")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	results := run(t)
+	out := new(bytes.Buffer)
+	if err := WriteText(out, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"--- FAIL: testdata/math-test.lsp ARITHMETIC/SUBTRACTION (",
+		"    testdata/math-test.lsp:12:3: <ASSERTION-FAILURE>: (- 3 1) returned 2, expected 3\n",
+		"\n3 passed, 1 failed, 2 errors\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteText() = %q, want %q in it", out, want)
+		}
+	}
+	out.Reset()
+	if err := WriteTAP(out, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"TAP version 13\n1..6\nnot ok 1 - testdata/broken-test.lsp LOAD\n",
+		"ok 2 - testdata/math-test.lsp ARITHMETIC/ADDITION\n",
+		"not ok 3 - testdata/math-test.lsp ARITHMETIC/SUBTRACTION\n  ---\n  message: \"(- 3 1) returned 2, expected 3\"\n  severity: fail\n",
+		"  at: \"testdata/math-test.lsp:12:3\"\n  ...\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteTAP() = %q, want %q in it", out, want)
+		}
+	}
+	out.Reset()
+	if err := WriteJUnit(out, results); err != nil {
+		t.Fatal(err)
+	}
+	var report junitSuites
+	if err := xml.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("WriteJUnit() = %q: %v", out, err)
+	}
+	if report.Tests != 6 || report.Failures != 1 || report.Errors != 2 || len(report.Suites) != 3 {
+		t.Fatalf("WriteJUnit() = %q, want 6 tests, 1 failure and 2 errors in 3 suites", out)
+	}
+	c := report.Suites[1].Cases[1]
+	if c.ClassName != "ARITHMETIC" || c.Name != "SUBTRACTION" || c.Failure == nil || c.Failure.Type != "<ASSERTION-FAILURE>" {
+		t.Errorf("WriteJUnit() case = %+v, want the failure of ARITHMETIC SUBTRACTION", c)
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 type ValidationError struct {
-	Actual core.Instance
+	Actual   core.Instance
 	Expected core.Instance
 }
 
@@ -14,14 +14,22 @@ func (err ValidationError) Error() string {
 	return "Validation Error"
 }
 
-type Validator = func (core.Instance) error
+// actual returns the object err is about if it is a ValidationError.
+func actual(err error) (core.Instance, bool) {
+	if err, ok := err.(ValidationError); ok {
+		return err.Actual, true
+	}
+	return nil, false
+}
+
+type Validator = func(core.Instance) error
 
 func Any(_ core.Instance) error {
 	return nil
 }
 
 func InstanceOf(class core.Class) Validator {
-	return func (instance core.Instance) error {
+	return func(instance core.Instance) error {
 		ok := core.InstanceOf(class, instance)
 		if !ok {
 			return ValidationError{instance, class}
@@ -32,10 +40,10 @@ func InstanceOf(class core.Class) Validator {
 
 func Symbol(pattern string) Validator {
 	re := regexp2.MustCompile(pattern, 0)
-	return func (instance core.Instance) error {
+	return func(instance core.Instance) error {
 		symbol, ok := instance.(core.Symbol)
 		if !ok {
-			return ValidationError{instance, core.SymbolClass} 
+			return ValidationError{instance, core.SymbolClass}
 		}
 		ok, err := re.MatchString(symbol.String())
 		if err != nil || !ok {
@@ -45,19 +53,44 @@ func Symbol(pattern string) Validator {
 	}
 }
 
+// SymbolNamed validates a symbol named one of names.
+func SymbolNamed(names ...string) Validator {
+	return func(instance core.Instance) error {
+		if symbol, ok := instance.(core.Symbol); ok {
+			for _, name := range names {
+				if symbol.String() == name {
+					return nil
+				}
+			}
+		}
+		return ValidationError{instance, core.SymbolClass}
+	}
+}
+
+// Or validates an instance valid for one of the validators. If there is
+// none, the error of the first validator failing on a part of the instance
+// rather than on the instance itself is returned, as it is the most precise.
 func Or(validators ...Validator) Validator {
-	return func (instance core.Instance) error {
+	return func(instance core.Instance) error {
+		var inner error
 		for _, validator := range validators {
-			if err := validator(instance); err == nil {
+			err := validator(instance)
+			if err == nil {
 				return nil
 			}
+			if a, ok := actual(err); inner == nil && ok && a != instance {
+				inner = err
+			}
+		}
+		if inner != nil {
+			return inner
 		}
 		return ValidationError{instance, core.ObjectClass}
 	}
 }
 
 func And(validators ...Validator) Validator {
-	return func (instance core.Instance) error {
+	return func(instance core.Instance) error {
 		for _, validator := range validators {
 			if err := validator(instance); err != nil {
 				return err
@@ -68,7 +101,7 @@ func And(validators ...Validator) Validator {
 }
 
 func Not(validator Validator) Validator {
-	return func (instance core.Instance) error {
+	return func(instance core.Instance) error {
 		if err := validator(instance); err != nil {
 			return nil
 		}
@@ -76,23 +109,27 @@ func Not(validator Validator) Validator {
 	}
 }
 
+// List validates a proper list whose elements are validated in order by the
+// validators, one each. An error is about the first invalid element, the
+// first extra element, or the list if it is too short.
 func List(validators ...Validator) Validator {
-	return func (args core.Instance) error {
-		if len(validators) == 0 && args == core.Nil {
-			return nil
-		}
-		if len(validators) == 0 || args == core.Nil {
-			return ValidationError{args, core.ListClass}
-		}
-		cons, ok := args.(*core.Cons)
-		if !ok {
-			return ValidationError{args, core.ConsClass}
+	return func(args core.Instance) error {
+		rest := args
+		for _, validator := range validators {
+			cons, ok := rest.(*core.Cons)
+			if !ok {
+				return ValidationError{args, core.ListClass}
+			}
+			if err := validator(cons.Car); err != nil {
+				return err
+			}
+			rest = cons.Cdr
 		}
-		if err := validators[0](cons.Car); err != nil {
-			return err
+		if cons, ok := rest.(*core.Cons); ok {
+			return ValidationError{cons.Car, core.NullClass}
 		}
-		if err := List(validators...)(cons.Cdr); err != nil {
-			return err
+		if rest != core.Nil {
+			return ValidationError{args, core.ListClass}
 		}
 		return nil
 	}
@@ -100,48 +137,64 @@ func List(validators ...Validator) Validator {
 
 var Nil = List()
 
+// Append validates a proper list which is the concatenation of lists
+// validated in order by the validators. When the list is invalid, the error
+// returned is that of the longest valid prefix for validators[0], or that of
+// validators[0] on the whole list if there is none.
 func Append(validators ...Validator) Validator {
-	return func (args core.Instance) error {
+	return func(args core.Instance) error {
 		if len(validators) == 0 {
-			return nil
+			return Nil(args)
 		}
+		prefix := []core.Instance{}
 		rest := args
+		var last error
 		for {
+			err := validators[0](list(prefix))
+			if err == nil {
+				if err = Append(validators[1:]...)(rest); err == nil {
+					return nil
+				}
+				last = err
+			}
 			cons, ok := rest.(*core.Cons)
 			if !ok {
-				return ValidationError{rest, core.ConsClass}
-			}
-			rest := cons.Cdr
-			cons.Cdr = core.Nil
-			if err := validators[0](args); err != nil {
-				cons.Cdr = rest
-				continue
-			}
-			if err := Append(validators...)(rest); err != nil {
-				cons.Cdr = rest
-				continue
+				if last == nil {
+					last = err
+				}
+				if _, ok := actual(last); ok && rest != core.Nil {
+					return ValidationError{args, core.ListClass}
+				}
+				return last
 			}
-			return nil
+			prefix = append(prefix, cons.Car)
+			rest = cons.Cdr
 		}
 	}
 }
 
-func Repeat(inner Validator) (outer Validator) {
-	outer = func (args core.Instance) error {
-		if args == core.Nil {
-			return nil
-		}
-		cons, ok := args.(*core.Cons)
-		if !ok {
-			return ValidationError{args, core.ConsClass} 
-		}
-		if err := inner(cons.Car); err != nil {
-			return err
-		}
-		if err := outer(cons.Cdr); err != nil {
-			return err
+func list(elements []core.Instance) core.Instance {
+	var l core.Instance = core.Nil
+	for i := len(elements) - 1; i >= 0; i-- {
+		l = core.NewCons(elements[i], l)
+	}
+	return l
+}
+
+// Repeat validates a proper list whose elements are all valid for inner.
+func Repeat(inner Validator) Validator {
+	return func(args core.Instance) error {
+		rest := args
+		for rest != core.Nil {
+			cons, ok := rest.(*core.Cons)
+			if !ok {
+				return ValidationError{args, core.ListClass}
+			}
+			if err := inner(cons.Car); err != nil {
+				return err
+			}
+			rest = cons.Cdr
 		}
 		return nil
 	}
-	return
 }
@@ -0,0 +1,56 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/islisp-dev/iris/core"
+	"github.com/islisp-dev/iris/reader/parser"
+	"github.com/islisp-dev/iris/reader/tokenizer"
+)
+
+func read(t *testing.T, src string) core.Instance {
+	e := core.NewEnvironment(nil, nil, nil, core.DefaultHandler)
+	obj, err := parser.Parse(e, tokenizer.NewBufferedTokenReader(strings.NewReader(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestValidator(t *testing.T) {
+	symbol := InstanceOf(core.SymbolClass)
+	integer := InstanceOf(core.IntegerClass)
+	tests := []struct {
+		name      string
+		validator Validator
+		src       string
+		ok        bool
+	}{
+		{"List", List(symbol, integer), `(a 1)`, true},
+		{"List", List(symbol, integer), `(a b)`, false},
+		{"List", List(symbol, integer), `(a 1 2)`, false},
+		{"List", List(symbol, integer), `(a)`, false},
+		{"Nil", Nil, `()`, true},
+		{"Repeat", Repeat(integer), `(1 2 3)`, true},
+		{"Repeat", Repeat(integer), `(1 a 3)`, false},
+		{"Append", Append(Repeat(integer), List(symbol)), `(1 2 a)`, true},
+		{"Append", Append(Repeat(integer), List(symbol)), `(a)`, true},
+		{"Append", Append(Repeat(integer), List(symbol)), `(1 2)`, false},
+		{"Append", Append(List(symbol), Repeat(Any)), `(a 1 b)`, true},
+		{"Append", Append(List(symbol), Repeat(Any)), `(1 b)`, false},
+		{"Or", Or(Nil, List(integer)), `(1)`, true},
+		{"Not", Not(integer), `a`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.src, func(t *testing.T) {
+			obj, want := read(t, tt.src), read(t, tt.src)
+			if err := tt.validator(obj); (err == nil) != tt.ok {
+				t.Errorf("%s(%v) = %v, want ok %v", tt.name, tt.src, err, tt.ok)
+			}
+			if !core.DeepEqual(obj, want) {
+				t.Errorf("%s(%v) modified the form to %v", tt.name, tt.src, obj)
+			}
+		})
+	}
+}